@@ -11,6 +11,7 @@ import (
 	"go/types"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strconv"
@@ -60,6 +61,13 @@ func Build(pkgName, outpath string, config *compileopts.Config, action func(Buil
 		DefaultStackSize:   config.Target.DefaultStackSize,
 		NeedsStackObjects:  config.NeedsStackObjects(),
 		Debug:              config.Debug(),
+		WasmABI:            config.WasmAbi(),
+		TrimPath:           config.Options.TrimPath,
+	}
+
+	if config.Options.BuildID && (config.GOOS() == "darwin" || config.GOARCH() == "wasm") {
+		// Mach-O and WebAssembly files don't have ELF note sections.
+		return errors.New("-build-id is only supported for ELF targets")
 	}
 
 	// Load the target machine, which is the LLVM object that contains all
@@ -82,22 +90,103 @@ func Build(pkgName, outpath string, config *compileopts.Config, action func(Buil
 		return err
 	}
 
+	// Set string globals from the command line (-ldflags="-X ...") and the
+	// build information for runtime/debug.ReadBuildInfo.
+	compilerConfig.GlobalValues = make(map[string]map[string]string)
+	for pkgPath, values := range config.Options.GlobalValues {
+		compilerConfig.GlobalValues[pkgPath] = values
+	}
+	if modinfo := makeModInfo(config, lprogram); modinfo != "" {
+		values := map[string]string{"modinfo": modinfo}
+		for name, value := range compilerConfig.GlobalValues["runtime/debug"] {
+			values[name] = value
+		}
+		compilerConfig.GlobalValues["runtime/debug"] = values
+	}
+
+	// Create a temporary directory for intermediary files.
+	dir, err := ioutil.TempDir("", "tinygo")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
 	// The slice of jobs that orchestrates most of the build.
 	// This is somewhat like an in-memory Makefile with each job being a
 	// Makefile target.
 	var jobs []*compileJob
 
-	// Add job to compile and optimize all Go files at once.
-	// TODO: parallelize this.
+	// Collect all C and assembly files that need to be compiled.
+	cFiles, err := collectCFiles(config, lprogram, dir)
+	if err != nil {
+		return err
+	}
+
+	// With -lto, C files are compiled to LLVM bitcode and linked into the Go
+	// module before it is optimized, instead of being compiled to object
+	// files that are passed to the linker.
+	var bitcodeJobs []*compileJob
+	var bitcodeFiles []string
+	if config.Options.LTO {
+		var objectFiles []cFile
+		for i, file := range cFiles {
+			if filepath.Ext(file.path) != ".c" {
+				// Assembly files can't be compiled to bitcode.
+				objectFiles = append(objectFiles, file)
+				continue
+			}
+			file := file
+			outpath := filepath.Join(dir, "c"+strconv.Itoa(i)+"-"+filepath.Base(file.path)+".bc")
+			job := &compileJob{
+				description: file.description,
+				run: func() error {
+					err := runCCompiler(config.Target.Compiler, append(file.cflags, "-c", "-emit-llvm", "-o", outpath, file.path)...)
+					if err != nil {
+						return &commandError{"failed to build", file.path, err}
+					}
+					return nil
+				},
+			}
+			jobs = append(jobs, job)
+			bitcodeJobs = append(bitcodeJobs, job)
+			bitcodeFiles = append(bitcodeFiles, outpath)
+		}
+		cFiles = objectFiles
+	}
+
+	// Add job to compile and optimize all Go files at once. The compiler
+	// itself compiles packages in parallel.
 	var mod llvm.Module
 	var stackSizeLoads []string
 	programJob := &compileJob{
-		description: "compile Go files",
+		description:  "compile Go files",
+		dependencies: bitcodeJobs,
 		run: func() (err error) {
-			mod, err = compileWholeProgram(pkgName, config, compilerConfig, lprogram, machine)
+			mod, err = compileWholeProgram(pkgName, config, compilerConfig, lprogram, machine, bitcodeFiles)
 			if err != nil {
 				return
 			}
+			// Write the interface descriptor of canonical exports and imports
+			// next to the output file, for generating host bindings.
+			if config.WasmAbi() == "canonical" && outpath != "" {
+				descriptorPath := strings.TrimSuffix(outpath, filepath.Ext(outpath)) + ".abi.json"
+				err = ioutil.WriteFile(descriptorPath, compiler.CanonicalABIDescriptor(mod), 0666)
+				if err != nil {
+					return
+				}
+			}
+			// Write an ES module to load the program from JavaScript, with
+			// TypeScript declarations for the exported functions.
+			if config.Options.JSLoader {
+				if config.GOOS() != "js" || filepath.Ext(outpath) != ".wasm" {
+					err = errors.New("-js-loader is only supported when building a .wasm file for GOOS=js")
+					return
+				}
+				err = writeJSLoader(outpath, config.WasmAbi(), compiler.WasmExports(mod))
+				if err != nil {
+					return
+				}
+			}
 			// Make sure stack sizes are loaded from a separate section so they can be
 			// modified after linking.
 			if config.AutomaticStackSize() {
@@ -140,13 +229,6 @@ func Build(pkgName, outpath string, config *compileopts.Config, action func(Buil
 	// First add all jobs necessary to build this object file, then afterwards
 	// run all jobs in parallel as far as possible.
 
-	// Create a temporary directory for intermediary files.
-	dir, err := ioutil.TempDir("", "tinygo")
-	if err != nil {
-		return err
-	}
-	defer os.RemoveAll(dir)
-
 	// Add job to write the output object file.
 	objfile := filepath.Join(dir, "main.o")
 	outputObjectFileJob := &compileJob{
@@ -212,18 +294,16 @@ func Build(pkgName, outpath string, config *compileopts.Config, action func(Buil
 		return fmt.Errorf("unknown libc: %s", config.Target.Libc)
 	}
 
-	// Add jobs to compile extra files. These files are in C or assembly and
-	// contain things like the interrupt vector table and low level operations
-	// such as stack switching.
-	for i, path := range config.ExtraFiles() {
-		abspath := filepath.Join(root, path)
-		outpath := filepath.Join(dir, "extra-"+strconv.Itoa(i)+"-"+filepath.Base(path)+".o")
+	// Add jobs to compile the remaining C and assembly files to object files.
+	for i, file := range cFiles {
+		file := file
+		outpath := filepath.Join(dir, "c"+strconv.Itoa(i)+"-"+filepath.Base(file.path)+".o")
 		job := &compileJob{
-			description: "compile extra file " + path,
+			description: file.description,
 			run: func() error {
-				err := runCCompiler(config.Target.Compiler, append(config.CFlags(), "-c", "-o", outpath, abspath)...)
+				err := runCCompiler(config.Target.Compiler, append(file.cflags, "-c", "-o", outpath, file.path)...)
 				if err != nil {
-					return &commandError{"failed to build", path, err}
+					return &commandError{"failed to build", file.path, err}
 				}
 				return nil
 			},
@@ -233,29 +313,6 @@ func Build(pkgName, outpath string, config *compileopts.Config, action func(Buil
 		ldflags = append(ldflags, outpath)
 	}
 
-	// Add jobs to compile C files in all packages. This is part of CGo.
-	// TODO: do this as part of building the package to be able to link the
-	// bitcode files together.
-	for i, pkg := range lprogram.Sorted() {
-		for j, filename := range pkg.CFiles {
-			file := filepath.Join(pkg.Dir, filename)
-			outpath := filepath.Join(dir, "pkg"+strconv.Itoa(i)+"."+strconv.Itoa(j)+"-"+filepath.Base(file)+".o")
-			job := &compileJob{
-				description: "compile CGo file " + file,
-				run: func() error {
-					err := runCCompiler(config.Target.Compiler, append(config.CFlags(), "-c", "-o", outpath, file)...)
-					if err != nil {
-						return &commandError{"failed to build", file, err}
-					}
-					return nil
-				},
-			}
-			jobs = append(jobs, job)
-			linkerDependencies = append(linkerDependencies, job)
-			ldflags = append(ldflags, outpath)
-		}
-	}
-
 	// Linker flags from CGo lines:
 	//     #cgo LDFLAGS: foo
 	if len(lprogram.LDFlags) > 0 {
@@ -273,6 +330,30 @@ func Build(pkgName, outpath string, config *compileopts.Config, action func(Buil
 				return &commandError{"failed to link", executable, err}
 			}
 
+			if config.Scheduler() == "tasks" && strings.HasPrefix(config.Triple(), "wasm") {
+				// Instrument the binary with asyncify, which is used to switch
+				// between goroutines (see src/internal/task/task_asyncify.go).
+				// Imports never unwind the stack so they can be ignored.
+				args := []string{"--asyncify", "--pass-arg=asyncify-ignore-imports", "-O" + config.Options.Opt}
+				if config.Debug() {
+					args = append(args, "-g")
+				}
+				args = append(args, executable, "--output", executable)
+				err = execCommand(commands["wasm-opt"], args...)
+				if err != nil {
+					return &commandError{"failed to run asyncify on", executable, err}
+				}
+			}
+
+			if config.Triple() == "wasm32-unknown-unknown" {
+				// There is no standard set of imports on this target, so the
+				// host only provides what the program declared.
+				err = checkWasmImports(executable, mod)
+				if err != nil {
+					return err
+				}
+			}
+
 			var calculatedStacks []string
 			var stackSizes map[string]functionStackSize
 			if config.Options.PrintStacks || config.AutomaticStackSize() {
@@ -329,14 +410,15 @@ func Build(pkgName, outpath string, config *compileopts.Config, action func(Buil
 		return err
 	}
 
-	// Get an Intel .hex file or .bin file from the .elf file.
+	// Get an Intel .hex file, .bin file or other firmware format from the .elf
+	// file. Keep this list in sync with compileopts.BinaryFormats.
 	outputBinaryFormat := config.BinaryFormat(outext)
 	switch outputBinaryFormat {
 	case "elf":
 		// do nothing, file is already in ELF format
-	case "hex", "bin":
-		// Extract raw binary, either encoding it as a hex file or as a raw
-		// firmware file.
+	case "hex", "bin", "srec":
+		// Extract raw binary, either encoding it as a hex file, an S-record
+		// file or as a raw firmware file.
 		tmppath = filepath.Join(dir, "main"+outext)
 		err := objcopy(executable, tmppath, outputBinaryFormat)
 		if err != nil {
@@ -349,6 +431,27 @@ func Build(pkgName, outpath string, config *compileopts.Config, action func(Buil
 		if err != nil {
 			return err
 		}
+	case "dfuse":
+		// DfuSe file, for STM32 chips with a USB DFU bootloader.
+		tmppath = filepath.Join(dir, "main"+outext)
+		err := convertELFFileToDfuSeFile(executable, tmppath)
+		if err != nil {
+			return err
+		}
+	case "nrf-dfu":
+		// Zip package for the Nordic (legacy) DFU bootloader.
+		tmppath = filepath.Join(dir, "main"+outext)
+		err := convertELFFileToNRFDFUFile(executable, tmppath)
+		if err != nil {
+			return err
+		}
+	case "mcuboot":
+		// Image for the MCUboot bootloader, optionally signed.
+		tmppath = filepath.Join(dir, "main"+outext)
+		err := convertELFFileToMCUbootFile(executable, tmppath, config.SigningKey(), config.ImageVersion(), config.Target.ImageHeaderSize)
+		if err != nil {
+			return err
+		}
 	case "esp32", "esp8266":
 		// Special format for the ESP family of chips (parsed by the ROM
 		// bootloader).
@@ -366,9 +469,104 @@ func Build(pkgName, outpath string, config *compileopts.Config, action func(Buil
 	})
 }
 
+// cFile is a C or assembly file that is compiled as part of the build, with
+// the flags to compile it with.
+type cFile struct {
+	path        string
+	cflags      []string
+	description string
+}
+
+// collectCFiles returns all C and assembly files that are part of the build:
+// the extra files of the target and the C files of all packages (CGo). Headers
+// needed to compile them are written to dir.
+func collectCFiles(config *compileopts.Config, lprogram *loader.Program, dir string) ([]cFile, error) {
+	var files []cFile
+
+	// Extra files are in C or assembly and contain things like the interrupt
+	// vector table and low level operations such as stack switching.
+	root := goenv.Get("TINYGOROOT")
+	for _, path := range config.ExtraFiles() {
+		cflags := config.CFlags()
+		if config.Options.TrimPath {
+			cflags = append(cflags, trimPathFlags(dir, root, "tinygo")...)
+		}
+		files = append(files, cFile{
+			path:        filepath.Join(root, path),
+			cflags:      cflags,
+			description: "compile extra file " + path,
+		})
+	}
+
+	// C files in packages are part of CGo.
+	for i, pkg := range lprogram.Sorted() {
+		if len(pkg.CFiles) == 0 {
+			continue
+		}
+
+		// C files may include _cgo_export.h to call functions exported with
+		// //export, so write it to a directory that is in the include path.
+		pkgCFlags := append([]string{"-I" + pkg.Dir}, pkg.CFlags...)
+		if pkg.ExportHeader != "" {
+			headerDir := filepath.Join(dir, "pkg"+strconv.Itoa(i)+"-include")
+			err := os.Mkdir(headerDir, 0777)
+			if err != nil {
+				return nil, err
+			}
+			err = ioutil.WriteFile(filepath.Join(headerDir, "_cgo_export.h"), []byte(pkg.ExportHeader), 0666)
+			if err != nil {
+				return nil, err
+			}
+			pkgCFlags = append(pkgCFlags, "-I"+headerDir)
+		}
+
+		for _, filename := range pkg.CFiles {
+			file := filepath.Join(pkg.Dir, filename)
+			cflags := append(config.CFlags(), pkgCFlags...)
+			if config.Options.TrimPath {
+				cflags = append(cflags, trimPathFlags(dir, pkg.Dir, path.Dir(lprogram.TrimPath(file)))...)
+			}
+			files = append(files, cFile{
+				path:        file,
+				cflags:      cflags,
+				description: "compile CGo file " + file,
+			})
+		}
+	}
+	return files, nil
+}
+
+// linkBitcodeFile links the LLVM bitcode file at path (usually a C file
+// compiled with -emit-llvm) into the module. It returns the names of the
+// functions and global variables defined in the bitcode file.
+func linkBitcodeFile(mod llvm.Module, path string) ([]string, error) {
+	buf, err := llvm.NewMemoryBufferFromFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ctx := mod.Context()
+	bitcodeMod, err := ctx.ParseIR(buf)
+	if err != nil {
+		return nil, fmt.Errorf("could not load bitcode of %s (is the C compiler based on the same LLVM version?): %w", path, err)
+	}
+	var names []string
+	for fn := bitcodeMod.FirstFunction(); !fn.IsNil(); fn = llvm.NextFunction(fn) {
+		if !fn.IsDeclaration() {
+			names = append(names, fn.Name())
+		}
+	}
+	for global := bitcodeMod.FirstGlobal(); !global.IsNil(); global = llvm.NextGlobal(global) {
+		if !global.IsDeclaration() {
+			names = append(names, global.Name())
+		}
+	}
+	return names, llvm.LinkModules(mod, bitcodeMod)
+}
+
 // compileWholeProgram compiles the entire *loader.Program to a LLVM module and
-// applies most necessary optimizations and transformations.
-func compileWholeProgram(pkgName string, config *compileopts.Config, compilerConfig *compiler.Config, lprogram *loader.Program, machine llvm.TargetMachine) (llvm.Module, error) {
+// applies most necessary optimizations and transformations. The given bitcode
+// files are linked into the module before it is optimized.
+func compileWholeProgram(pkgName string, config *compileopts.Config, compilerConfig *compiler.Config, lprogram *loader.Program, machine llvm.TargetMachine, bitcodeFiles []string) (llvm.Module, error) {
 	// Compile AST to IR.
 	mod, errs := compiler.CompileProgram(lprogram, machine, compilerConfig, config.DumpSSA())
 	if errs != nil {
@@ -383,14 +581,42 @@ func compileWholeProgram(pkgName string, config *compileopts.Config, compilerCon
 		return mod, errors.New("verification error after IR construction")
 	}
 
-	err := interp.Run(mod, config.DumpSSA())
+	report, err := interp.RunWithReport(mod, config.DumpSSA())
 	if err != nil {
 		return mod, err
 	}
+	if config.Options.PrintInterp {
+		report.Print(os.Stdout)
+	}
 	if err := llvm.VerifyModule(mod, llvm.PrintMessageAction); err != nil {
 		return mod, errors.New("verification error after interpreting runtime.initAll")
 	}
 
+	// Link C code compiled to bitcode (-lto), so that it is optimized together
+	// with the Go code. This allows small C functions to be inlined in Go code
+	// and the other way around.
+	var cNames []string
+	for _, path := range bitcodeFiles {
+		names, err := linkBitcodeFile(mod, path)
+		if err != nil {
+			return mod, err
+		}
+		cNames = append(cNames, names...)
+	}
+	if len(bitcodeFiles) != 0 {
+		// The C code is now part of the module, so unless the linker needs
+		// them, its symbols can be internal. That allows the optimizer to
+		// remove unused C functions.
+		transform.InternalizeSymbols(mod, cNames)
+		if err := llvm.VerifyModule(mod, llvm.PrintMessageAction); err != nil {
+			return mod, errors.New("verification error after linking C bitcode files")
+		}
+	}
+
+	// Globals that are only written during package initialization can now be
+	// put in flash instead of RAM.
+	transform.MakeGlobalsConstant(mod)
+
 	if config.GOOS() != "darwin" {
 		transform.ApplyFunctionSections(mod) // -ffunction-sections
 	}
@@ -437,6 +663,9 @@ func compileWholeProgram(pkgName string, config *compileopts.Config, compilerCon
 	if err := llvm.VerifyModule(mod, llvm.PrintMessageAction); err != nil {
 		return mod, errors.New("verification failure after LLVM optimization passes")
 	}
+	if config.Options.PrintReach != "" {
+		transform.PrintReachability(os.Stdout, mod, config.Options.PrintReach)
+	}
 
 	// LLVM 11 by default tries to emit tail calls (even with the target feature
 	// disabled) unless it is explicitly disabled with a function attribute.
@@ -574,6 +803,23 @@ func determineStackSizes(mod llvm.Module, executable string) ([]string, map[stri
 	return gowrappers, sizes, nil
 }
 
+// trimPathFlags returns the C compiler flags to remove machine-specific paths
+// from debug information and __FILE__ macros, for -trimpath. The source
+// directory is replaced with the given trimmed directory, the temporary
+// directory of this build with "tmp" and TINYGOROOT with "tinygo". The
+// compilation directory (the current working directory) is replaced with ".".
+func trimPathFlags(tmpdir, sourceDir, trimmedDir string) []string {
+	flags := []string{
+		"-ffile-prefix-map=" + tmpdir + "=tmp",
+		"-ffile-prefix-map=" + goenv.Get("TINYGOROOT") + "=tinygo",
+		"-Xclang", "-fdebug-compilation-dir", "-Xclang", ".",
+	}
+	if sourceDir != goenv.Get("TINYGOROOT") {
+		flags = append(flags, "-ffile-prefix-map="+sourceDir+"="+trimmedDir)
+	}
+	return flags
+}
+
 // modifyStackSizes modifies the .tinygo_stacksizes section with the updated
 // stack size information. Before this modification, all stack sizes in the
 // section assume the default stack size (which is relatively big).
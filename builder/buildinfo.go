@@ -0,0 +1,110 @@
+package builder
+
+// This file creates the build information that can be read at runtime using
+// runtime/debug.ReadBuildInfo. It uses the same text format as the go command.
+
+import (
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tinygo-org/tinygo/compileopts"
+	"github.com/tinygo-org/tinygo/loader"
+)
+
+// makeModInfo returns the build information of the given program, to be
+// stored in runtime/debug.modinfo. It returns the empty string when the program
+// is not built in module mode.
+func makeModInfo(config *compileopts.Config, lprogram *loader.Program) string {
+	mainPkg := lprogram.MainPkg()
+	if mainPkg.Module == nil {
+		return ""
+	}
+
+	buf := &strings.Builder{}
+	buf.WriteString("path\t" + mainPkg.ImportPath + "\n")
+	writeModule := func(word string, m *loader.Module) {
+		version := m.Version
+		if m.Main {
+			version = "(devel)"
+		}
+		buf.WriteString(word + "\t" + m.Path + "\t" + version + "\n")
+		if m.Replace != nil {
+			buf.WriteString("=>\t" + m.Replace.Path + "\t" + m.Replace.Version + "\n")
+		}
+	}
+	writeModule("mod", mainPkg.Module)
+
+	// Add all modules (except for the main module) that provide at least one
+	// package, sorted by module path.
+	deps := make(map[string]*loader.Module)
+	for _, pkg := range lprogram.Sorted() {
+		if pkg.Module != nil && !pkg.Module.Main {
+			deps[pkg.Module.Path] = pkg.Module
+		}
+	}
+	var depPaths []string
+	for path := range deps {
+		depPaths = append(depPaths, path)
+	}
+	sort.Strings(depPaths)
+	for _, path := range depPaths {
+		writeModule("dep", deps[path])
+	}
+
+	// Build settings.
+	settings := [][2]string{{"-compiler", "tinygo"}}
+	if tags := config.Options.Tags; tags != "" {
+		settings = append(settings, [2]string{"-tags", tags})
+	}
+	if config.Options.TrimPath {
+		settings = append(settings, [2]string{"-trimpath", "true"})
+	}
+	settings = append(settings, [2]string{"GOARCH", config.GOARCH()}, [2]string{"GOOS", config.GOOS()})
+	if config.Options.Target != "" {
+		settings = append(settings, [2]string{"target", config.Options.Target})
+	}
+	settings = append(settings, readVCSInfo(mainPkg.Module.Dir)...)
+	for _, setting := range settings {
+		buf.WriteString("build\t" + setting[0] + "=" + setting[1] + "\n")
+	}
+	return buf.String()
+}
+
+// readVCSInfo returns version control information about the main module, in
+// the same form as the go command: the current revision, the commit time and
+// whether there are uncommitted changes. Only git is supported. If the
+// information cannot be determined (for example because the module is not in
+// a git repository), nil is returned.
+func readVCSInfo(dir string) [][2]string {
+	if dir == "" {
+		return nil
+	}
+	git := func(args ...string) (string, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.Output()
+		return strings.TrimSpace(string(out)), err
+	}
+	revision, err := git("-c", "log.showsignature=false", "log", "-1", "--format=%H:%ct")
+	if err != nil || strings.IndexByte(revision, ':') < 0 {
+		return nil
+	}
+	index := strings.IndexByte(revision, ':')
+	commitTime, err := strconv.ParseInt(revision[index+1:], 10, 64)
+	if err != nil {
+		return nil
+	}
+	status, err := git("status", "--porcelain")
+	if err != nil {
+		return nil
+	}
+	return [][2]string{
+		{"vcs", "git"},
+		{"vcs.revision", revision[:index]},
+		{"vcs.time", time.Unix(commitTime, 0).UTC().Format(time.RFC3339)},
+		{"vcs.modified", strconv.FormatBool(status != "")},
+	}
+}
@@ -0,0 +1,188 @@
+package builder
+
+// This file implements two different firmware formats that are both called
+// "DFU" (after the USB Device Firmware Upgrade class):
+//
+//   - The DfuSe format by ST, used by dfu-util and STM32CubeProgrammer to flash
+//     STM32 chips over USB.
+//   - The DFU zip package format used by Nordic Semiconductor (nrfutil and
+//     adafruit-nrfutil) to flash nRF5x chips with a legacy (unsigned) DFU
+//     bootloader, such as the Adafruit nRF52 bootloader.
+//
+// Documentation for the DfuSe format is in UM0391 from ST:
+// https://www.st.com/resource/en/user_manual/cd00264379.pdf
+// The Nordic format is documented in the nRF5 SDK v11 and in the source code of
+// adafruit-nrfutil:
+// https://github.com/adafruit/Adafruit_nRF52_nrfutil/blob/master/nordicsemi/dfu/init_packet.py
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"io/ioutil"
+)
+
+// convertELFFileToDfuSeFile converts an ELF file to a DfuSe (.dfu) file with a
+// single image element.
+func convertELFFileToDfuSeFile(infile, outfile string) error {
+	// Read the .text segment.
+	addr, data, err := extractROM(infile)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(outfile, makeDfuSeImage(uint32(addr), data), 0644)
+}
+
+// makeDfuSeImage creates a DfuSe file in memory from the given firmware image.
+// The USB vendor and product ID are left unspecified (0xffff), so that the
+// file can be flashed to any device.
+func makeDfuSeImage(addr uint32, data []byte) []byte {
+	buf := &bytes.Buffer{}
+
+	// Image element.
+	element := &bytes.Buffer{}
+	binary.Write(element, binary.LittleEndian, addr)
+	binary.Write(element, binary.LittleEndian, uint32(len(data)))
+	element.Write(data)
+
+	// Target prefix, for alternate setting 0 (internal flash).
+	target := &bytes.Buffer{}
+	target.WriteString("Target")
+	target.WriteByte(0)                                              // bAlternateSetting
+	binary.Write(target, binary.LittleEndian, uint32(0))             // bTargetNamed
+	target.Write(make([]byte, 255))                                  // szTargetName
+	binary.Write(target, binary.LittleEndian, uint32(element.Len())) // dwTargetSize
+	binary.Write(target, binary.LittleEndian, uint32(1))             // dwNbElements
+
+	// DfuSe prefix.
+	buf.WriteString("DfuSe")
+	buf.WriteByte(0x01)                                                           // bVersion
+	binary.Write(buf, binary.LittleEndian, uint32(11+target.Len()+element.Len())) // DFUImageSize
+	buf.WriteByte(1)                                                              // bTargets
+	buf.Write(target.Bytes())
+	buf.Write(element.Bytes())
+
+	// DFU suffix. The CRC covers everything but the CRC itself, and is stored
+	// without the final inversion of the standard CRC32 algorithm.
+	binary.Write(buf, binary.LittleEndian, uint16(0xffff)) // bcdDevice
+	binary.Write(buf, binary.LittleEndian, uint16(0xffff)) // idProduct
+	binary.Write(buf, binary.LittleEndian, uint16(0xffff)) // idVendor
+	binary.Write(buf, binary.LittleEndian, uint16(0x011a)) // bcdDFU (DfuSe)
+	buf.WriteString("UFD")                                 // ucDfuSignature
+	buf.WriteByte(16)                                      // bLength
+	binary.Write(buf, binary.LittleEndian, ^crc32.ChecksumIEEE(buf.Bytes()))
+	return buf.Bytes()
+}
+
+// nrfDFUManifest is the manifest.json file inside a Nordic DFU zip package.
+type nrfDFUManifest struct {
+	Manifest struct {
+		Application struct {
+			BinFile        string `json:"bin_file"`
+			DatFile        string `json:"dat_file"`
+			InitPacketData struct {
+				ApplicationVersion uint32   `json:"application_version"`
+				DeviceRevision     uint16   `json:"device_revision"`
+				DeviceType         uint16   `json:"device_type"`
+				FirmwareCRC16      uint16   `json:"firmware_crc16"`
+				SoftDeviceReq      []uint16 `json:"softdevice_req"`
+			} `json:"init_packet_data"`
+		} `json:"application"`
+		DFUVersion float64 `json:"dfu_version"`
+	} `json:"manifest"`
+}
+
+// convertELFFileToNRFDFUFile converts an ELF file to a Nordic DFU zip package.
+func convertELFFileToNRFDFUFile(infile, outfile string) error {
+	// Read the .text segment. The start address isn't stored in the package,
+	// the bootloader determines it from the SoftDevice (if any).
+	_, data, err := extractROM(infile)
+	if err != nil {
+		return err
+	}
+	pkg, err := makeNRFDFUPackage(data)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(outfile, pkg, 0666)
+}
+
+// makeNRFDFUPackage creates a Nordic DFU zip package in memory from the given
+// firmware image, containing the raw firmware, a (legacy) init packet and a
+// manifest.
+//
+// All fields in the init packet are set to their "any" values: the package can
+// be flashed to any device type and revision, with any SoftDevice installed.
+func makeNRFDFUPackage(data []byte) ([]byte, error) {
+	var manifest nrfDFUManifest
+	app := &manifest.Manifest.Application
+	app.BinFile = "application.bin"
+	app.DatFile = "application.dat"
+	app.InitPacketData.ApplicationVersion = 0xffffffff
+	app.InitPacketData.DeviceRevision = 0xffff
+	app.InitPacketData.DeviceType = 0xffff
+	app.InitPacketData.FirmwareCRC16 = crc16CCITT(data)
+	app.InitPacketData.SoftDeviceReq = []uint16{0xfffe}
+	manifest.Manifest.DFUVersion = 0.5
+
+	// Legacy init packet: all fields are little endian.
+	initPacket := &bytes.Buffer{}
+	binary.Write(initPacket, binary.LittleEndian, app.InitPacketData.DeviceType)
+	binary.Write(initPacket, binary.LittleEndian, app.InitPacketData.DeviceRevision)
+	binary.Write(initPacket, binary.LittleEndian, app.InitPacketData.ApplicationVersion)
+	binary.Write(initPacket, binary.LittleEndian, uint16(len(app.InitPacketData.SoftDeviceReq)))
+	for _, sd := range app.InitPacketData.SoftDeviceReq {
+		binary.Write(initPacket, binary.LittleEndian, sd)
+	}
+	binary.Write(initPacket, binary.LittleEndian, app.InitPacketData.FirmwareCRC16)
+
+	manifestData, err := json.MarshalIndent(&manifest, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+
+	// Write the zip file.
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{
+		{app.BinFile, data},
+		{app.DatFile, initPacket.Bytes()},
+		{"manifest.json", manifestData},
+	} {
+		fw, err := w.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		_, err = fw.Write(file.data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// crc16CCITT calculates the CRC-16/CCITT-FALSE checksum (polynomial 0x1021,
+// initial value 0xffff), as used in the Nordic DFU init packet.
+func crc16CCITT(data []byte) uint16 {
+	crc := uint16(0xffff)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
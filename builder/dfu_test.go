@@ -0,0 +1,171 @@
+package builder
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestDfuSe(t *testing.T) {
+	data := testFirmware(100)
+	image := makeDfuSeImage(0x08000000, data)
+	checkGolden(t, "dfuse.dfu", image)
+
+	// Prefix: signature, version, size of the image without the suffix and
+	// the number of targets.
+	if string(image[:5]) != "DfuSe" || image[5] != 1 {
+		t.Errorf("invalid prefix: % x", image[:6])
+	}
+	if size := binary.LittleEndian.Uint32(image[6:]); size != uint32(len(image)-16) {
+		t.Errorf("DFUImageSize is %d, expected %d", size, len(image)-16)
+	}
+	if image[10] != 1 {
+		t.Errorf("expected 1 target, got %d", image[10])
+	}
+
+	// Target prefix, followed by a single image element.
+	target := image[11:]
+	if string(target[:6]) != "Target" || target[6] != 0 {
+		t.Errorf("invalid target prefix: % x", target[:7])
+	}
+	if size := binary.LittleEndian.Uint32(target[266:]); size != uint32(8+len(data)) {
+		t.Errorf("dwTargetSize is %d, expected %d", size, 8+len(data))
+	}
+	if n := binary.LittleEndian.Uint32(target[270:]); n != 1 {
+		t.Errorf("expected 1 element, got %d", n)
+	}
+	element := target[274:]
+	if addr := binary.LittleEndian.Uint32(element); addr != 0x08000000 {
+		t.Errorf("element address is 0x%x", addr)
+	}
+	if size := binary.LittleEndian.Uint32(element[4:]); size != uint32(len(data)) {
+		t.Errorf("element size is %d, expected %d", size, len(data))
+	}
+	if !bytes.Equal(element[8:8+len(data)], data) {
+		t.Errorf("element data does not match the firmware")
+	}
+
+	// Suffix: device, product and vendor ID, DFU version, signature, length
+	// and CRC.
+	suffix := image[len(image)-16:]
+	expectedSuffix := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x1a, 0x01, 'U', 'F', 'D', 16}
+	if !bytes.Equal(suffix[:12], expectedSuffix) {
+		t.Errorf("invalid suffix: % x", suffix[:12])
+	}
+
+	// The CRC is a standard CRC-32 without the final inversion, calculated
+	// bit by bit here to check it independently of hash/crc32.
+	crc := uint32(0xffffffff)
+	for _, b := range image[:len(image)-4] {
+		crc ^= uint32(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xedb88320
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	if stored := binary.LittleEndian.Uint32(suffix[12:]); stored != crc {
+		t.Errorf("CRC is 0x%08x, expected 0x%08x", stored, crc)
+	}
+}
+
+func TestNRFDFU(t *testing.T) {
+	data := testFirmware(100)
+	pkg, err := makeNRFDFUPackage(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(pkg), int64(len(pkg)))
+	if err != nil {
+		t.Fatal("could not read zip file:", err)
+	}
+	files := map[string][]byte{}
+	var names []string
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, f.Name)
+		files[f.Name] = contents
+	}
+	if expected := []string{"application.bin", "application.dat", "manifest.json"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("unexpected files in package: %v", names)
+	}
+
+	if !bytes.Equal(files["application.bin"], data) {
+		t.Errorf("application.bin does not match the firmware")
+	}
+
+	// CRC-16/CCITT-FALSE of the firmware, calculated here bit by bit (most
+	// significant bit first) to check it independently of crc16CCITT.
+	crc := uint16(0xffff)
+	for _, b := range data {
+		for i := 7; i >= 0; i-- {
+			bit := (b>>uint(i))&1 != 0
+			if (crc&0x8000 != 0) != bit {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	// Init packet: device type, device revision, application version, the
+	// number of allowed SoftDevices followed by the list (0xfffe means any),
+	// and the CRC of the firmware.
+	expectedInitPacket := []byte{
+		0xff, 0xff, // device type
+		0xff, 0xff, // device revision
+		0xff, 0xff, 0xff, 0xff, // application version
+		0x01, 0x00, // number of SoftDevices
+		0xfe, 0xff, // any SoftDevice
+		byte(crc), byte(crc >> 8),
+	}
+	if !bytes.Equal(files["application.dat"], expectedInitPacket) {
+		t.Errorf("unexpected init packet:\n% x\nexpected:\n% x", files["application.dat"], expectedInitPacket)
+	}
+
+	var manifest map[string]interface{}
+	err = json.Unmarshal(files["manifest.json"], &manifest)
+	if err != nil {
+		t.Fatal("could not parse manifest.json:", err)
+	}
+	expectedManifest := map[string]interface{}{
+		"manifest": map[string]interface{}{
+			"application": map[string]interface{}{
+				"bin_file": "application.bin",
+				"dat_file": "application.dat",
+				"init_packet_data": map[string]interface{}{
+					"application_version": float64(0xffffffff),
+					"device_revision":     float64(0xffff),
+					"device_type":         float64(0xffff),
+					"firmware_crc16":      float64(crc),
+					"softdevice_req":      []interface{}{float64(0xfffe)},
+				},
+			},
+			"dfu_version": 0.5,
+		},
+	}
+	if !reflect.DeepEqual(manifest, expectedManifest) {
+		t.Errorf("unexpected manifest.json:\n%s", files["manifest.json"])
+	}
+}
+
+func TestCRC16CCITT(t *testing.T) {
+	// Check value of CRC-16/CCITT-FALSE.
+	if crc := crc16CCITT([]byte("123456789")); crc != 0x29b1 {
+		t.Errorf("expected 0x29b1, got 0x%04x", crc)
+	}
+}
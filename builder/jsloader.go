@@ -0,0 +1,334 @@
+package builder
+
+// This file generates an ES module next to a .wasm file built for the wasm
+// (GOOS=js) target with -js-loader. The module contains wasm_exec.js and
+// exports its Go class, a load function that instantiates and starts the
+// program, and a typed wrapper for every exported function. A TypeScript
+// declaration file is written along with it.
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tinygo-org/tinygo/compiler"
+	"github.com/tinygo-org/tinygo/goenv"
+)
+
+// Words that cannot be used as a function or parameter name in the generated
+// JavaScript, including the names exported by the loader itself. All other
+// names used internally by the loader start with a $, which is not allowed at
+// the start of an exported function or parameter name, so they can't collide.
+var jsReservedWords = map[string]bool{
+	"arguments": true, "await": true, "break": true, "case": true, "catch": true,
+	"class": true, "const": true, "continue": true, "debugger": true,
+	"default": true, "delete": true, "do": true, "else": true, "enum": true,
+	"eval": true, "export": true, "extends": true, "false": true,
+	"finally": true, "for": true, "function": true, "if": true,
+	"implements": true, "import": true, "in": true, "instanceof": true,
+	"interface": true, "let": true, "new": true, "null": true,
+	"package": true, "private": true, "protected": true, "public": true,
+	"return": true, "static": true, "super": true, "switch": true,
+	"this": true, "throw": true, "true": true, "try": true, "typeof": true,
+	"var": true, "void": true, "while": true, "with": true, "yield": true,
+
+	// Names exported by the loader itself.
+	"Go": true, "load": true,
+}
+
+var jsIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+// jsFlatTypes lists the WebAssembly value types that a value of the given type
+// is passed as, using the same names as the DataView getters.
+var jsFlatTypes = map[string][]string{
+	"bool":     {"Int32"},
+	"s8":       {"Int32"},
+	"s16":      {"Int32"},
+	"s32":      {"Int32"},
+	"s64":      {"BigInt64"},
+	"u8":       {"Int32"},
+	"u16":      {"Int32"},
+	"u32":      {"Int32"},
+	"u64":      {"BigInt64"},
+	"f32":      {"Float32"},
+	"f64":      {"Float64"},
+	"pointer":  {"Int32"},
+	"string":   {"Int32", "Int32"},
+	"list<u8>": {"Int32", "Int32"},
+}
+
+// jsModulePrelude is inserted before wasm_exec.js, which is written as a
+// classic script, so that it also works as part of an ES module. Node.js
+// doesn't define require in ES modules, but wasm_exec.js uses it to load the
+// fs and crypto modules.
+const jsModulePrelude = `if (typeof process !== "undefined" && process.versions != null && process.versions.node != null && typeof require === "undefined") {
+	const { createRequire } = await import("module");
+	globalThis.require = createRequire(import.meta.url);
+}
+
+`
+
+// jsExport is a single exported function for which a wrapper is generated.
+type jsExport struct {
+	compiler.WasmExport
+	paramNames []string
+}
+
+// writeJSLoader writes the ES module loader and its TypeScript declarations for
+// the given .wasm file. Exported functions of which the parameters or results
+// can't be converted automatically are only available through the
+// WebAssembly.Instance returned by load.
+func writeJSLoader(wasmPath, wasmABI string, exports []compiler.WasmExport) error {
+	runtimeJS, err := ioutil.ReadFile(filepath.Join(goenv.Get("TINYGOROOT"), "targets", "wasm_exec.js"))
+	if err != nil {
+		return err
+	}
+
+	var supported []jsExport
+	for _, export := range exports {
+		if !jsIdentifier.MatchString(export.Name) || jsReservedWords[export.Name] {
+			continue
+		}
+		if !jsSupportedExport(export, wasmABI) {
+			continue
+		}
+		fn := jsExport{WasmExport: export}
+		for i, param := range export.Params {
+			name := param.Name
+			if !jsIdentifier.MatchString(name) || jsReservedWords[name] || name == "_" {
+				name = "p" + strconv.Itoa(i)
+			}
+			fn.paramNames = append(fn.paramNames, name)
+		}
+		supported = append(supported, fn)
+	}
+
+	base := strings.TrimSuffix(filepath.Base(wasmPath), filepath.Ext(wasmPath))
+	outbase := strings.TrimSuffix(wasmPath, filepath.Ext(wasmPath))
+
+	js := &strings.Builder{}
+	js.WriteString("// Code generated by TinyGo. DO NOT EDIT.\n\n")
+	js.WriteString(jsModulePrelude)
+	js.Write(runtimeJS)
+	fmt.Fprintf(js, `
+export const Go = globalThis.Go;
+
+let $wasmInstance;
+
+// load instantiates and starts the WebAssembly module, which is loaded from
+// %s.wasm next to this file by default. The source may also be a URL, a
+// Response, or the bytes of the module.
+export async function load(source = new URL(%s, import.meta.url), go = new Go()) {
+	if (source instanceof URL && source.protocol === "file:") {
+		const fs = await import("fs");
+		source = await fs.promises.readFile(source);
+	} else if (source instanceof URL) {
+		source = await fetch(source);
+	}
+	if (typeof Response !== "undefined" && source instanceof Response) {
+		source = await source.arrayBuffer();
+	}
+	const result = await WebAssembly.instantiate(source, go.importObject);
+	$wasmInstance = result.instance;
+	go.run($wasmInstance);
+	return $wasmInstance;
+}
+
+function $memory() {
+	return new DataView($wasmInstance.exports.memory.buffer);
+}
+`, base, strconv.Quote(base+".wasm"))
+	if jsUsesMemoryAllocation(supported) {
+		js.WriteString(`
+const $encoder = new TextEncoder("utf-8");
+const $decoder = new TextDecoder("utf-8");
+
+// Copy the bytes to memory allocated with cabi_realloc, and return the pointer.
+function $lowerBytes(bytes) {
+	const ptr = $wasmInstance.exports.cabi_realloc(0, 0, 1, bytes.length);
+	new Uint8Array($wasmInstance.exports.memory.buffer, ptr, bytes.length).set(bytes);
+	return ptr;
+}
+`)
+	}
+	for _, fn := range supported {
+		writeJSWrapper(js, fn)
+	}
+
+	ts := &strings.Builder{}
+	ts.WriteString(`// Code generated by TinyGo. DO NOT EDIT.
+
+export declare class Go {
+	importObject: WebAssembly.Imports;
+	exited: boolean;
+	run(instance: WebAssembly.Instance): Promise<void>;
+}
+
+export declare function load(source?: URL | Response | BufferSource, go?: Go): Promise<WebAssembly.Instance>;
+`)
+	for _, fn := range supported {
+		var params []string
+		for i, param := range fn.Params {
+			params = append(params, fn.paramNames[i]+": "+tsType(param.Type))
+		}
+		var result string
+		switch len(fn.Results) {
+		case 0:
+			result = "void"
+		case 1:
+			result = tsType(fn.Results[0].Type)
+		default:
+			var results []string
+			for _, r := range fn.Results {
+				results = append(results, tsType(r.Type))
+			}
+			result = "[" + strings.Join(results, ", ") + "]"
+		}
+		fmt.Fprintf(ts, "export declare function %s(%s): %s;\n", fn.Name, strings.Join(params, ", "), result)
+	}
+
+	err = ioutil.WriteFile(outbase+".mjs", []byte(js.String()), 0666)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(outbase+".d.ts", []byte(ts.String()), 0666)
+}
+
+// jsSupportedExport returns whether a wrapper can be generated for the given
+// exported function.
+func jsSupportedExport(export compiler.WasmExport, wasmABI string) bool {
+	values := append(append([]compiler.WasmValue{}, export.Params...), export.Results...)
+	for _, value := range values {
+		switch value.Type {
+		case "string", "list<u8>":
+			// Needs the canonical ABI to be passed as pointer and length.
+			if !export.Canonical {
+				return false
+			}
+		case "s64", "u64":
+			// The js ABI passes 64-bit integers indirectly.
+			if wasmABI == "js" {
+				return false
+			}
+		default:
+			if _, ok := jsFlatTypes[value.Type]; !ok {
+				return false
+			}
+		}
+	}
+	if len(export.Results) > 1 && !export.Canonical {
+		return false
+	}
+	return true
+}
+
+// jsUsesMemoryAllocation returns whether any of the exports needs to allocate
+// memory in the WebAssembly module to pass a parameter.
+func jsUsesMemoryAllocation(exports []jsExport) bool {
+	for _, fn := range exports {
+		for _, value := range append(append([]compiler.WasmValue{}, fn.Params...), fn.Results...) {
+			if value.Type == "string" || value.Type == "list<u8>" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeJSWrapper writes the JavaScript function that converts the parameters,
+// calls the exported function and converts the results.
+func writeJSWrapper(js *strings.Builder, fn jsExport) {
+	fmt.Fprintf(js, "\nexport function %s(%s) {\n", fn.Name, strings.Join(fn.paramNames, ", "))
+
+	// Convert the parameters.
+	var args []string
+	for i, param := range fn.Params {
+		name := fn.paramNames[i]
+		switch param.Type {
+		case "bool":
+			args = append(args, name+" ? 1 : 0")
+		case "string":
+			fmt.Fprintf(js, "\tconst $%sBytes = $encoder.encode(%s);\n", name, name)
+			args = append(args, "$lowerBytes($"+name+"Bytes)", "$"+name+"Bytes.length")
+		case "list<u8>":
+			args = append(args, "$lowerBytes("+name+")", name+".length")
+		default:
+			args = append(args, name)
+		}
+	}
+	call := "$wasmInstance.exports." + fn.Name + "(" + strings.Join(args, ", ") + ")"
+
+	// Determine the flat (lowered) result values.
+	var flatTypes []string
+	for _, result := range fn.Results {
+		flatTypes = append(flatTypes, jsFlatTypes[result.Type]...)
+	}
+	switch len(flatTypes) {
+	case 0:
+		fmt.Fprintf(js, "\t%s;\n}\n", call)
+		return
+	case 1:
+		fmt.Fprintf(js, "\tconst $r0 = %s;\n", call)
+	default:
+		// The results are stored in a return area, laid out as a C struct.
+		fmt.Fprintf(js, "\tconst $retarea = %s;\n", call)
+		js.WriteString("\tconst $mem = $memory();\n")
+		offset := 0
+		for i, typ := range flatTypes {
+			size := 4
+			if typ == "BigInt64" || typ == "Float64" {
+				size = 8
+			}
+			offset = (offset + size - 1) / size * size
+			fmt.Fprintf(js, "\tconst $r%d = $mem.get%s($retarea + %d, true);\n", i, typ, offset)
+			offset += size
+		}
+	}
+
+	// Convert the results back to JavaScript values.
+	var results []string
+	index := 0
+	for _, result := range fn.Results {
+		r := "$r" + strconv.Itoa(index)
+		switch result.Type {
+		case "bool":
+			results = append(results, r+" !== 0")
+		case "u32", "pointer":
+			results = append(results, r+" >>> 0")
+		case "u64":
+			results = append(results, "BigInt.asUintN(64, "+r+")")
+		case "string":
+			results = append(results, fmt.Sprintf("$decoder.decode(new Uint8Array($wasmInstance.exports.memory.buffer, %s, $r%d))", r, index+1))
+		case "list<u8>":
+			results = append(results, fmt.Sprintf("new Uint8Array($wasmInstance.exports.memory.buffer, %s, $r%d).slice()", r, index+1))
+		default:
+			results = append(results, r)
+		}
+		index += len(jsFlatTypes[result.Type])
+	}
+	if len(results) == 1 {
+		fmt.Fprintf(js, "\treturn %s;\n}\n", results[0])
+	} else {
+		fmt.Fprintf(js, "\treturn [%s];\n}\n", strings.Join(results, ", "))
+	}
+}
+
+// tsType returns the TypeScript type of a parameter or result of an exported
+// function.
+func tsType(typ string) string {
+	switch typ {
+	case "bool":
+		return "boolean"
+	case "s64", "u64":
+		return "bigint"
+	case "string":
+		return "string"
+	case "list<u8>":
+		return "Uint8Array"
+	default:
+		return "number"
+	}
+}
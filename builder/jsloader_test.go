@@ -0,0 +1,88 @@
+package builder
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tinygo-org/tinygo/compiler"
+)
+
+// TestJSLoaderNames checks that exported functions and their parameters may
+// use the same names as the variables and helpers of the generated loader.
+func TestJSLoaderNames(t *testing.T) {
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node not found:", err)
+	}
+
+	tmpDir, err := ioutil.TempDir("", "jsloader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	exports := []compiler.WasmExport{
+		{
+			Name:    "memory",
+			Params:  []compiler.WasmValue{{Name: "mem", Type: "s32"}, {Name: "retarea", Type: "s32"}},
+			Results: []compiler.WasmValue{{Type: "s32"}},
+		},
+		{
+			Name:      "encoder",
+			Params:    []compiler.WasmValue{{Name: "decoder", Type: "string"}, {Name: "decoderBytes", Type: "list<u8>"}},
+			Results:   []compiler.WasmValue{{Type: "string"}},
+			Canonical: true,
+		},
+		{
+			Name:      "lowerBytes",
+			Params:    []compiler.WasmValue{{Name: "r0", Type: "s32"}, {Name: "wasmInstance", Type: "bool"}},
+			Results:   []compiler.WasmValue{{Type: "s32"}, {Type: "f64"}},
+			Canonical: true,
+		},
+		{
+			Name:   "wasmInstance",
+			Params: []compiler.WasmValue{{Name: "lowerBytes", Type: "s32"}},
+		},
+		{
+			// Starts with a $, so it could collide with the names used
+			// internally by the loader.
+			Name: "$memory",
+		},
+	}
+	wasmPath := filepath.Join(tmpDir, "names.wasm")
+	err = writeJSLoader(wasmPath, "js", exports)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A duplicate declaration would be a SyntaxError.
+	output, err := exec.Command("node", "--check", filepath.Join(tmpDir, "names.mjs")).CombinedOutput()
+	if err != nil {
+		t.Fatalf("generated loader is invalid: %v\n%s", err, output)
+	}
+
+	js, err := ioutil.ReadFile(filepath.Join(tmpDir, "names.mjs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"export function memory(mem, retarea) {",
+		"export function encoder(decoder, decoderBytes) {",
+		"\tconst $decoderBytes = $encoder.encode(decoder);\n",
+		"$wasmInstance.exports.encoder($lowerBytes($decoderBytes), $decoderBytes.length, $lowerBytes(decoderBytes), decoderBytes.length)",
+		"export function lowerBytes(r0, wasmInstance) {",
+		"\tconst $r1 = $mem.getFloat64($retarea + 8, true);\n",
+		"\treturn [$r0, $r1];\n",
+		"export function wasmInstance(lowerBytes) {",
+	} {
+		if !strings.Contains(string(js), line) {
+			t.Errorf("generated loader does not contain %q", line)
+		}
+	}
+	if strings.Contains(string(js), "export function $memory(") {
+		t.Error("wrapper generated for a name starting with $")
+	}
+}
@@ -0,0 +1,261 @@
+package builder
+
+// This file implements the MCUboot image format. MCUboot is a secure
+// bootloader that verifies (and optionally swaps) firmware images before
+// booting them. Images consist of a header, the firmware itself and a trailer
+// with TLV (type-length-value) records containing a hash and a signature.
+//
+// This is the same format as produced by `imgtool sign`, with the header added
+// in front of the firmware (like the --pad-header flag). Therefore the firmware
+// must be linked at the start of the image slot plus the header size: the
+// linker script of the target must reserve image-header-size bytes (0x200 by
+// default) in front of the vector table. This is not done automatically, but
+// images where the header can't fit in front of .text are rejected.
+//
+// Documentation:
+// https://github.com/mcu-tools/mcuboot/blob/main/docs/design.md#image-format
+// https://github.com/mcu-tools/mcuboot/blob/main/scripts/imgtool/image.py
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	mcubootImageMagic   = 0x96f3b83d
+	mcubootTLVInfoMagic = 0x6907
+
+	mcubootTLVKeyHash = 0x01
+	mcubootTLVSHA256  = 0x10
+	mcubootTLVECDSA   = 0x22 // ECDSA P-256 signature
+	mcubootTLVEd25519 = 0x24
+
+	// Default header size, the same as used by Zephyr. It must be big enough
+	// so that the vector table that follows it is correctly aligned.
+	mcubootDefaultHeaderSize = 0x200
+)
+
+// mcubootVersion is the image version stored in the image header.
+type mcubootVersion struct {
+	major    uint8
+	minor    uint8
+	revision uint16
+	build    uint32
+}
+
+// parseMCUbootVersion parses a version string in the form of
+// major.minor.revision+build, like imgtool. All parts except for the major
+// version are optional. An empty string results in version 0.0.0+0.
+func parseMCUbootVersion(s string) (mcubootVersion, error) {
+	var version mcubootVersion
+	if s == "" {
+		return version, nil
+	}
+	versionString := s
+	if index := strings.IndexByte(s, '+'); index >= 0 {
+		build, err := strconv.ParseUint(s[index+1:], 10, 32)
+		if err != nil {
+			return version, fmt.Errorf("invalid image version %#v: %w", versionString, err)
+		}
+		version.build = uint32(build)
+		s = s[:index]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return version, fmt.Errorf("invalid image version %#v: expected major.minor.revision+build", versionString)
+	}
+	for i, part := range parts {
+		bits := 8
+		if i == 2 {
+			bits = 16
+		}
+		n, err := strconv.ParseUint(part, 10, bits)
+		if err != nil {
+			return version, fmt.Errorf("invalid image version %#v: %w", versionString, err)
+		}
+		switch i {
+		case 0:
+			version.major = uint8(n)
+		case 1:
+			version.minor = uint8(n)
+		case 2:
+			version.revision = uint16(n)
+		}
+	}
+	return version, nil
+}
+
+// convertELFFileToMCUbootFile converts an ELF file to a MCUboot image. If
+// keyPath is not empty, the image is signed with the (ECDSA P-256 or Ed25519)
+// private key in the given PEM file. Otherwise, only a SHA256 hash is added.
+func convertELFFileToMCUbootFile(infile, outfile, keyPath, versionString string, headerSize uint32) error {
+	// Read the .text segment.
+	address, data, err := extractROM(infile)
+	if err != nil {
+		return err
+	}
+
+	version, err := parseMCUbootVersion(versionString)
+	if err != nil {
+		return err
+	}
+
+	var key interface{}
+	if keyPath != "" {
+		key, err = loadSigningKey(keyPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	image, err := makeMCUbootImage(address, data, version, headerSize, key)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(outfile, image, 0666)
+}
+
+// makeMCUbootImage creates a MCUboot image in memory, for firmware that starts
+// at the given address. The key may be nil (for an unsigned image), an
+// *ecdsa.PrivateKey or an ed25519.PrivateKey.
+func makeMCUbootImage(address uint64, data []byte, version mcubootVersion, headerSize uint32, key interface{}) ([]byte, error) {
+	if headerSize == 0 {
+		headerSize = mcubootDefaultHeaderSize
+	}
+	if headerSize < 32 {
+		return nil, fmt.Errorf("MCUboot header size too small: %d", headerSize)
+	}
+	if headerSize > 0xffff {
+		// The header size is stored in a 16-bit field.
+		return nil, fmt.Errorf("MCUboot header size too large: %d", headerSize)
+	}
+	if address < uint64(headerSize) {
+		// The header is placed directly in front of the firmware, so there
+		// must be room for it in the image slot.
+		return nil, fmt.Errorf("MCUboot header (0x%x bytes) does not fit in front of the firmware at 0x%x: the linker script must reserve image-header-size bytes before .text", headerSize, address)
+	}
+
+	// Write first to an in-memory buffer, so that we can easily calculate a
+	// hash over the header and firmware.
+	outf := &bytes.Buffer{}
+
+	// Image header:
+	// https://github.com/mcu-tools/mcuboot/blob/main/boot/bootutil/include/bootutil/image.h
+	binary.Write(outf, binary.LittleEndian, struct {
+		magic            uint32
+		load_addr        uint32
+		hdr_size         uint16
+		protect_tlv_size uint16
+		img_size         uint32
+		flags            uint32
+		ver              mcubootVersion
+		pad1             uint32
+	}{
+		magic:    mcubootImageMagic,
+		hdr_size: uint16(headerSize),
+		img_size: uint32(len(data)),
+		ver:      version,
+	})
+	outf.Write(make([]byte, int(headerSize)-outf.Len()))
+
+	// Firmware image.
+	outf.Write(data)
+
+	// Calculate all TLV records.
+	hash := sha256.Sum256(outf.Bytes())
+	tlvs := &bytes.Buffer{}
+	writeTLV := func(tlvType uint16, value []byte) {
+		binary.Write(tlvs, binary.LittleEndian, tlvType)
+		binary.Write(tlvs, binary.LittleEndian, uint16(len(value)))
+		tlvs.Write(value)
+	}
+	writeTLV(mcubootTLVSHA256, hash[:])
+	switch key := key.(type) {
+	case nil:
+		// Unsigned image.
+	case *ecdsa.PrivateKey:
+		pubkey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		keyHash := sha256.Sum256(pubkey)
+		writeTLV(mcubootTLVKeyHash, keyHash[:])
+		r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+		if err != nil {
+			return nil, err
+		}
+		signature, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+		if err != nil {
+			return nil, err
+		}
+		writeTLV(mcubootTLVECDSA, signature)
+	case ed25519.PrivateKey:
+		pubkey, err := x509.MarshalPKIXPublicKey(key.Public())
+		if err != nil {
+			return nil, err
+		}
+		keyHash := sha256.Sum256(pubkey)
+		writeTLV(mcubootTLVKeyHash, keyHash[:])
+		// Like imgtool, sign the hash and not the image itself.
+		writeTLV(mcubootTLVEd25519, ed25519.Sign(key, hash[:]))
+	default:
+		return nil, fmt.Errorf("unsupported signing key type: %T", key)
+	}
+
+	// TLV info header followed by all TLV records.
+	binary.Write(outf, binary.LittleEndian, uint16(mcubootTLVInfoMagic))
+	binary.Write(outf, binary.LittleEndian, uint16(4+tlvs.Len()))
+	outf.Write(tlvs.Bytes())
+
+	return outf.Bytes(), nil
+}
+
+// loadSigningKey loads a private key from a PEM file, as generated by
+// `imgtool keygen` or openssl. Only ECDSA P-256 and Ed25519 keys are supported.
+func loadSigningKey(path string) (interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("could not find a PEM encoded key in " + path)
+	}
+	var key interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported key type %#v in %s", block.Type, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse signing key %s: %w", path, err)
+	}
+	switch key := key.(type) {
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("unsupported ECDSA curve in %s: only P-256 is supported", path)
+		}
+		return key, nil
+	case ed25519.PrivateKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %T in %s", key, path)
+	}
+}
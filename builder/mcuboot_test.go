@@ -0,0 +1,193 @@
+package builder
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"math/big"
+	"testing"
+)
+
+// mcubootTLV is a single TLV record read back from a MCUboot image.
+type mcubootTLV struct {
+	tlvType uint16
+	value   []byte
+}
+
+// checkMCUbootImage checks the header and the TLV records of a MCUboot image,
+// and returns the TLV records.
+func checkMCUbootImage(t *testing.T, image, data []byte, headerSize int, version mcubootVersion) []mcubootTLV {
+	t.Helper()
+
+	// Image header.
+	le := binary.LittleEndian
+	if magic := le.Uint32(image[0:]); magic != 0x96f3b83d {
+		t.Errorf("invalid image magic: 0x%08x", magic)
+	}
+	if size := le.Uint16(image[8:]); int(size) != headerSize {
+		t.Errorf("header size is 0x%x, expected 0x%x", size, headerSize)
+	}
+	if size := le.Uint16(image[10:]); size != 0 {
+		t.Errorf("protected TLV size is %d, expected 0", size)
+	}
+	if size := le.Uint32(image[12:]); int(size) != len(data) {
+		t.Errorf("image size is %d, expected %d", size, len(data))
+	}
+	if image[20] != version.major || image[21] != version.minor || le.Uint16(image[22:]) != version.revision || le.Uint32(image[24:]) != version.build {
+		t.Errorf("unexpected version in header: % x", image[20:28])
+	}
+	if !bytes.Equal(image[28:headerSize], make([]byte, headerSize-28)) {
+		t.Errorf("header is not padded with zeroes")
+	}
+	if !bytes.Equal(image[headerSize:headerSize+len(data)], data) {
+		t.Errorf("firmware does not follow the header")
+	}
+
+	// TLV info header and records.
+	trailer := image[headerSize+len(data):]
+	if magic := le.Uint16(trailer); magic != 0x6907 {
+		t.Fatalf("invalid TLV info magic: 0x%04x", magic)
+	}
+	if size := le.Uint16(trailer[2:]); int(size) != len(trailer) {
+		t.Fatalf("TLV area size is %d, expected %d", size, len(trailer))
+	}
+	var tlvs []mcubootTLV
+	for buf := trailer[4:]; len(buf) != 0; {
+		if len(buf) < 4 {
+			t.Fatalf("truncated TLV record")
+		}
+		length := int(le.Uint16(buf[2:]))
+		tlvs = append(tlvs, mcubootTLV{le.Uint16(buf), buf[4 : 4+length]})
+		buf = buf[4+length:]
+	}
+
+	// The first record is always the hash over the header and the firmware.
+	hash := sha256.Sum256(image[:headerSize+len(data)])
+	if len(tlvs) == 0 || tlvs[0].tlvType != 0x10 || !bytes.Equal(tlvs[0].value, hash[:]) {
+		t.Errorf("first TLV record is not the SHA256 hash of the image")
+	}
+	return tlvs
+}
+
+func TestMCUbootImage(t *testing.T) {
+	data := testFirmware(1000)
+	version := mcubootVersion{major: 1, minor: 2, revision: 3, build: 4}
+
+	t.Run("unsigned", func(t *testing.T) {
+		image, err := makeMCUbootImage(0x10200, data, version, 0, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tlvs := checkMCUbootImage(t, image, data, 0x200, version)
+		if len(tlvs) != 1 {
+			t.Errorf("expected only a hash TLV, got %d records", len(tlvs))
+		}
+	})
+
+	t.Run("ecdsa", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		image, err := makeMCUbootImage(0x10200, data, version, 0x100, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tlvs := checkMCUbootImage(t, image, data, 0x100, version)
+		if len(tlvs) != 3 || tlvs[1].tlvType != 0x01 || tlvs[2].tlvType != 0x22 {
+			t.Fatalf("unexpected TLV records: %v", tlvs)
+		}
+		checkKeyHash(t, tlvs[1].value, &key.PublicKey)
+		var signature struct{ R, S *big.Int }
+		if _, err := asn1.Unmarshal(tlvs[2].value, &signature); err != nil {
+			t.Fatal("could not parse signature:", err)
+		}
+		if !ecdsa.Verify(&key.PublicKey, tlvs[0].value, signature.R, signature.S) {
+			t.Error("invalid ECDSA signature")
+		}
+	})
+
+	t.Run("ed25519", func(t *testing.T) {
+		public, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		image, err := makeMCUbootImage(0x10200, data, version, 0, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tlvs := checkMCUbootImage(t, image, data, 0x200, version)
+		if len(tlvs) != 3 || tlvs[1].tlvType != 0x01 || tlvs[2].tlvType != 0x24 {
+			t.Fatalf("unexpected TLV records: %v", tlvs)
+		}
+		checkKeyHash(t, tlvs[1].value, public)
+		if !ed25519.Verify(public, tlvs[0].value, tlvs[2].value) {
+			t.Error("invalid Ed25519 signature")
+		}
+	})
+
+	t.Run("header size", func(t *testing.T) {
+		for _, size := range []uint32{16, 0x10000} {
+			if _, err := makeMCUbootImage(0x10200, data, version, size, nil); err == nil {
+				t.Errorf("expected an error for header size 0x%x", size)
+			}
+		}
+	})
+
+	t.Run("header space", func(t *testing.T) {
+		// Firmware linked at the start of flash leaves no room for the header.
+		if _, err := makeMCUbootImage(0, data, version, 0, nil); err == nil {
+			t.Error("expected an error for firmware at address 0")
+		}
+		if _, err := makeMCUbootImage(0x100, data, version, 0x200, nil); err == nil {
+			t.Error("expected an error for a header that overlaps the firmware")
+		}
+		if _, err := makeMCUbootImage(0x200, data, version, 0x200, nil); err != nil {
+			t.Errorf("unexpected error for a header in front of the firmware: %v", err)
+		}
+	})
+}
+
+// checkKeyHash checks that the key hash TLV is the SHA256 hash of the public
+// key.
+func checkKeyHash(t *testing.T, keyHash []byte, public interface{}) {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := sha256.Sum256(der)
+	if !bytes.Equal(keyHash, expected[:]) {
+		t.Error("key hash TLV does not match the public key")
+	}
+}
+
+func TestParseMCUbootVersion(t *testing.T) {
+	for _, tc := range []struct {
+		input    string
+		expected mcubootVersion
+		valid    bool
+	}{
+		{"", mcubootVersion{}, true},
+		{"1", mcubootVersion{major: 1}, true},
+		{"1.2.300+4000", mcubootVersion{1, 2, 300, 4000}, true},
+		{"256", mcubootVersion{}, false},
+		{"1.2.3.4", mcubootVersion{}, false},
+		{"1.2+x", mcubootVersion{}, false},
+	} {
+		version, err := parseMCUbootVersion(tc.input)
+		if (err == nil) != tc.valid {
+			t.Errorf("%#v: unexpected error state: %v", tc.input, err)
+			continue
+		}
+		if tc.valid && version != tc.expected {
+			t.Errorf("%#v: expected %+v, got %+v", tc.input, tc.expected, version)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"debug/elf"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sort"
 
 	"github.com/marcinbor85/gohex"
@@ -106,7 +107,7 @@ func extractROM(path string) (uint64, []byte, error) {
 }
 
 // objcopy converts an ELF file to a different (simpler) output file format:
-// .bin or .hex. It extracts only the .text section.
+// .bin, .hex or .srec. It extracts only the .text section.
 func objcopy(infile, outfile, binaryFormat string) error {
 	f, err := os.OpenFile(outfile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
 	if err != nil {
@@ -135,6 +136,9 @@ func objcopy(infile, outfile, binaryFormat string) error {
 		// should use .hex files in most cases).
 		_, err := f.Write(data)
 		return err
+	case "srec":
+		// Motorola S-record file, which also includes the start address.
+		return writeSRecord(f, uint32(addr), data, filepath.Base(outfile))
 	default:
 		panic("unreachable")
 	}
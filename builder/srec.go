@@ -0,0 +1,79 @@
+package builder
+
+// This file implements writing Motorola S-record files. It is a text based
+// format similar to Intel hex files and is supported by many (older) flashing
+// tools.
+//
+// For more information, see:
+// https://en.wikipedia.org/wiki/SREC_(file_format)
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// srecLineLength is the number of data bytes per S-record line, the same as
+// used by GNU objcopy.
+const srecLineLength = 16
+
+// writeSRecord writes the given firmware image, loaded at the given address,
+// as a Motorola S-record file. The smallest address width that can hold all
+// addresses is used (S1, S2 or S3 records).
+func writeSRecord(w io.Writer, addr uint32, data []byte, name string) error {
+	buf := bufio.NewWriter(w)
+
+	// Determine the address width, and with that the data and termination
+	// record types to use.
+	var dataType, endType byte
+	var addrLen int
+	end := uint64(addr) + uint64(len(data))
+	switch {
+	case end <= 0x10000:
+		dataType, endType, addrLen = '1', '9', 2
+	case end <= 0x1000000:
+		dataType, endType, addrLen = '2', '8', 3
+	default:
+		dataType, endType, addrLen = '3', '7', 4
+	}
+
+	// Header record, containing the file name (without address).
+	writeSRecordLine(buf, '0', 2, 0, []byte(name))
+
+	// Data records.
+	numRecords := 0
+	for offset := 0; offset < len(data); offset += srecLineLength {
+		line := data[offset:]
+		if len(line) > srecLineLength {
+			line = line[:srecLineLength]
+		}
+		writeSRecordLine(buf, dataType, addrLen, addr+uint32(offset), line)
+		numRecords++
+	}
+
+	// Record count, only if it fits in the S5 record (16 bits).
+	if numRecords <= 0xffff {
+		writeSRecordLine(buf, '5', 2, uint32(numRecords), nil)
+	}
+
+	// Termination record, containing the start address.
+	writeSRecordLine(buf, endType, addrLen, addr, nil)
+
+	return buf.Flush()
+}
+
+// writeSRecordLine writes a single S-record line to the given writer.
+func writeSRecordLine(w *bufio.Writer, recordType byte, addrLen int, addr uint32, data []byte) {
+	record := make([]byte, 0, 1+addrLen+len(data)+1)
+	record = append(record, byte(addrLen+len(data)+1)) // byte count
+	for i := addrLen - 1; i >= 0; i-- {
+		record = append(record, byte(addr>>(uint(i)*8)))
+	}
+	record = append(record, data...)
+	var sum byte
+	for _, b := range record {
+		sum += b
+	}
+	record = append(record, ^sum) // checksum
+	fmt.Fprintf(w, "S%c%X\n", recordType, record)
+}
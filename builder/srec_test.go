@@ -0,0 +1,106 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Pass -update to go test to update the golden files of the tests.
+var flagUpdate = flag.Bool("update", false, "Update golden files based on test output.")
+
+// testFirmware returns a firmware image of the given size with a recognizable
+// pattern.
+func testFirmware(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i*7 + 3)
+	}
+	return data
+}
+
+// checkGolden compares the output of a test against the golden file in the
+// testdata directory, or updates the file when -update is passed.
+func checkGolden(t *testing.T, name string, actual []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *flagUpdate {
+		err := ioutil.WriteFile(path, actual, 0666)
+		if err != nil {
+			t.Fatal("could not update golden file:", err)
+		}
+		return
+	}
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal("could not read golden file:", err)
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("output does not match %s", path)
+	}
+}
+
+func TestSRecord(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		addr       uint32
+		size       int
+		dataType   string
+		endType    string
+		numRecords int
+	}{
+		{"s1", 0x0000, 40, "S1", "S9", 3},     // 16-bit addresses (AVR)
+		{"s2", 0xfff8, 20, "S2", "S8", 2},     // crosses the 16-bit boundary
+		{"s3", 0x08000000, 33, "S3", "S7", 3}, // 32-bit addresses (STM32)
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := writeSRecord(buf, tc.addr, testFirmware(tc.size), "main.srec")
+			if err != nil {
+				t.Fatal("could not write S-record file:", err)
+			}
+			checkGolden(t, "srec-"+tc.name+".srec", buf.Bytes())
+
+			// Independently check the structure and checksum of every
+			// record.
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			if len(lines) != tc.numRecords+3 {
+				t.Fatalf("expected %d records, got %d", tc.numRecords+3, len(lines))
+			}
+			for i, line := range lines {
+				record, err := hex.DecodeString(line[2:])
+				if err != nil {
+					t.Fatalf("line %d: %v", i+1, err)
+				}
+				if int(record[0]) != len(record)-1 {
+					t.Errorf("line %d: byte count is %d, expected %d", i+1, record[0], len(record)-1)
+				}
+				var sum byte
+				for _, b := range record {
+					sum += b
+				}
+				if sum != 0xff {
+					t.Errorf("line %d: invalid checksum %02X", i+1, record[len(record)-1])
+				}
+				var recordType string
+				switch {
+				case i == 0:
+					recordType = "S0"
+				case i <= tc.numRecords:
+					recordType = tc.dataType
+				case i == tc.numRecords+1:
+					recordType = "S5"
+				default:
+					recordType = tc.endType
+				}
+				if line[:2] != recordType {
+					t.Errorf("line %d: expected record type %s, got %s", i+1, recordType, line[:2])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package builder
+
+// This file checks the imports of a WebAssembly module built for a host that
+// doesn't provide a standard set of imports (such as WASI), to make sure the
+// module only imports what the program declared.
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"tinygo.org/x/go-llvm"
+)
+
+// wasmImport is a single import of a WebAssembly module.
+type wasmImport struct {
+	module string
+	name   string
+}
+
+// checkWasmImports returns an error if the WebAssembly file imports anything
+// that isn't declared with //go:wasm-module in the program. Such an import
+// is usually pulled in by the C library, and the host won't be able to
+// provide it.
+func checkWasmImports(path string, mod llvm.Module) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	imports, err := readWasmImports(data)
+	if err != nil {
+		return fmt.Errorf("could not read imports of %s: %w", path, err)
+	}
+
+	// Collect all imports declared in the program.
+	declared := map[wasmImport]bool{}
+	for fn := mod.FirstFunction(); !fn.IsNil(); fn = llvm.NextFunction(fn) {
+		moduleAttr := fn.GetStringAttributeAtIndex(-1, "wasm-import-module")
+		if moduleAttr.IsNil() {
+			continue
+		}
+		name := fn.Name()
+		if nameAttr := fn.GetStringAttributeAtIndex(-1, "wasm-import-name"); !nameAttr.IsNil() {
+			name = nameAttr.GetStringValue()
+		}
+		declared[wasmImport{moduleAttr.GetStringValue(), name}] = true
+	}
+
+	for _, imp := range imports {
+		if !declared[imp] {
+			return fmt.Errorf("%s imports %s.%s, which was not declared with //go:wasm-module in the program", path, imp.module, imp.name)
+		}
+	}
+	return nil
+}
+
+// readWasmImports returns the list of imports in the import section of the
+// given WebAssembly binary.
+func readWasmImports(data []byte) ([]wasmImport, error) {
+	if len(data) < 8 || !bytes.Equal(data[:4], []byte("\x00asm")) {
+		return nil, errors.New("not a WebAssembly file")
+	}
+	r := &wasmReader{data: data[8:]}
+	for len(r.data) != 0 {
+		id := r.byte()
+		size := r.uleb()
+		if r.err != nil {
+			return nil, r.err
+		}
+		if uint64(len(r.data)) < size {
+			return nil, errors.New("unexpected end of file")
+		}
+		section := r.data[:size]
+		r.data = r.data[size:]
+		if id != 2 {
+			// Not the import section.
+			continue
+		}
+
+		sr := &wasmReader{data: section}
+		count := sr.uleb()
+		var imports []wasmImport
+		for i := uint64(0); i < count && sr.err == nil; i++ {
+			imp := wasmImport{module: sr.name(), name: sr.name()}
+			switch sr.byte() {
+			case 0: // function
+				sr.uleb()
+			case 1: // table
+				sr.byte()
+				sr.limits()
+			case 2: // memory
+				sr.limits()
+			case 3: // global
+				sr.byte()
+				sr.byte()
+			default:
+				return nil, fmt.Errorf("unknown kind of import %s.%s", imp.module, imp.name)
+			}
+			imports = append(imports, imp)
+		}
+		if sr.err != nil {
+			return nil, sr.err
+		}
+		return imports, nil
+	}
+	return nil, nil
+}
+
+// wasmReader reads values from a WebAssembly binary. The first error is
+// stored in err, after which all reads return zero values.
+type wasmReader struct {
+	data []byte
+	err  error
+}
+
+func (r *wasmReader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.data) == 0 {
+		r.err = errors.New("unexpected end of section")
+		return 0
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+	return b
+}
+
+// uleb reads an unsigned LEB128 integer. All integers that are read (sizes,
+// counts and indices) are 32-bit, so the encoding is at most 5 bytes long.
+func (r *wasmReader) uleb() uint64 {
+	var value uint64
+	for shift := uint(0); r.err == nil; shift += 7 {
+		b := r.byte()
+		if shift == 28 && b > 0x0f {
+			// The last byte may only contain the upper 4 bits of the value.
+			r.err = errors.New("integer too large")
+			return 0
+		}
+		value |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	return value
+}
+
+func (r *wasmReader) name() string {
+	size := r.uleb()
+	if r.err != nil {
+		return ""
+	}
+	if uint64(len(r.data)) < size {
+		r.err = errors.New("unexpected end of section")
+		return ""
+	}
+	s := string(r.data[:size])
+	r.data = r.data[size:]
+	return s
+}
+
+// limits reads the limits of a table or memory.
+func (r *wasmReader) limits() {
+	hasMaximum := r.byte()&1 != 0
+	r.uleb() // minimum
+	if hasMaximum {
+		r.uleb() // maximum
+	}
+}
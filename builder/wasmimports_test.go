@@ -0,0 +1,116 @@
+package builder
+
+import (
+	"reflect"
+	"testing"
+)
+
+// wasmModule returns a WebAssembly binary with the given sections. Each
+// section is given as the section ID followed by the contents. The contents
+// must be smaller than 128 bytes, so that the size fits in a single byte.
+func wasmModule(sections ...[]byte) []byte {
+	data := []byte("\x00asm\x01\x00\x00\x00")
+	for _, section := range sections {
+		data = append(data, section[0], byte(len(section)-1))
+		data = append(data, section[1:]...)
+	}
+	return data
+}
+
+func TestReadWasmImports(t *testing.T) {
+	typeSection := []byte{1, 1, 0x60, 0, 0} // one function type: func()
+	for _, tc := range []struct {
+		name    string
+		data    []byte
+		imports []wasmImport
+		err     string
+	}{
+		{
+			name: "no imports",
+			data: wasmModule(typeSection),
+		},
+		{
+			name: "all kinds",
+			data: wasmModule(typeSection, []byte{2, 4,
+				3, 'e', 'n', 'v', 1, 'f', 0, 0, // function of type 0
+				3, 'e', 'n', 'v', 1, 't', 1, 0x70, 1, 1, 2, // table with maximum
+				3, 'e', 'n', 'v', 1, 'm', 2, 0, 1, // memory without maximum
+				4, 'h', 'o', 's', 't', 1, 'g', 3, 0x7f, 1, // mutable i32 global
+			}),
+			imports: []wasmImport{{"env", "f"}, {"env", "t"}, {"env", "m"}, {"host", "g"}},
+		},
+		{
+			name:    "section after imports",
+			data:    wasmModule(typeSection, []byte{2, 1, 3, 'e', 'n', 'v', 1, 'f', 0, 0}, []byte{3, 1, 0}),
+			imports: []wasmImport{{"env", "f"}},
+		},
+		{
+			// Sizes may be encoded with more bytes than necessary.
+			name:    "padded LEB128",
+			data:    append([]byte("\x00asm\x01\x00\x00\x00\x02\x8b\x80\x80\x80\x00"), 1, 3, 'e', 'n', 'v', 0x81, 0x80, 0x00, 'f', 0, 0),
+			imports: []wasmImport{{"env", "f"}},
+		},
+		{
+			name:    "multi-byte count",
+			data:    wasmModule([]byte{2, 0x81, 0x00, 3, 'e', 'n', 'v', 1, 'f', 0, 0x80, 0x01}),
+			imports: []wasmImport{{"env", "f"}},
+		},
+		{
+			name: "LEB128 too long",
+			data: []byte("\x00asm\x01\x00\x00\x00\x02\x80\x80\x80\x80\x80\x00"),
+			err:  "integer too large",
+		},
+		{
+			name: "LEB128 too large",
+			data: []byte("\x00asm\x01\x00\x00\x00\x02\xff\xff\xff\xff\x1f"),
+			err:  "integer too large",
+		},
+		{
+			name: "truncated LEB128",
+			data: []byte("\x00asm\x01\x00\x00\x00\x02\x80"),
+			err:  "unexpected end of section",
+		},
+		{
+			name: "truncated section",
+			data: append([]byte("\x00asm\x01\x00\x00\x00\x02\x10"), 1, 3, 'e', 'n', 'v'),
+			err:  "unexpected end of file",
+		},
+		{
+			name: "truncated name",
+			data: wasmModule([]byte{2, 1, 3, 'e', 'n'}),
+			err:  "unexpected end of section",
+		},
+		{
+			name: "truncated import",
+			data: wasmModule([]byte{2, 2, 3, 'e', 'n', 'v', 1, 'f', 0, 0}),
+			err:  "unexpected end of section",
+		},
+		{
+			name: "unknown kind",
+			data: wasmModule([]byte{2, 1, 3, 'e', 'n', 'v', 1, 'x', 4, 0}),
+			err:  "unknown kind of import env.x",
+		},
+		{
+			name: "not WebAssembly",
+			data: []byte("\x7fELF\x01\x01\x01\x00"),
+			err:  "not a WebAssembly file",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			imports, err := readWasmImports(tc.data)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("could not read imports:", err)
+			}
+			if !reflect.DeepEqual(imports, tc.imports) {
+				t.Errorf("expected imports %v, got %v", tc.imports, imports)
+			}
+		})
+	}
+}
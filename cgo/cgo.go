@@ -14,6 +14,7 @@ package cgo
 import (
 	"fmt"
 	"go/ast"
+	"go/parser"
 	"go/scanner"
 	"go/token"
 	"path/filepath"
@@ -35,12 +36,14 @@ type cgoPackage struct {
 	tokenFiles      map[string]*token.File
 	missingSymbols  map[string]struct{}
 	constants       map[string]constantInfo
+	macros          map[string]*macroInfo
 	functions       map[string]*functionInfo
 	globals         map[string]globalInfo
 	typedefs        map[string]*typedefInfo
 	elaboratedTypes map[string]*elaboratedTypeInfo
 	enums           map[string]enumInfo
 	anonStructNum   int
+	cflags          []string
 	ldflags         []string
 }
 
@@ -51,6 +54,17 @@ type constantInfo struct {
 	pos  token.Pos
 }
 
+// macroInfo stores a #define found by libclang. Object-like macros are
+// converted to constants when they are used (see addMacros), function-like
+// macros are expanded where they are called (see expandMacro).
+type macroInfo struct {
+	pos      token.Pos // position of the macro name
+	valuePos token.Pos // position of the value (after the parameter list)
+	value    string
+	funcLike bool
+	params   []string
+}
+
 // functionInfo stores some information about a CGo function found by libclang
 // and declared in the AST.
 type functionInfo struct {
@@ -153,18 +167,56 @@ typedef long long           _Cgo_longlong;
 typedef unsigned long long  _Cgo_ulonglong;
 `
 
+// cgoBuiltins contains the helper functions that are part of the C
+// pseudo-package, such as C.CString. They are implemented in the runtime. The
+// "_C_" prefix is replaced with "C." after parsing, as such names cannot be
+// parsed directly. Only the helpers that are used are added to the AST, with
+// the exception of the __ prefixed internal functions, which are added when the
+// helper with the same name (without the prefix) is used.
+const cgoBuiltins = `
+package C
+
+import "unsafe"
+
+//go:linkname _C_CString runtime.cgo_CString
+func _C_CString(string) *_C_char
+
+//go:linkname _C_CBytes runtime.cgo_CBytes
+func _C_CBytes([]byte) unsafe.Pointer
+
+//go:linkname _C_GoString runtime.cgo_GoString
+func _C_GoString(*_C_char) string
+
+//go:linkname _C___GoStringN runtime.cgo_GoStringN
+func _C___GoStringN(*_C_char, uintptr) string
+
+func _C_GoStringN(cstr *_C_char, length _C_int) string {
+	return _C___GoStringN(cstr, uintptr(length))
+}
+
+//go:linkname _C___GoBytes runtime.cgo_GoBytes
+func _C___GoBytes(unsafe.Pointer, uintptr) []byte
+
+func _C_GoBytes(ptr unsafe.Pointer, length _C_int) []byte {
+	return _C___GoBytes(ptr, uintptr(length))
+}
+`
+
 // Process extracts `import "C"` statements from the AST, parses the comment
 // with libclang, and modifies the AST to use this information. It returns a
 // newly created *ast.File that should be added to the list of to-be-parsed
-// files. If there is one or more error, it returns these in the []error slice
-// but still modifies the AST.
-func Process(files []*ast.File, dir string, fset *token.FileSet, cflags []string) (*ast.File, []string, []error) {
+// files, the contents of the _cgo_export.h header for C files in the package
+// (empty if no functions are exported), and the flags from #cgo CFLAGS and
+// #cgo LDFLAGS lines. If there is one or more error, it returns these in the
+// []error slice but still modifies the AST.
+func Process(files []*ast.File, dir string, fset *token.FileSet, cflags []string) (*ast.File, string, []string, []string, []error) {
 	p := &cgoPackage{
 		dir:             dir,
 		fset:            fset,
 		tokenFiles:      map[string]*token.File{},
 		missingSymbols:  map[string]struct{}{},
 		constants:       map[string]constantInfo{},
+		macros:          map[string]*macroInfo{},
 		functions:       map[string]*functionInfo{},
 		globals:         map[string]globalInfo{},
 		typedefs:        map[string]*typedefInfo{},
@@ -185,7 +237,7 @@ func Process(files []*ast.File, dir string, fset *token.FileSet, cflags []string
 	// Find the absolute path for this package.
 	packagePath, err := filepath.Abs(fset.File(files[0].Pos()).Name())
 	if err != nil {
-		return nil, nil, []error{
+		return nil, "", nil, nil, []error{
 			scanner.Error{
 				Pos: fset.Position(files[0].Pos()),
 				Msg: "cgo: cannot find absolute path: " + err.Error(), // TODO: wrap this error
@@ -256,6 +308,9 @@ func Process(files []*ast.File, dir string, fset *token.FileSet, cflags []string
 		p.missingSymbols["_Cgo_"+name] = struct{}{}
 	}
 
+	// Declare the helper functions (C.CString etc) that are used.
+	p.addBuiltinDecls()
+
 	// Find `import "C"` statements in the file.
 	var statements []*ast.GenDecl
 	for _, f := range files {
@@ -360,7 +415,7 @@ func Process(files []*ast.File, dir string, fset *token.FileSet, cflags []string
 						continue
 					}
 					makePathsAbsolute(flags, packagePath)
-					cflags = append(cflags, flags...)
+					p.cflags = append(p.cflags, flags...)
 				case "LDFLAGS":
 					flags, err := shlex.Split(value)
 					if err != nil {
@@ -384,6 +439,7 @@ func Process(files []*ast.File, dir string, fset *token.FileSet, cflags []string
 	}
 
 	// Process all CGo imports.
+	cflags = append(cflags, p.cflags...)
 	for _, genDecl := range statements {
 		cgoComment := genDecl.Doc.Text()
 
@@ -424,10 +480,14 @@ func Process(files []*ast.File, dir string, fset *token.FileSet, cflags []string
 		astutil.Apply(f, p.walker, nil)
 	}
 
+	// Create the header with prototypes of exported functions, for use by C
+	// files in this package.
+	exportHeader := p.makeExportHeader(files, statements)
+
 	// Print the newly generated in-memory AST, for debugging.
 	//ast.Print(fset, p.generated)
 
-	return p.generated, p.ldflags, p.errors
+	return p.generated, exportHeader, p.cflags, p.ldflags, p.errors
 }
 
 // makePathsAbsolute converts some common path compiler flags (-I, -L) from
@@ -454,6 +514,44 @@ func makePathsAbsolute(args []string, packagePath string) {
 	}
 }
 
+// addBuiltinDecls adds the declarations of the CGo helper functions (see
+// cgoBuiltins) that are used in this package to the AST.
+func (p *cgoPackage) addBuiltinDecls() {
+	f, err := parser.ParseFile(p.fset, p.dir+"/!cgo-builtins.go", cgoBuiltins, parser.ParseComments)
+	if err != nil {
+		// This is a bug in the cgoBuiltins constant.
+		panic("cgo: could not parse builtins: " + err.Error())
+	}
+	for _, decl := range f.Decls {
+		decl, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		name := strings.TrimPrefix(decl.Name.Name, "_C_")
+		_, used := p.missingSymbols[name]
+		if strings.HasPrefix(name, "__") {
+			_, used = p.missingSymbols[name[2:]]
+		}
+		if !used {
+			continue
+		}
+
+		// Rename all _C_ identifiers to C. identifiers.
+		astutil.Apply(decl, func(cursor *astutil.Cursor) bool {
+			if ident, ok := cursor.Node().(*ast.Ident); ok && strings.HasPrefix(ident.Name, "_C_") {
+				ident.Name = "C." + ident.Name[len("_C_"):]
+			}
+			return true
+		}, nil)
+		if decl.Doc != nil {
+			for _, comment := range decl.Doc.List {
+				comment.Text = strings.Replace(comment.Text, "_C_", "C.", -1)
+			}
+		}
+		p.generated.Decls = append(p.generated.Decls, decl)
+	}
+}
+
 // addFuncDecls adds the C function declarations found by libclang in the
 // comment above the `import "C"` statement.
 func (p *cgoPackage) addFuncDecls() {
@@ -610,6 +708,153 @@ func (p *cgoPackage) addConstDecls() {
 	}
 }
 
+// addMacroDefinition stores a #define found in the C preamble or in an
+// included header, given its name and the source text after the name. Like in
+// C, a macro is function-like when the name is directly followed by a '('.
+func (p *cgoPackage) addMacroDefinition(name string, pos token.Pos, value string) {
+	macro := &macroInfo{
+		pos:      pos,
+		valuePos: pos + token.Pos(len(name)),
+		value:    value,
+	}
+	if strings.HasPrefix(value, "(") {
+		end := strings.IndexByte(value, ')')
+		if end < 0 {
+			// Invalid macro. Clang has already reported an error.
+			return
+		}
+		macro.funcLike = true
+		for _, param := range strings.Split(value[1:end], ",") {
+			if param := strings.TrimSpace(param); param != "" {
+				macro.params = append(macro.params, param)
+			}
+		}
+		macro.valuePos += token.Pos(end + 1)
+		macro.value = value[end+1:]
+	}
+	p.macros[name] = macro
+}
+
+// addMacros converts the object-like macros that are used from Go to
+// constants. All identifiers that are referenced by used macros (such as other
+// macros or typedefs in casts) are marked as used as well, so that they are
+// declared even when they aren't used directly from Go. This must be called
+// before the C declarations are read, so that these identifiers are included.
+func (p *cgoPackage) addMacros() {
+	var worklist []string
+	for name := range p.missingSymbols {
+		if _, ok := p.macros[name]; ok {
+			worklist = append(worklist, name)
+		}
+	}
+	sort.Strings(worklist)
+	for len(worklist) != 0 {
+		name := worklist[0]
+		worklist = worklist[1:]
+		macro := p.macros[name]
+
+		// Mark all identifiers used in this macro as used.
+		t := newTokenizer(macro.valuePos, p.fset, macro.value)
+		for t.token != token.EOF && t.token != token.ILLEGAL {
+			if t.token == token.IDENT && !macro.hasParam(t.value) {
+				if _, ok := cTypeKeywords[t.value]; !ok {
+					if _, ok := p.missingSymbols[t.value]; !ok {
+						p.missingSymbols[t.value] = struct{}{}
+						if _, ok := p.macros[t.value]; ok {
+							worklist = append(worklist, t.value)
+						}
+					}
+				}
+			}
+			t.Next()
+		}
+
+		if macro.funcLike {
+			// Expanded where it is used.
+			continue
+		}
+
+		// Try to convert this #define into a Go constant expression.
+		expr, scannerError := parseConst(macro.valuePos, p.fset, macro.value)
+		if scannerError != nil {
+			p.errors = append(p.errors, *scannerError)
+		}
+		if expr != nil {
+			// Parsing was successful.
+			expr = p.expandMacroCalls(expr, map[string]bool{name: true})
+			p.constants[name] = constantInfo{expr, macro.pos}
+		}
+	}
+}
+
+// hasParam returns whether the given name is a parameter of the macro.
+func (macro *macroInfo) hasParam(name string) bool {
+	for _, param := range macro.params {
+		if param == name {
+			return true
+		}
+	}
+	return false
+}
+
+// expandMacro returns the expression that results from calling the given
+// function-like macro, with the arguments of the call substituted for the
+// parameters. It returns nil if there is no function-like macro with this
+// name. Macros that are currently being expanded are listed in expanding and
+// are not expanded again, like in C.
+func (p *cgoPackage) expandMacro(name string, call *ast.CallExpr, expanding map[string]bool) ast.Expr {
+	macro, ok := p.macros[name]
+	if !ok || !macro.funcLike || expanding[name] {
+		return nil
+	}
+	if len(macro.params) != len(call.Args) {
+		p.addError(call.Lparen, fmt.Sprintf("cgo: macro %s takes %d arguments, got %d", name, len(macro.params), len(call.Args)))
+		return nil
+	}
+	params := map[string]ast.Expr{}
+	for i, param := range macro.params {
+		if param == "..." {
+			p.addError(call.Lparen, "cgo: variadic macro "+name+" is not supported")
+			return nil
+		}
+		params[param] = call.Args[i]
+	}
+	expr, scannerError := parseMacroBody(macro.valuePos, p.fset, macro.value, params)
+	if scannerError != nil {
+		p.errors = append(p.errors, *scannerError)
+		return nil
+	}
+	expanding[name] = true
+	expr = p.expandMacroCalls(expr, expanding)
+	delete(expanding, name)
+	return &ast.ParenExpr{
+		Lparen: expr.Pos(),
+		X:      expr,
+		Rparen: expr.End(),
+	}
+}
+
+// expandMacroCalls expands all calls to function-like macros in an expression
+// created by the constant parser, in which C names are identifiers of the form
+// C.name.
+func (p *cgoPackage) expandMacroCalls(expr ast.Expr, expanding map[string]bool) ast.Expr {
+	return astutil.Apply(expr, func(cursor *astutil.Cursor) bool {
+		call, ok := cursor.Node().(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		fun, ok := call.Fun.(*ast.Ident)
+		if !ok || !strings.HasPrefix(fun.Name, "C.") {
+			return true
+		}
+		if expr := p.expandMacro(fun.Name[len("C."):], call, expanding); expr != nil {
+			cursor.Replace(expr)
+			return false
+		}
+		return true
+	}, nil).(ast.Expr)
+}
+
 // addVarDecls declares external C globals in the Go source.
 // It adds code like the following to the AST:
 //
@@ -1346,6 +1591,14 @@ func (p *cgoPackage) walker(cursor *astutil.Cursor) bool {
 		if !ok {
 			return true
 		}
+		if _, ok := p.functions[fun.Sel.Name]; !ok && x.Name == "C" {
+			if expr := p.expandMacro(fun.Sel.Name, node, map[string]bool{}); expr != nil {
+				// The replacement is not walked by astutil.Apply, so walk it
+				// here to patch the arguments.
+				cursor.Replace(astutil.Apply(expr, p.walker, nil))
+				return false
+			}
+		}
 		if _, ok := p.functions[fun.Sel.Name]; ok && x.Name == "C" {
 			node.Fun = &ast.Ident{
 				NamePos: x.NamePos,
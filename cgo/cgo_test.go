@@ -39,7 +39,7 @@ func normalizeResult(result string) string {
 func TestCGo(t *testing.T) {
 	var cflags = []string{"--target=armv6m-none-eabi"}
 
-	for _, name := range []string{"basic", "errors", "types", "flags", "const"} {
+	for _, name := range []string{"basic", "errors", "types", "flags", "const", "builtins", "export"} {
 		name := name // avoid a race condition
 		t.Run(name, func(t *testing.T) {
 			// Skip tests that require specific Go version.
@@ -65,7 +65,7 @@ func TestCGo(t *testing.T) {
 			}
 
 			// Process the AST with CGo.
-			cgoAST, _, cgoErrors := Process([]*ast.File{f}, "testdata", fset, cflags)
+			cgoAST, exportHeader, _, _, cgoErrors := Process([]*ast.File{f}, "testdata", fset, cflags)
 
 			// Check the AST for type errors.
 			var typecheckErrors []error
@@ -128,6 +128,27 @@ func TestCGo(t *testing.T) {
 				}
 				t.Errorf("output did not match:\n%s", string(actual))
 			}
+
+			// Check the _cgo_export.h header, if one was generated.
+			if exportHeader != "" {
+				headerfile := filepath.Join("testdata", name+".out.h")
+				expectedBytes, err := ioutil.ReadFile(headerfile)
+				if err != nil && !*flagUpdate {
+					t.Fatalf("could not read expected header: %v", err)
+				}
+				expected := strings.Replace(string(expectedBytes), "\r\n", "\n", -1)
+				actual := strings.Replace(exportHeader, "testdata\\\\", "testdata/", -1)
+				if expected != actual {
+					if *flagUpdate {
+						err := ioutil.WriteFile(headerfile, []byte(actual), 0666)
+						if err != nil {
+							t.Error("could not write updated header file:", err)
+						}
+						return
+					}
+					t.Errorf("header did not match:\n%s", actual)
+				}
+			}
 		})
 	}
 }
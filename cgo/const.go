@@ -1,11 +1,18 @@
 package cgo
 
 // This file implements a parser of a subset of the C language, just enough to
-// parse common #define statements to Go constant expressions.
+// parse common #define statements to Go constant expressions. It supports
+// literals, references to other macros, casts to integer types, the unary and
+// binary arithmetic and bitwise operators, and calls to function-like macros.
+//
+// Integer literals with an unsigned suffix (like 1u or 0x0fUL) are converted to
+// a typed Go constant, so that a bitwise complement results in a mask of the
+// width of the type instead of a negative number.
 
 import (
 	"fmt"
 	"go/ast"
+	"go/constant"
 	"go/scanner"
 	"go/token"
 	"strings"
@@ -13,8 +20,16 @@ import (
 
 // parseConst parses the given string as a C constant.
 func parseConst(pos token.Pos, fset *token.FileSet, value string) (ast.Expr, *scanner.Error) {
+	return parseMacroBody(pos, fset, value, nil)
+}
+
+// parseMacroBody parses the body of a macro as a C constant expression. For
+// function-like macros, params maps the name of each parameter to the
+// expression that should be substituted for it.
+func parseMacroBody(pos token.Pos, fset *token.FileSet, value string, params map[string]ast.Expr) (ast.Expr, *scanner.Error) {
 	t := newTokenizer(pos, fset, value)
-	expr, err := parseConstExpr(t)
+	t.params = params
+	expr, err := parseConstExpr(t, 1)
 	if t.token != token.EOF {
 		return nil, &scanner.Error{
 			Pos: t.fset.Position(t.pos),
@@ -24,13 +39,93 @@ func parseConst(pos token.Pos, fset *token.FileSet, value string) (ast.Expr, *sc
 	return expr, err
 }
 
-// parseConstExpr parses a stream of C tokens to a Go expression.
-func parseConstExpr(t *tokenizer) (ast.Expr, *scanner.Error) {
+// binaryPrecedence lists the precedence of the supported binary operators in
+// C. It is different from the precedence of the same operators in Go, for
+// example bitwise and binds less tightly than addition in C.
+var binaryPrecedence = map[token.Token]int{
+	token.MUL: 6,
+	token.QUO: 6,
+	token.REM: 6,
+	token.ADD: 5,
+	token.SUB: 5,
+	token.SHL: 4,
+	token.SHR: 4,
+	token.AND: 3,
+	token.XOR: 2,
+	token.OR:  1,
+}
+
+// parseConstExpr parses a stream of C tokens to a Go expression. Only binary
+// operators with a precedence of at least minPrecedence are consumed, the
+// others are left to the caller.
+func parseConstExpr(t *tokenizer, minPrecedence int) (ast.Expr, *scanner.Error) {
+	x, err := parseUnaryExpr(t)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		precedence := binaryPrecedence[t.token]
+		if precedence < minPrecedence || t.value == "~" {
+			return x, nil
+		}
+		op := t.token
+		opPos := t.pos
+		t.Next()
+		y, err := parseConstExpr(t, precedence+1)
+		if err != nil {
+			return nil, err
+		}
+		x = makeBinaryExpr(x, op, opPos, y)
+	}
+}
+
+// makeBinaryExpr creates a binary expression. Operands are wrapped in
+// parentheses where needed to keep the C evaluation order when the expression
+// is printed as Go code.
+func makeBinaryExpr(x ast.Expr, op token.Token, opPos token.Pos, y ast.Expr) ast.Expr {
+	if xBinary, ok := x.(*ast.BinaryExpr); ok && xBinary.Op.Precedence() < op.Precedence() {
+		x = &ast.ParenExpr{Lparen: x.Pos(), X: x, Rparen: x.End()}
+	}
+	if yBinary, ok := y.(*ast.BinaryExpr); ok && yBinary.Op.Precedence() <= op.Precedence() {
+		y = &ast.ParenExpr{Lparen: y.Pos(), X: y, Rparen: y.End()}
+	}
+	return &ast.BinaryExpr{X: x, OpPos: opPos, Op: op, Y: y}
+}
+
+// parseUnaryExpr parses a single operand, possibly preceded by unary operators
+// or a cast.
+func parseUnaryExpr(t *tokenizer) (ast.Expr, *scanner.Error) {
 	switch t.token {
+	case token.ADD, token.SUB, token.XOR:
+		if t.value == "^" {
+			// A ^ is only valid as a binary operator. Bitwise complement is
+			// written as ~ in C.
+			break
+		}
+		opPos := t.pos
+		op := t.token
+		t.Next()
+		x, err := parseUnaryExpr(t)
+		if err != nil {
+			return nil, err
+		}
+		if op == token.SUB && isUnsignedExpr(x) {
+			// Negating an unsigned value wraps around in C, while it is a
+			// constant overflow in Go.
+			return negateUnsigned(opPos, x), nil
+		}
+		return &ast.UnaryExpr{
+			OpPos: opPos,
+			Op:    op,
+			X:     x,
+		}, nil
 	case token.LPAREN:
 		lparen := t.pos
+		if typeName, ok := parseCastType(t); ok {
+			return parseCast(t, lparen, typeName)
+		}
 		t.Next()
-		x, err := parseConstExpr(t)
+		x, err := parseConstExpr(t, 1)
 		if err != nil {
 			return nil, err
 		}
@@ -45,31 +140,471 @@ func parseConstExpr(t *tokenizer) (ast.Expr, *scanner.Error) {
 		t.Next()
 		return expr, nil
 	case token.INT, token.FLOAT, token.STRING, token.CHAR:
-		expr := &ast.BasicLit{
+		var expr ast.Expr = &ast.BasicLit{
 			ValuePos: t.pos,
 			Kind:     t.token,
 			Value:    t.value,
 		}
+		if t.token == token.INT {
+			if typeName := unsignedSuffixType(t.suffix); typeName != "" {
+				// Keep the type of the literal, like 5u (C.uint(5)).
+				expr = &ast.CallExpr{
+					Fun:    &ast.Ident{NamePos: t.pos, Name: typeName},
+					Lparen: t.pos,
+					Args:   []ast.Expr{expr},
+					Rparen: t.pos,
+				}
+			}
+		}
 		t.Next()
 		return expr, nil
 	case token.IDENT:
+		if param, ok := t.params[t.value]; ok {
+			// Parameter of a function-like macro.
+			t.Next()
+			switch param.(type) {
+			case *ast.Ident, *ast.BasicLit, *ast.ParenExpr, *ast.CallExpr, *ast.SelectorExpr:
+				return param, nil
+			default:
+				return &ast.ParenExpr{Lparen: param.Pos(), X: param, Rparen: param.End()}, nil
+			}
+		}
 		expr := &ast.Ident{
 			NamePos: t.pos,
 			Name:    "C." + t.value,
 		}
 		t.Next()
+		if t.token == token.LPAREN {
+			// Call of a function-like macro. It is expanded later, when all
+			// macros are known.
+			return parseCallArgs(t, expr)
+		}
 		return expr, nil
 	case token.EOF:
 		return nil, &scanner.Error{
 			Pos: t.fset.Position(t.pos),
 			Msg: "empty constant",
 		}
+	}
+	return nil, &scanner.Error{
+		Pos: t.fset.Position(t.pos),
+		Msg: fmt.Sprintf("unexpected token %s", t.token),
+	}
+}
+
+// parseCallArgs parses the argument list of a call to a function-like macro,
+// starting at the opening parenthesis.
+func parseCallArgs(t *tokenizer, fun ast.Expr) (ast.Expr, *scanner.Error) {
+	call := &ast.CallExpr{
+		Fun:    fun,
+		Lparen: t.pos,
+	}
+	t.Next()
+	for t.token != token.RPAREN {
+		arg, err := parseConstExpr(t, 1)
+		if err != nil {
+			return nil, err
+		}
+		call.Args = append(call.Args, arg)
+		if t.token == token.COMMA {
+			t.Next()
+		} else if t.token != token.RPAREN {
+			return nil, unexpectedToken(t, token.RPAREN)
+		}
+	}
+	call.Rparen = t.pos
+	t.Next()
+	return call, nil
+}
+
+// parseCastType checks whether the tokenizer (positioned at an opening
+// parenthesis) is at the start of a cast like (uint32_t) or (unsigned long). If
+// it is, the type is consumed and the Go name of the type is returned.
+// Otherwise the tokenizer is left untouched.
+//
+// Casts to a typedef are ambiguous in C without knowing all type names, so a
+// parenthesized identifier is only treated as a type when it is followed by
+// something that can only be an operand or when it is a well-known typedef
+// like uint32_t. For example, (FOO)-1 is parsed as a subtraction while
+// (uint32_t)-1 is parsed as a cast.
+func parseCastType(t *tokenizer) (string, bool) {
+	saved := *t
+	t.Next()
+	if t.token != token.IDENT {
+		*t = saved
+		return "", false
+	}
+	if _, ok := t.params[t.value]; ok {
+		*t = saved
+		return "", false
+	}
+	if _, ok := cTypeKeywords[t.value]; ok {
+		// Builtin type, like (unsigned int).
+		var words []string
+		for t.token == token.IDENT {
+			if _, ok := cTypeKeywords[t.value]; !ok {
+				*t = saved
+				return "", false
+			}
+			words = append(words, t.value)
+			t.Next()
+		}
+		typeName, ok := builtinCastType(words)
+		if !ok || t.token != token.RPAREN {
+			*t = saved
+			return "", false
+		}
+		t.Next()
+		return typeName, true
+	}
+	typeName := "C." + t.value
+	t.Next()
+	if t.token != token.RPAREN {
+		*t = saved
+		return "", false
+	}
+	t.Next()
+	if _, ok := castTypedefs[typeName]; ok {
+		// A well-known typedef from stdint.h or stddef.h, which can't be the
+		// name of a constant.
+		return typeName, true
+	}
+	switch {
+	case t.token == token.INT, t.token == token.FLOAT, t.token == token.CHAR, t.token == token.IDENT, t.token == token.LPAREN, t.value == "~":
+		return typeName, true
+	}
+	*t = saved
+	return "", false
+}
+
+// parseCast parses the operand of a cast and converts it to a Go type
+// conversion. A cast of a bitwise complement, like (uint32_t)~0, is converted
+// to ^C.uint32_t(0) as the Go conversion would otherwise overflow.
+//
+// Unlike a Go conversion, a C cast of an integer constant truncates the value
+// to the width of the type. If the operand is an integer constant that doesn't
+// fit in the type, it is replaced by the value after the cast. For example,
+// (uint8_t)0x1ff is converted to C.uint8_t(0xff) and (uint32_t)-1 to
+// C.uint32_t(0xffffffff). For types with a width that depends on the target
+// (like unsigned long) this is only possible for negative values: (unsigned
+// long)-1 is converted to ^C.ulong(0), which is correct for any width.
+func parseCast(t *tokenizer, lparen token.Pos, typeName string) (ast.Expr, *scanner.Error) {
+	x, err := parseUnaryExpr(t)
+	if err != nil {
+		return nil, err
+	}
+	// The type of a suffixed literal doesn't matter when it is cast to another
+	// type, so (uint8_t)0x1ffu is the same as (uint8_t)0x1ff.
+	x = stripSuffixType(x)
+	conversion := func(x ast.Expr) ast.Expr {
+		return &ast.CallExpr{
+			Fun: &ast.Ident{
+				NamePos: lparen,
+				Name:    typeName,
+			},
+			Lparen: x.Pos(),
+			Args:   []ast.Expr{x},
+			Rparen: x.End(),
+		}
+	}
+	if x, ok := x.(*ast.UnaryExpr); ok && x.Op == token.XOR {
+		return &ast.UnaryExpr{
+			OpPos: lparen,
+			Op:    token.XOR,
+			X:     conversion(stripSuffixType(x.X)),
+		}, nil
+	}
+	value, ok := evalIntConst(x)
+	if !ok || typeName == "float32" || typeName == "float64" {
+		// Not an integer constant, or a cast to a floating point type: the
+		// Go conversion has the same result.
+		return conversion(x), nil
+	}
+	if info, ok := castTypeWidths[typeName]; ok {
+		// The width of the type is known, so the cast can be evaluated.
+		bits := uint(info.bits)
+		mask := constant.Shift(constant.MakeInt64(1), token.SHL, bits)
+		mask = constant.BinaryOp(mask, token.SUB, constant.MakeInt64(1))
+		result := constant.BinaryOp(value, token.AND, mask)
+		if info.signed {
+			// Sign-extend the truncated value.
+			signBit := constant.Shift(constant.MakeInt64(1), token.SHL, bits-1)
+			if constant.Compare(result, token.GEQ, signBit) {
+				result = constant.BinaryOp(result, token.SUB, constant.BinaryOp(mask, token.ADD, constant.MakeInt64(1)))
+			}
+		}
+		if constant.Compare(result, token.EQL, value) {
+			return conversion(x), nil
+		}
+		var lit ast.Expr
+		if info.signed {
+			lit = &ast.BasicLit{ValuePos: x.Pos(), Kind: token.INT, Value: result.ExactString()}
+			if constant.Sign(result) < 0 {
+				lit = &ast.UnaryExpr{
+					OpPos: x.Pos(),
+					Op:    token.SUB,
+					X:     &ast.BasicLit{ValuePos: x.Pos(), Kind: token.INT, Value: constant.UnaryOp(token.SUB, result, 0).ExactString()},
+				}
+			}
+		} else {
+			u, _ := constant.Uint64Val(result)
+			lit = &ast.BasicLit{ValuePos: x.Pos(), Kind: token.INT, Value: fmt.Sprintf("%#x", u)}
+		}
+		return conversion(lit), nil
+	}
+	if constant.Sign(value) < 0 {
+		// The width of the type is not known, but a negative value can be
+		// written as the complement of a positive value. This results in the
+		// same value for signed types, and in the truncated value for
+		// unsigned types.
+		complement := constant.UnaryOp(token.XOR, value, 0)
+		return &ast.UnaryExpr{
+			OpPos: lparen,
+			Op:    token.XOR,
+			X:     conversion(&ast.BasicLit{ValuePos: x.Pos(), Kind: token.INT, Value: complement.ExactString()}),
+		}, nil
+	}
+	return conversion(x), nil
+}
+
+// unsignedSuffixType returns the Go type for an integer literal with the given
+// suffix, like C.ulong for 5UL. It returns the empty string for literals
+// without an unsigned suffix, which are left untyped.
+func unsignedSuffixType(suffix string) string {
+	suffix = strings.ToLower(suffix)
+	if !strings.Contains(suffix, "u") {
+		return ""
+	}
+	switch strings.Count(suffix, "l") {
+	case 0:
+		return "C.uint"
+	case 1:
+		return "C.ulong"
 	default:
-		return nil, &scanner.Error{
-			Pos: t.fset.Position(t.pos),
-			Msg: fmt.Sprintf("unexpected token %s", t.token),
+		return "C.ulonglong"
+	}
+}
+
+// stripSuffixType returns the literal inside a conversion created for a
+// suffixed literal, or the expression itself if it is not such a conversion.
+func stripSuffixType(x ast.Expr) ast.Expr {
+	if call, ok := x.(*ast.CallExpr); ok && len(call.Args) == 1 {
+		if lit, ok := call.Args[0].(*ast.BasicLit); ok && lit.Kind == token.INT && isUnsignedType(call.Fun) {
+			return lit
 		}
 	}
+	return x
+}
+
+// isUnsignedType returns whether the given expression is the name of an
+// unsigned integer type.
+func isUnsignedType(fun ast.Expr) bool {
+	ident, ok := fun.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	switch ident.Name {
+	case "C.uchar", "C.ushort", "C.uint", "C.ulong", "C.ulonglong", "C.size_t", "C.uintptr_t":
+		return true
+	}
+	info, ok := castTypeWidths[ident.Name]
+	return ok && !info.signed
+}
+
+// isUnsignedExpr returns whether the given expression has an unsigned integer
+// type, because one of its operands is a conversion to an unsigned type.
+func isUnsignedExpr(expr ast.Expr) bool {
+	switch expr := expr.(type) {
+	case *ast.CallExpr:
+		return len(expr.Args) == 1 && isUnsignedType(expr.Fun)
+	case *ast.ParenExpr:
+		return isUnsignedExpr(expr.X)
+	case *ast.UnaryExpr:
+		return isUnsignedExpr(expr.X)
+	case *ast.BinaryExpr:
+		if expr.Op == token.SHL || expr.Op == token.SHR {
+			// The type of a shift is the type of the left operand.
+			return isUnsignedExpr(expr.X)
+		}
+		return isUnsignedExpr(expr.X) || isUnsignedExpr(expr.Y)
+	}
+	return false
+}
+
+// negateUnsigned converts the negation of an unsigned expression to a Go
+// expression that wraps around like in C. For example, -1u is converted to
+// ^C.uint(0) and -(1u << 3) to (^(C.uint(1) << 3) + 1).
+func negateUnsigned(opPos token.Pos, x ast.Expr) ast.Expr {
+	if call, ok := x.(*ast.CallExpr); ok {
+		if lit := stripSuffixType(call); lit != x {
+			value, ok := evalIntConst(lit)
+			if ok && constant.Sign(value) > 0 {
+				// Negating a positive value is the same as the complement of
+				// that value minus one.
+				value = constant.BinaryOp(value, token.SUB, constant.MakeInt64(1))
+				call.Args[0] = &ast.BasicLit{ValuePos: lit.Pos(), Kind: token.INT, Value: value.ExactString()}
+				return &ast.UnaryExpr{OpPos: opPos, Op: token.XOR, X: call}
+			}
+			if ok {
+				// Negating zero results in zero.
+				return x
+			}
+		}
+	}
+	if _, ok := x.(*ast.ParenExpr); !ok {
+		x = &ast.ParenExpr{Lparen: x.Pos(), X: x, Rparen: x.End()}
+	}
+	sum := &ast.BinaryExpr{
+		X:     &ast.UnaryExpr{OpPos: opPos, Op: token.XOR, X: x},
+		OpPos: opPos,
+		Op:    token.ADD,
+		Y:     &ast.BasicLit{ValuePos: opPos, Kind: token.INT, Value: "1"},
+	}
+	return &ast.ParenExpr{Lparen: opPos, X: sum, Rparen: x.End()}
+}
+
+// castTypedefs contains the typedefs that are always treated as a type when
+// they appear in parentheses.
+var castTypedefs = map[string]struct{}{
+	"C.int8_t":    struct{}{},
+	"C.int16_t":   struct{}{},
+	"C.int32_t":   struct{}{},
+	"C.int64_t":   struct{}{},
+	"C.uint8_t":   struct{}{},
+	"C.uint16_t":  struct{}{},
+	"C.uint32_t":  struct{}{},
+	"C.uint64_t":  struct{}{},
+	"C.intptr_t":  struct{}{},
+	"C.uintptr_t": struct{}{},
+	"C.size_t":    struct{}{},
+}
+
+// castTypeWidths contains the width and signedness of the integer types that
+// have the same width on all targets.
+var castTypeWidths = map[string]struct {
+	bits   int
+	signed bool
+}{
+	"C.int8_t":    {8, true},
+	"C.int16_t":   {16, true},
+	"C.int32_t":   {32, true},
+	"C.int64_t":   {64, true},
+	"C.uint8_t":   {8, false},
+	"C.uint16_t":  {16, false},
+	"C.uint32_t":  {32, false},
+	"C.uint64_t":  {64, false},
+	"C.schar":     {8, true},
+	"C.uchar":     {8, false},
+	"C.short":     {16, true},
+	"C.ushort":    {16, false},
+	"C.longlong":  {64, true},
+	"C.ulonglong": {64, false},
+}
+
+// evalIntConst evaluates an integer constant expression, as parsed by
+// parseConstExpr. It returns false if the expression is not an integer
+// constant, for example because it refers to another macro.
+func evalIntConst(expr ast.Expr) (constant.Value, bool) {
+	switch expr := expr.(type) {
+	case *ast.BasicLit:
+		if expr.Kind != token.INT && expr.Kind != token.CHAR {
+			return nil, false
+		}
+		value := constant.MakeFromLiteral(expr.Value, expr.Kind, 0)
+		return value, value.Kind() == constant.Int
+	case *ast.ParenExpr:
+		return evalIntConst(expr.X)
+	case *ast.UnaryExpr:
+		x, ok := evalIntConst(expr.X)
+		if !ok {
+			return nil, false
+		}
+		return constant.UnaryOp(expr.Op, x, 0), true
+	case *ast.BinaryExpr:
+		x, ok := evalIntConst(expr.X)
+		if !ok {
+			return nil, false
+		}
+		y, ok := evalIntConst(expr.Y)
+		if !ok {
+			return nil, false
+		}
+		switch expr.Op {
+		case token.SHL, token.SHR:
+			shift, ok := constant.Uint64Val(y)
+			if !ok || shift > 64 {
+				return nil, false
+			}
+			return constant.Shift(x, expr.Op, uint(shift)), true
+		case token.QUO, token.REM:
+			if constant.Sign(y) == 0 {
+				return nil, false
+			}
+			if expr.Op == token.QUO {
+				// Integer division.
+				return constant.BinaryOp(x, token.QUO_ASSIGN, y), true
+			}
+			return constant.BinaryOp(x, token.REM, y), true
+		case token.ADD, token.SUB, token.MUL, token.AND, token.OR, token.XOR:
+			return constant.BinaryOp(x, expr.Op, y), true
+		}
+	}
+	return nil, false
+}
+
+// cTypeKeywords are the C keywords that can be part of the name of a builtin
+// arithmetic type.
+var cTypeKeywords = map[string]struct{}{
+	"char":     struct{}{},
+	"short":    struct{}{},
+	"int":      struct{}{},
+	"long":     struct{}{},
+	"signed":   struct{}{},
+	"unsigned": struct{}{},
+	"float":    struct{}{},
+	"double":   struct{}{},
+}
+
+// builtinCastType returns the Go type for a builtin C type, such as C.ulong for
+// "unsigned long int".
+func builtinCastType(words []string) (string, bool) {
+	count := map[string]int{}
+	for _, word := range words {
+		count[word]++
+	}
+	if count["signed"] != 0 && count["unsigned"] != 0 {
+		return "", false
+	}
+	prefix := ""
+	if count["unsigned"] != 0 {
+		prefix = "u"
+	}
+	switch {
+	case len(words) == 1 && words[0] == "float":
+		return "float32", true
+	case len(words) == 1 && words[0] == "double":
+		return "float64", true
+	case count["float"] != 0 || count["double"] != 0:
+		return "", false
+	case count["char"] == 1 && len(words) == 1:
+		return "C.char", true
+	case count["char"] == 1 && len(words) == 2:
+		if count["signed"] != 0 {
+			return "C.schar", true
+		}
+		return "C.uchar", true
+	case count["char"] != 0:
+		return "", false
+	case count["short"] == 1 && count["long"] == 0:
+		return "C." + prefix + "short", true
+	case count["long"] == 1 && count["short"] == 0:
+		return "C." + prefix + "long", true
+	case count["long"] == 2 && count["short"] == 0:
+		return "C." + prefix + "longlong", true
+	case count["short"] == 0 && count["long"] == 0 && count["int"] <= 1:
+		return "C." + prefix + "int", true
+	}
+	return "", false
 }
 
 // unexpectedToken returns an error of the form "unexpected token FOO, expected
@@ -83,11 +618,13 @@ func unexpectedToken(t *tokenizer, expected token.Token) *scanner.Error {
 
 // tokenizer reads C source code and converts it to Go tokens.
 type tokenizer struct {
-	pos   token.Pos
-	fset  *token.FileSet
-	token token.Token
-	value string
-	buf   string
+	pos    token.Pos
+	fset   *token.FileSet
+	token  token.Token
+	value  string
+	suffix string // suffix of a numeric literal, like "UL" or "f"
+	buf    string
+	params map[string]ast.Expr // parameters of a function-like macro
 }
 
 // newTokenizer initializes a new tokenizer, positioned at the first token in
@@ -106,7 +643,8 @@ func newTokenizer(start token.Pos, fset *token.FileSet, buf string) *tokenizer {
 // Next consumes the next token in the stream. There is no return value, read
 // the next token from the pos, token and value properties.
 func (t *tokenizer) Next() {
-	t.pos += token.Pos(len(t.value))
+	t.pos += token.Pos(len(t.value) + len(t.suffix))
+	t.suffix = ""
 	for {
 		if len(t.buf) == 0 {
 			t.token = token.EOF
@@ -120,17 +658,47 @@ func (t *tokenizer) Next() {
 			// https://en.cppreference.com/w/cpp/string/byte/isspace
 			t.pos++
 			t.buf = t.buf[1:]
-		case c == '(' || c == ')':
+		case c == '(' || c == ')' || c == ',' || c == '+' || c == '-' || c == '*' || c == '/' || c == '%' || c == '&' || c == '|' || c == '^' || c == '~':
 			// Single-character tokens.
 			switch c {
 			case '(':
 				t.token = token.LPAREN
 			case ')':
 				t.token = token.RPAREN
+			case ',':
+				t.token = token.COMMA
+			case '+':
+				t.token = token.ADD
+			case '-':
+				t.token = token.SUB
+			case '*':
+				t.token = token.MUL
+			case '/':
+				t.token = token.QUO
+			case '%':
+				t.token = token.REM
+			case '&':
+				t.token = token.AND
+			case '|':
+				t.token = token.OR
+			case '^', '~':
+				// Go uses ^ both for bitwise xor and bitwise complement. The
+				// value is used to tell them apart.
+				t.token = token.XOR
 			}
 			t.value = t.buf[:1]
 			t.buf = t.buf[1:]
 			return
+		case strings.HasPrefix(t.buf, "<<") || strings.HasPrefix(t.buf, ">>"):
+			// Shift operators.
+			if c == '<' {
+				t.token = token.SHL
+			} else {
+				t.token = token.SHR
+			}
+			t.value = t.buf[:2]
+			t.buf = t.buf[2:]
+			return
 		case c >= '0' && c <= '9':
 			// Numeric constant (int, float, etc.).
 			// Find the last non-numeric character.
@@ -146,18 +714,19 @@ func (t *tokenizer) Next() {
 					break
 				}
 			}
-			t.value = t.buf[:tokenLen]
+			literal := t.buf[:tokenLen]
 			t.buf = t.buf[tokenLen:]
 			if hasDot {
 				// Integer constants are more complicated than this but this is
 				// a close approximation.
 				// https://en.cppreference.com/w/cpp/language/integer_literal
 				t.token = token.FLOAT
-				t.value = strings.TrimRight(t.value, "f")
+				t.value = strings.TrimRight(literal, "f")
 			} else {
 				t.token = token.INT
-				t.value = strings.TrimRight(t.value, "uUlL")
+				t.value = strings.TrimRight(literal, "uUlL")
 			}
+			t.suffix = literal[len(t.value):]
 			return
 		case c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c == '_':
 			// Identifier. Find all remaining tokens that are part of this
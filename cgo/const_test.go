@@ -31,6 +31,49 @@ func TestParseConst(t *testing.T) {
 		{`0b10`, `0b10`},
 		{`0x1234_5678`, `0x1234_5678`},
 		{`5 5`, `error: 1:3: unexpected token INT`}, // test for a bugfix
+		{`1 + 2`, `1 + 2`},
+		{`-5`, `-5`},
+		{`~0x0f`, `^0x0f`},
+		{`^5`, `error: 1:1: unexpected token ^`},
+		{`1 << 4 | 2`, `1<<4 | 2`},
+		{`1 | 2 << 4`, `1 | 2<<4`},
+		{`1 + 2 & 3`, `(1 + 2) & 3`},
+		{`8 - 4 - 2`, `8 - 4 - 2`},
+		{`8 - (4 - 2)`, `8 - (4 - 2)`},
+		{`(1u << (5))`, `(C.uint(1) << (5))`},
+		{`5UL`, `C.ulong(5)`},
+		{`5ull`, `C.ulonglong(5)`},
+		{`5L`, `5`},
+		{`~0u`, `^C.uint(0)`},
+		{`~0x0fUL`, `^C.ulong(0x0f)`},
+		{`~(1u << 3)`, `^(C.uint(1) << 3)`},
+		{`-1u`, `^C.uint(0)`},
+		{`-0u`, `C.uint(0)`},
+		{`-(1u << 3)`, `(^(C.uint(1) << 3) + 1)`},
+		{`(uint8_t)0x1ffu`, `C.uint8_t(0xff)`},
+		{`(uint32_t)~0u`, `^C.uint32_t(0)`},
+		{`5u +`, `error: 1:5: empty constant`},
+		{`FOO | BAR`, `C.FOO | C.BAR`},
+		{`((uint32_t)0x40000000)`, `(C.uint32_t(0x40000000))`},
+		{`(unsigned long int)5`, `C.ulong(5)`},
+		{`(signed char)-1`, `C.schar(-1)`},
+		{`(double)1`, `float64(1)`},
+		{`(uint32_t)~0`, `^C.uint32_t(0)`},
+		{`((uint32_t)-1)`, `(C.uint32_t(0xffffffff))`},
+		{`(uint8_t)0x1ff`, `C.uint8_t(0xff)`},
+		{`(uint16_t)(1 << 16 | 5)`, `C.uint16_t(0x5)`},
+		{`(int8_t)200`, `C.int8_t(-56)`},
+		{`(int16_t)-5`, `C.int16_t(-5)`},
+		{`(unsigned long)-1`, `^C.ulong(0)`},
+		{`(unsigned int)-(3)`, `^C.uint(2)`},
+		{`(FOO)-1`, `(C.FOO) - 1`},
+		{`(uint32_t)FOO`, `C.uint32_t(C.FOO)`},
+		{`(FOO) - 1`, `(C.FOO) - 1`},
+		{`(FOO)(1)`, `C.FOO((1))`},
+		{`BIT(3) | BIT(4, 5)`, `C.BIT(3) | C.BIT(4, 5)`},
+		{`BIT(3`, `error: 1:6: unexpected token EOF, expected )`},
+		{`1 +`, `error: 1:4: empty constant`},
+		{`1 && 2`, `error: 1:4: unexpected token &`},
 	} {
 		fset := token.NewFileSet()
 		startPos := fset.AddFile("", -1, 1000).Pos(0)
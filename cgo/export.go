@@ -0,0 +1,312 @@
+package cgo
+
+// This file generates the _cgo_export.h header for Go functions exported to C
+// with //export, so that C files in the same package can call them.
+
+import (
+	"go/ast"
+	"strconv"
+	"strings"
+)
+
+// exportHeaderPrologue declares the Go types that may be used in the
+// signatures of exported functions. Their layout matches the layout used by
+// the compiler: int and uint have the size of a pointer, strings are a pointer
+// and length and slices are a pointer, length and capacity. Note that strings
+// and slices are not passed to exported functions as a GoString or GoSlice:
+// the compiler passes each field as a separate parameter, see
+// exportParams.
+const exportHeaderPrologue = `#include <stddef.h>
+#include <stdint.h>
+
+#ifndef GO_CGO_PROLOGUE_H
+#define GO_CGO_PROLOGUE_H
+
+typedef int8_t GoInt8;
+typedef uint8_t GoUint8;
+typedef int16_t GoInt16;
+typedef uint16_t GoUint16;
+typedef int32_t GoInt32;
+typedef uint32_t GoUint32;
+typedef int64_t GoInt64;
+typedef uint64_t GoUint64;
+typedef intptr_t GoInt;
+typedef uintptr_t GoUint;
+typedef uintptr_t GoUintptr;
+typedef float GoFloat32;
+typedef double GoFloat64;
+#ifdef __cplusplus
+typedef bool GoBool;
+#else
+typedef _Bool GoBool;
+#endif
+typedef struct { const char *p; uintptr_t n; } GoString;
+typedef struct { void *data; uintptr_t len; uintptr_t cap; } GoSlice;
+
+#endif
+`
+
+// exportBasicTypes maps Go basic types to the equivalent type in the
+// _cgo_export.h header.
+var exportBasicTypes = map[string]string{
+	"bool":    "GoBool",
+	"int8":    "GoInt8",
+	"uint8":   "GoUint8",
+	"byte":    "GoUint8",
+	"int16":   "GoInt16",
+	"uint16":  "GoUint16",
+	"int32":   "GoInt32",
+	"rune":    "GoInt32",
+	"uint32":  "GoUint32",
+	"int64":   "GoInt64",
+	"uint64":  "GoUint64",
+	"int":     "GoInt",
+	"uint":    "GoUint",
+	"uintptr": "GoUintptr",
+	"float32": "GoFloat32",
+	"float64": "GoFloat64",
+	"string":  "GoString",
+}
+
+// exportBuiltinTypes maps the builtin C types (see builtinAliases) to their
+// spelling in C.
+var exportBuiltinTypes = map[string]string{
+	"char":      "char",
+	"schar":     "signed char",
+	"uchar":     "unsigned char",
+	"short":     "short",
+	"ushort":    "unsigned short",
+	"int":       "int",
+	"uint":      "unsigned int",
+	"long":      "long",
+	"ulong":     "unsigned long",
+	"longlong":  "long long",
+	"ulonglong": "unsigned long long",
+}
+
+// makeExportHeader creates the contents of the _cgo_export.h header file. It
+// contains all CGo preambles (so that C types used in exported functions are
+// declared) followed by a prototype for each function marked with //export or
+// //go:export. It returns the empty string if there are no exported functions.
+//
+// It must be called after the AST has been rewritten by the walker, so that C
+// types are referenced as "C.name" identifiers.
+func (p *cgoPackage) makeExportHeader(files []*ast.File, statements []*ast.GenDecl) string {
+	var prototypes []string
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			decl, ok := decl.(*ast.FuncDecl)
+			if !ok || decl.Doc == nil {
+				continue
+			}
+			name := exportName(decl.Doc)
+			if name == "" {
+				continue
+			}
+			if decl.Recv != nil {
+				p.addError(decl.Pos(), "cgo: cannot export method "+decl.Name.Name)
+				continue
+			}
+			if prototype, ok := p.makeExportPrototype(name, decl.Type); ok {
+				prototypes = append(prototypes, prototype)
+			}
+		}
+	}
+	if len(prototypes) == 0 {
+		return ""
+	}
+
+	buf := &strings.Builder{}
+	buf.WriteString("/* Code generated by tinygo. DO NOT EDIT. */\n\n")
+	buf.WriteString("/* Start of preamble from import \"C\" comments. */\n\n")
+	for _, genDecl := range statements {
+		if genDecl.Doc == nil {
+			continue
+		}
+		// The preamble starts on the line after the opening /* (see
+		// parseFragment).
+		position := p.fset.PositionFor(genDecl.Doc.Pos(), true)
+		buf.WriteString("#line " + strconv.Itoa(position.Line+1) + " " + strconv.Quote(position.Filename) + "\n")
+		buf.WriteString(genDecl.Doc.Text())
+		buf.WriteString("\n")
+	}
+	buf.WriteString("/* End of preamble from import \"C\" comments. */\n\n")
+	buf.WriteString("#line 1 \"cgo-generated-wrapper\"\n\n")
+	buf.WriteString(exportHeaderPrologue)
+	buf.WriteString("\n#ifdef __cplusplus\nextern \"C\" {\n#endif\n\n")
+	for _, prototype := range prototypes {
+		buf.WriteString(prototype)
+	}
+	buf.WriteString("\n#ifdef __cplusplus\n}\n#endif\n")
+	return buf.String()
+}
+
+// exportName returns the C name of a function given its doc comment, or the
+// empty string if the function isn't exported.
+func exportName(doc *ast.CommentGroup) string {
+	for _, comment := range doc.List {
+		parts := strings.Fields(comment.Text)
+		if len(parts) == 2 && (parts[0] == "//export" || parts[0] == "//go:export") {
+			return parts[1]
+		}
+	}
+	return ""
+}
+
+// makeExportPrototype returns the C prototype of an exported Go function. If
+// the signature cannot be expressed in C, an error is added and false is
+// returned.
+func (p *cgoPackage) makeExportPrototype(name string, funcType *ast.FuncType) (string, bool) {
+	result := "void"
+	if funcType.Results != nil && len(funcType.Results.List) != 0 {
+		if len(funcType.Results.List) != 1 || len(funcType.Results.List[0].Names) > 1 {
+			p.addError(funcType.Results.Pos(), "cgo: exported function "+name+" may have at most one result")
+			return "", false
+		}
+		resultType := funcType.Results.List[0].Type
+		if isExportAggregate(resultType) {
+			// These are returned as a LLVM struct, which doesn't match the
+			// way a C struct is returned on most architectures.
+			p.addError(resultType.Pos(), "cgo: exported function "+name+" cannot return a string or slice")
+			return "", false
+		}
+		if p.isExportRecord(resultType) {
+			p.addError(resultType.Pos(), "cgo: exported function "+name+" cannot return a C struct or union by value")
+			return "", false
+		}
+		typ, ok := p.exportType(resultType)
+		if !ok {
+			return "", false
+		}
+		result = typ
+	}
+
+	var params []string
+	index := 0 // index of the Go parameter, used for unnamed parameters
+	for _, field := range funcType.Params.List {
+		if len(field.Names) == 0 {
+			fieldParams, ok := p.exportParams(name, field.Type, "p"+strconv.Itoa(index))
+			index++
+			if !ok {
+				return "", false
+			}
+			params = append(params, fieldParams...)
+			continue
+		}
+		for _, ident := range field.Names {
+			paramName := ident.Name
+			if paramName == "_" {
+				paramName = "p" + strconv.Itoa(index)
+			}
+			index++
+			fieldParams, ok := p.exportParams(name, field.Type, paramName)
+			if !ok {
+				return "", false
+			}
+			params = append(params, fieldParams...)
+		}
+	}
+	if len(params) == 0 {
+		params = []string{"void"}
+	}
+	return "extern " + result + " " + name + "(" + strings.Join(params, ", ") + ");\n", true
+}
+
+// exportParams returns the C parameter declarations for a single Go
+// parameter. Most parameters map to a single C parameter, but strings and
+// slices are split in their fields by the compiler (see maxFieldsPerParam in
+// the compiler package), so they map to a parameter for each field: the
+// pointer and length of a string and the pointer, length and capacity of a
+// slice. C structs and unions are not supported, see isExportRecord.
+func (p *cgoPackage) exportParams(funcName string, expr ast.Expr, name string) ([]string, bool) {
+	if p.isExportRecord(expr) {
+		p.addError(expr.Pos(), "cgo: exported function "+funcName+" cannot take a C struct or union by value")
+		return nil, false
+	}
+	if ident, ok := expr.(*ast.Ident); ok && ident.Name == "string" {
+		return []string{"const char* " + name + "_p", "GoUintptr " + name + "_n"}, true
+	}
+	if array, ok := expr.(*ast.ArrayType); ok && array.Len == nil {
+		return []string{"void* " + name + "_data", "GoUintptr " + name + "_len", "GoUintptr " + name + "_cap"}, true
+	}
+	typ, ok := p.exportType(expr)
+	if !ok {
+		return nil, false
+	}
+	return []string{typ + " " + name}, true
+}
+
+// isExportAggregate returns whether the given type expression is a string or
+// slice, which the compiler passes as multiple values.
+func isExportAggregate(expr ast.Expr) bool {
+	if ident, ok := expr.(*ast.Ident); ok && ident.Name == "string" {
+		return true
+	}
+	if array, ok := expr.(*ast.ArrayType); ok && array.Len == nil {
+		return true
+	}
+	return false
+}
+
+// isExportRecord returns whether the given type expression is a C struct or
+// union, possibly through a typedef. The compiler splits small structs in
+// their fields and returns structs as a LLVM aggregate, neither of which
+// matches the C calling convention, so they can only be passed by pointer.
+func (p *cgoPackage) isExportRecord(expr ast.Expr) bool {
+	switch expr := expr.(type) {
+	case *ast.StructType:
+		return true
+	case *ast.Ident:
+		if !strings.HasPrefix(expr.Name, "C.") {
+			return false
+		}
+		name := expr.Name[len("C."):]
+		if strings.HasPrefix(name, "struct_") || strings.HasPrefix(name, "union_") {
+			return true
+		}
+		if typedef := p.typedefs[name]; typedef != nil {
+			return p.isExportRecord(typedef.typeExpr)
+		}
+	}
+	return false
+}
+
+// exportType returns the C spelling of the given Go type expression, as used
+// in the _cgo_export.h header. If the type cannot be used in an exported
+// function, an error is added and false is returned.
+func (p *cgoPackage) exportType(expr ast.Expr) (string, bool) {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if strings.HasPrefix(expr.Name, "C.") {
+			name := expr.Name[len("C."):]
+			if typ, ok := exportBuiltinTypes[name]; ok {
+				return typ, true
+			}
+			for _, kind := range []string{"struct", "union", "enum"} {
+				if strings.HasPrefix(name, kind+"_") {
+					return kind + " " + name[len(kind)+1:], true
+				}
+			}
+			return name, true
+		}
+		if typ, ok := exportBasicTypes[expr.Name]; ok {
+			return typ, true
+		}
+	case *ast.StarExpr:
+		typ, ok := p.exportType(expr.X)
+		if !ok {
+			return "", false
+		}
+		return typ + "*", true
+	case *ast.SelectorExpr:
+		if x, ok := expr.X.(*ast.Ident); ok && x.Name == "unsafe" && expr.Sel.Name == "Pointer" {
+			return "void*", true
+		}
+	case *ast.ArrayType:
+		if expr.Len == nil {
+			return "GoSlice", true
+		}
+	}
+	p.addError(expr.Pos(), "cgo: unsupported type in exported function")
+	return "", false
+}
@@ -54,6 +54,7 @@ CXType tinygo_clang_getEnumDeclIntegerType(GoCXCursor c);
 unsigned tinygo_clang_Cursor_isBitField(GoCXCursor c);
 
 int tinygo_clang_globals_visitor(GoCXCursor c, GoCXCursor parent, CXClientData client_data);
+int tinygo_clang_macro_visitor(GoCXCursor c, GoCXCursor parent, CXClientData client_data);
 int tinygo_clang_struct_visitor(GoCXCursor c, GoCXCursor parent, CXClientData client_data);
 int tinygo_clang_enum_visitor(GoCXCursor c, GoCXCursor parent, CXClientData client_data);
 */
@@ -137,6 +138,13 @@ func (p *cgoPackage) parseFragment(fragment string, cflags []string, posFilename
 	ref := storedRefs.Put(p)
 	defer storedRefs.Remove(ref)
 	cursor := C.tinygo_clang_getTranslationUnitCursor(unit)
+
+	// Macros may refer to other macros and to types that are defined earlier,
+	// so they are read (and the identifiers they use marked as used) before
+	// the declarations.
+	C.tinygo_clang_visitChildren(cursor, C.CXCursorVisitor(C.tinygo_clang_macro_visitor), C.CXClientData(ref))
+	p.addMacros()
+
 	C.tinygo_clang_visitChildren(cursor, C.CXCursorVisitor(C.tinygo_clang_globals_visitor), C.CXClientData(ref))
 }
 
@@ -204,54 +212,6 @@ func tinygo_clang_globals_visitor(c, parent C.GoCXCursor, client_data C.CXClient
 			typeExpr: p.makeASTType(cursorType, pos),
 			pos:      pos,
 		}
-	case C.CXCursor_MacroDefinition:
-		name := getString(C.tinygo_clang_getCursorSpelling(c))
-		if _, required := p.missingSymbols[name]; !required {
-			return C.CXChildVisit_Continue
-		}
-		sourceRange := C.tinygo_clang_getCursorExtent(c)
-		start := C.clang_getRangeStart(sourceRange)
-		end := C.clang_getRangeEnd(sourceRange)
-		var file, endFile C.CXFile
-		var startOffset, endOffset C.unsigned
-		C.clang_getExpansionLocation(start, &file, nil, nil, &startOffset)
-		if file == nil {
-			p.addError(pos, "internal error: could not find file where macro is defined")
-			break
-		}
-		C.clang_getExpansionLocation(end, &endFile, nil, nil, &endOffset)
-		if file != endFile {
-			p.addError(pos, "internal error: expected start and end location of a macro to be in the same file")
-			break
-		}
-		if startOffset > endOffset {
-			p.addError(pos, "internal error: start offset of macro is after end offset")
-			break
-		}
-
-		// read file contents and extract the relevant byte range
-		tu := C.tinygo_clang_Cursor_getTranslationUnit(c)
-		var size C.size_t
-		sourcePtr := C.clang_getFileContents(tu, file, &size)
-		if endOffset >= C.uint(size) {
-			p.addError(pos, "internal error: end offset of macro lies after end of file")
-			break
-		}
-		source := string(((*[1 << 28]byte)(unsafe.Pointer(sourcePtr)))[startOffset:endOffset:endOffset])
-		if !strings.HasPrefix(source, name) {
-			p.addError(pos, fmt.Sprintf("internal error: expected macro value to start with %#v, got %#v", name, source))
-			break
-		}
-		value := source[len(name):]
-		// Try to convert this #define into a Go constant expression.
-		expr, scannerError := parseConst(pos+token.Pos(len(name)), p.fset, value)
-		if scannerError != nil {
-			p.errors = append(p.errors, *scannerError)
-		}
-		if expr != nil {
-			// Parsing was successful.
-			p.constants[name] = constantInfo{expr, pos}
-		}
 	case C.CXCursor_EnumDecl:
 		// Visit all enums, because the fields may be used even when the enum
 		// type itself is not.
@@ -261,6 +221,60 @@ func tinygo_clang_globals_visitor(c, parent C.GoCXCursor, client_data C.CXClient
 	return C.CXChildVisit_Continue
 }
 
+// tinygo_clang_macro_visitor stores all macro definitions, see
+// addMacroDefinition. Errors are only reported for macros that are used, as
+// many macros (like those predefined by the compiler) have no usable source.
+//
+//export tinygo_clang_macro_visitor
+func tinygo_clang_macro_visitor(c, parent C.GoCXCursor, client_data C.CXClientData) C.int {
+	p := storedRefs.Get(unsafe.Pointer(client_data)).(*cgoPackage)
+	if C.tinygo_clang_getCursorKind(c) != C.CXCursor_MacroDefinition {
+		return C.CXChildVisit_Continue
+	}
+	pos := p.getCursorPosition(c)
+	name := getString(C.tinygo_clang_getCursorSpelling(c))
+	addError := func(msg string) {
+		if _, required := p.missingSymbols[name]; required {
+			p.addError(pos, msg)
+		}
+	}
+	sourceRange := C.tinygo_clang_getCursorExtent(c)
+	start := C.clang_getRangeStart(sourceRange)
+	end := C.clang_getRangeEnd(sourceRange)
+	var file, endFile C.CXFile
+	var startOffset, endOffset C.unsigned
+	C.clang_getExpansionLocation(start, &file, nil, nil, &startOffset)
+	if file == nil {
+		addError("internal error: could not find file where macro is defined")
+		return C.CXChildVisit_Continue
+	}
+	C.clang_getExpansionLocation(end, &endFile, nil, nil, &endOffset)
+	if file != endFile {
+		addError("internal error: expected start and end location of a macro to be in the same file")
+		return C.CXChildVisit_Continue
+	}
+	if startOffset > endOffset {
+		addError("internal error: start offset of macro is after end offset")
+		return C.CXChildVisit_Continue
+	}
+
+	// read file contents and extract the relevant byte range
+	tu := C.tinygo_clang_Cursor_getTranslationUnit(c)
+	var size C.size_t
+	sourcePtr := C.clang_getFileContents(tu, file, &size)
+	if endOffset >= C.uint(size) {
+		addError("internal error: end offset of macro lies after end of file")
+		return C.CXChildVisit_Continue
+	}
+	source := string(((*[1 << 28]byte)(unsafe.Pointer(sourcePtr)))[startOffset:endOffset:endOffset])
+	if !strings.HasPrefix(source, name) {
+		addError(fmt.Sprintf("internal error: expected macro value to start with %#v, got %#v", name, source))
+		return C.CXChildVisit_Continue
+	}
+	p.addMacroDefinition(name, pos, source[len(name):])
+	return C.CXChildVisit_Continue
+}
+
 func getString(clangString C.CXString) (s string) {
 	rawString := C.clang_getCString(clangString)
 	s = C.GoString(rawString)
@@ -0,0 +1,15 @@
+package main
+
+// The CGo helper functions don't need any declarations in the preamble.
+
+import "C"
+
+import "unsafe"
+
+var (
+	_ *C.char        = C.CString("foo")
+	_ unsafe.Pointer = C.CBytes([]byte{1, 2, 3})
+	_ string         = C.GoString(nil)
+	_ string         = C.GoStringN(nil, 3)
+	_ []byte         = C.GoBytes(nil, 3)
+)
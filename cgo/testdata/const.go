@@ -1,12 +1,51 @@
 package main
 
 /*
+#include <stdint.h>
+
 #define foo 3
 #define bar foo
+
+// Macros that are only used by other macros.
+#define unreferenced 4
+#define referenced unreferenced
+
+// Operators, casts and suffixes, as often found in vendor headers.
+#define PERIPH_BASE ((uint32_t)0x40000000UL)
+#define GPIOA_BASE  (PERIPH_BASE + 0x0800)
+#define MASK        (~0x0fUL)
+#define ALL_ONES    (~0u)
+#define SIGNED      ((int)-5)
+#define PRECEDENCE  (1 + 2 << 3 | 4 & 5)
+
+// Function-like macros.
+#define fnlike() 5
+#define fn_foo(x) ((x) + foo)
+#define REG_BIT(n) (1u << (n))
+#define TWO_ARGS(a, b) ((a) * (b))
+#define ALL_BITS (REG_BIT(0) | REG_BIT(3))
+#define NOT_BIT(n) (~(1u << (n)))
+#define NOT_BIT3 NOT_BIT(3)
 */
 import "C"
 
 const (
-	Foo = C.foo
-	Bar = C.bar
+	Foo        = C.foo
+	Bar        = C.bar
+	Referenced = C.referenced
+	GPIOA      = C.GPIOA_BASE
+	Mask       = C.MASK
+	Signed     = C.SIGNED
+	Precedence = C.PRECEDENCE
+	AllBits    = C.ALL_BITS
+	AllOnes    = C.ALL_ONES
+	NotBit3    = C.NOT_BIT3
+)
+
+var (
+	_ int     = C.fnlike()
+	_ int     = C.fn_foo(3)
+	_ C.uint  = C.REG_BIT(5)
+	_ C.uint  = C.NOT_BIT(5)
+	_ C.short = C.TWO_ARGS(2, C.foo)
 )
@@ -4,8 +4,18 @@ import "unsafe"
 
 var _ unsafe.Pointer
 
+const C.ALL_BITS = ((C.uint(1) << (0)) | (C.uint(1) << (3)))
+const C.ALL_ONES = (^C.uint(0))
+const C.GPIOA_BASE = (C.PERIPH_BASE + 0x0800)
+const C.MASK = (^C.ulong(0x0f))
+const C.NOT_BIT3 = (^(C.uint(1) << (3)))
+const C.PERIPH_BASE = (C.uint32_t(0x40000000))
+const C.PRECEDENCE = ((1+2)<<3 | 4&5)
+const C.SIGNED = (C.int(-5))
 const C.bar = C.foo
 const C.foo = 3
+const C.referenced = C.unreferenced
+const C.unreferenced = 4
 
 type C.int16_t = int16
 type C.int32_t = int32
@@ -0,0 +1,75 @@
+package main
+
+/*
+#include <stdint.h>
+
+typedef struct {
+	int x;
+	int y;
+} point_t;
+
+struct pair {
+	int a;
+	int b;
+};
+*/
+import "C"
+
+import "unsafe"
+
+//export add
+func add(a, b C.int) C.int {
+	return a + b
+}
+
+//export process
+func process(buf unsafe.Pointer, _ C.uint, name string, data []byte) bool {
+	return false
+}
+
+//go:export store
+func store(*C.char, C.ulonglong) {
+}
+
+//export goCallback
+func goCallback(cb *C.int) *C.schar {
+	return nil
+}
+
+//export divmod
+func divmod(a, b int) (int, int) {
+	return a / b, a % b
+}
+
+//export lookup
+func lookup(m map[string]int) {
+}
+
+//export greeting
+func greeting() string {
+	return "hello"
+}
+
+//export sum
+func sum([]C.int, C.int) C.int {
+	return 0
+}
+
+//export movePoint
+func movePoint(p C.point_t) {
+}
+
+//export makePair
+func makePair() C.struct_pair {
+	return C.struct_pair{}
+}
+
+//export swapPair
+func swapPair(p *C.struct_pair) *C.point_t {
+	return nil
+}
+
+// notExported is not exported to C.
+func notExported(x int) int {
+	return x
+}
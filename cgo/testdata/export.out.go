@@ -0,0 +1,41 @@
+// CGo errors:
+//     testdata/export.go:40:23: cgo: exported function divmod may have at most one result
+//     testdata/export.go:45:15: cgo: unsupported type in exported function
+//     testdata/export.go:49:17: cgo: exported function greeting cannot return a string or slice
+//     testdata/export.go:59:18: cgo: exported function movePoint cannot take a C struct or union by value
+//     testdata/export.go:63:17: cgo: exported function makePair cannot return a C struct or union by value
+
+package main
+
+import "unsafe"
+
+var _ unsafe.Pointer
+
+type C.int16_t = int16
+type C.int32_t = int32
+type C.int64_t = int64
+type C.int8_t = int8
+type C.uint16_t = uint16
+type C.uint32_t = uint32
+type C.uint64_t = uint64
+type C.uint8_t = uint8
+type C.uintptr_t = uintptr
+type C.char uint8
+type C.int int32
+type C.long int32
+type C.longlong int64
+type C.schar int8
+type C.short int16
+type C.uchar uint8
+type C.uint uint32
+type C.ulong uint32
+type C.ulonglong uint64
+type C.ushort uint16
+type C.point_t = struct {
+	x C.int
+	y C.int
+}
+type C.struct_pair struct {
+	a C.int
+	b C.int
+}
@@ -0,0 +1,64 @@
+/* Code generated by tinygo. DO NOT EDIT. */
+
+/* Start of preamble from import "C" comments. */
+
+#line 4 "testdata/export.go"
+#include <stdint.h>
+
+typedef struct {
+	int x;
+	int y;
+} point_t;
+
+struct pair {
+	int a;
+	int b;
+};
+
+/* End of preamble from import "C" comments. */
+
+#line 1 "cgo-generated-wrapper"
+
+#include <stddef.h>
+#include <stdint.h>
+
+#ifndef GO_CGO_PROLOGUE_H
+#define GO_CGO_PROLOGUE_H
+
+typedef int8_t GoInt8;
+typedef uint8_t GoUint8;
+typedef int16_t GoInt16;
+typedef uint16_t GoUint16;
+typedef int32_t GoInt32;
+typedef uint32_t GoUint32;
+typedef int64_t GoInt64;
+typedef uint64_t GoUint64;
+typedef intptr_t GoInt;
+typedef uintptr_t GoUint;
+typedef uintptr_t GoUintptr;
+typedef float GoFloat32;
+typedef double GoFloat64;
+#ifdef __cplusplus
+typedef bool GoBool;
+#else
+typedef _Bool GoBool;
+#endif
+typedef struct { const char *p; uintptr_t n; } GoString;
+typedef struct { void *data; uintptr_t len; uintptr_t cap; } GoSlice;
+
+#endif
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+extern int add(int a, int b);
+extern GoBool process(void* buf, unsigned int p1, const char* name_p, GoUintptr name_n, void* data_data, GoUintptr data_len, GoUintptr data_cap);
+extern void store(char* p0, unsigned long long p1);
+extern signed char* goCallback(int* cb);
+extern int sum(void* p0_data, GoUintptr p0_len, GoUintptr p0_cap, int p1);
+extern point_t* swapPair(struct pair* p);
+
+#ifdef __cplusplus
+}
+#endif
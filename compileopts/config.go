@@ -106,7 +106,8 @@ func (c *Config) NeedsStackObjects() bool {
 }
 
 // Scheduler returns the scheduler implementation. Valid values are "none",
-//"coroutines" and "tasks".
+//"coroutines" and "tasks". On WebAssembly, the tasks scheduler requires the
+// wasm-opt tool from Binaryen.
 func (c *Config) Scheduler() string {
 	if c.Options.Scheduler != "" {
 		return c.Options.Scheduler
@@ -192,12 +193,30 @@ func (c *Config) LDFlags() []string {
 	if c.Target.LinkerScript != "" {
 		ldflags = append(ldflags, "-T", c.Target.LinkerScript)
 	}
+	if c.Options.BuildID {
+		// Let the linker calculate a hash over the output file and store it
+		// in a .note.gnu.build-id section. The linker may be invoked
+		// directly (ld.lld, avr-ld, etc) or through a compiler driver.
+		if strings.HasSuffix(c.Target.Linker, "ld") || c.Target.Linker == "ld.lld" {
+			ldflags = append(ldflags, "--build-id=sha1")
+		} else {
+			ldflags = append(ldflags, "-Wl,--build-id=sha1")
+		}
+	}
 	return ldflags
 }
 
 // ExtraFiles returns the list of extra files to be built and linked with the
 // executable. This can include extra C and assembly files.
 func (c *Config) ExtraFiles() []string {
+	if c.Scheduler() == "tasks" && strings.HasPrefix(c.Triple(), "wasm") {
+		// Goroutines on WebAssembly are implemented using asyncify, which
+		// needs a bit of assembly to start and stop unwinding. This file
+		// cannot be included unconditionally as it imports the asyncify
+		// functions, which only exist after running wasm-opt --asyncify.
+		files := append([]string{}, c.Target.ExtraFiles...)
+		return append(files, "src/internal/task/task_asyncify_wasm.S")
+	}
 	return c.Target.ExtraFiles
 }
 
@@ -220,8 +239,12 @@ func (c *Config) Debug() bool {
 }
 
 // BinaryFormat returns an appropriate binary format, based on the file
-// extension and the configured binary format in the target JSON file.
+// extension and the configured binary format in the target JSON file. The
+// -format flag overrides both.
 func (c *Config) BinaryFormat(ext string) string {
+	if c.Options.BinaryFormat != "" {
+		return c.Options.BinaryFormat
+	}
 	switch ext {
 	case ".bin", ".gba", ".nro":
 		// The simplest format possible: dump everything in a raw binary file.
@@ -238,12 +261,39 @@ func (c *Config) BinaryFormat(ext string) string {
 		// More information:
 		// https://github.com/Microsoft/uf2
 		return "uf2"
+	case ".srec", ".s19", ".mot":
+		// Motorola S-record file. Like .hex, it includes the start address.
+		return "srec"
+	case ".dfu":
+		// DfuSe file, used by dfu-util and other tools to flash STM32 chips
+		// over USB.
+		return "dfuse"
 	default:
 		// Use the ELF format for unrecognized file formats.
 		return "elf"
 	}
 }
 
+// SigningKey returns the path to the private key used to sign firmware images
+// (for example, MCUboot images). The -signing-key flag overrides the key set in
+// the target JSON file.
+func (c *Config) SigningKey() string {
+	if c.Options.SigningKey != "" {
+		return c.Options.SigningKey
+	}
+	return c.Target.SigningKey
+}
+
+// ImageVersion returns the firmware version stored in the image header of
+// formats that support it, like MCUboot images. It is in the form
+// major.minor.revision+build.
+func (c *Config) ImageVersion() string {
+	if c.Options.ImageVersion != "" {
+		return c.Options.ImageVersion
+	}
+	return c.Target.ImageVersion
+}
+
 // Programmer returns the flash method and OpenOCD interface name given a
 // particular configuration. It may either be all configured in the target JSON
 // file or be modified using the -programmmer command-line option.
@@ -252,7 +302,7 @@ func (c *Config) Programmer() (method, openocdInterface string) {
 	case "":
 		// No configuration supplied.
 		return c.Target.FlashMethod, c.Target.OpenOCDInterface
-	case "openocd", "msd", "command":
+	case "openocd", "msd", "command", "bossa", "stk500v1":
 		// The -programmer flag only specifies the flash method.
 		return c.Options.Programmer, c.Target.OpenOCDInterface
 	default:
@@ -0,0 +1,28 @@
+package compileopts
+
+import "testing"
+
+func TestBinaryFormat(t *testing.T) {
+	for _, tc := range []struct {
+		ext          string
+		flag         string
+		targetFormat string
+		expected     string
+	}{
+		{".hex", "", "", "hex"},
+		{".srec", "", "", "srec"},
+		{".dfu", "", "", "dfuse"},
+		{".bin", "", "esp32", "esp32"},
+		{".zip", "", "", "elf"}, // .zip doesn't imply a particular format
+		{".zip", "nrf-dfu", "", "nrf-dfu"},
+		{".bin", "srec", "esp32", "srec"}, // -format overrides everything
+	} {
+		config := &Config{
+			Options: &Options{BinaryFormat: tc.flag},
+			Target:  &TargetSpec{BinaryFormat: tc.targetFormat},
+		}
+		if format := config.BinaryFormat(tc.ext); format != tc.expected {
+			t.Errorf("BinaryFormat(%#v) with -format=%#v and binary-format %#v: expected %#v, got %#v", tc.ext, tc.flag, tc.targetFormat, tc.expected, format)
+		}
+	}
+}
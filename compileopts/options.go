@@ -10,8 +10,15 @@ var (
 	validSchedulerOptions     = []string{"none", "tasks", "coroutines"}
 	validPrintSizeOptions     = []string{"none", "short", "full"}
 	validPanicStrategyOptions = []string{"print", "trap"}
+	validBinaryFormatOptions  = []string{"elf", "hex", "bin", "uf2", "srec", "dfuse", "nrf-dfu", "mcuboot", "esp32", "esp8266"}
 )
 
+// BinaryFormats returns the output formats accepted by -format. Every format in
+// this list must be handled by builder.Build.
+func BinaryFormats() []string {
+	return append([]string(nil), validBinaryFormatOptions...)
+}
+
 // Options contains extra options to give to the compiler. These options are
 // usually passed from the command line.
 type Options struct {
@@ -27,12 +34,22 @@ type Options struct {
 	Debug         bool
 	PrintSizes    string
 	PrintStacks   bool
+	PrintInterp   bool
+	PrintReach    string
+	LTO           bool
 	CFlags        []string
 	LDFlags       []string
+	GlobalValues  map[string]map[string]string // -ldflags="-X pkg.name=value"
 	Tags          string
 	WasmAbi       string
+	JSLoader      bool
 	TestConfig    TestConfig
 	Programmer    string
+	BinaryFormat  string
+	SigningKey    string
+	ImageVersion  string
+	TrimPath      bool
+	BuildID       bool
 }
 
 // Verify performs a validation on the given options, raising an error if options are not valid.
@@ -73,6 +90,15 @@ func (o *Options) Verify() error {
 		}
 	}
 
+	if o.BinaryFormat != "" {
+		valid := isInArray(validBinaryFormatOptions, o.BinaryFormat)
+		if !valid {
+			return fmt.Errorf(`invalid format option '%s': valid values are %s`,
+				o.BinaryFormat,
+				strings.Join(validBinaryFormatOptions, ", "))
+		}
+	}
+
 	return nil
 }
 
@@ -13,6 +13,7 @@ func TestVerifyOptions(t *testing.T) {
 	expectedSchedulerError := errors.New(`invalid scheduler option 'incorrect': valid values are none, tasks, coroutines`)
 	expectedPrintSizeError := errors.New(`invalid size option 'incorrect': valid values are none, short, full`)
 	expectedPanicStrategyError := errors.New(`invalid panic option 'incorrect': valid values are print, trap`)
+	expectedBinaryFormatError := errors.New(`invalid format option 'zip': valid values are elf, hex, bin, uf2, srec, dfuse, nrf-dfu, mcuboot, esp32, esp8266`)
 
 	testCases := []struct {
 		name          string
@@ -123,6 +124,25 @@ func TestVerifyOptions(t *testing.T) {
 				PanicStrategy: "trap",
 			},
 		},
+		{
+			name: "InvalidBinaryFormatOption",
+			opts: compileopts.Options{
+				BinaryFormat: "zip",
+			},
+			expectedError: expectedBinaryFormatError,
+		},
+		{
+			name: "BinaryFormatOptionNRFDFU",
+			opts: compileopts.Options{
+				BinaryFormat: "nrf-dfu",
+			},
+		},
+		{
+			name: "BinaryFormatOptionMCUboot",
+			opts: compileopts.Options{
+				BinaryFormat: "mcuboot",
+			},
+		},
 	}
 
 	for _, tc := range testCases {
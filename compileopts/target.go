@@ -45,10 +45,14 @@ type TargetSpec struct {
 	GDB              string   `json:"gdb"`
 	PortReset        string   `json:"flash-1200-bps-reset"`
 	FlashMethod      string   `json:"flash-method"`
+	FlashBaudRate    uint32   `json:"flash-baud-rate"` // baud rate for the bossa and stk500v1 flash methods
 	FlashVolume      string   `json:"msd-volume-name"`
 	FlashFilename    string   `json:"msd-firmware-name"`
 	UF2FamilyID      string   `json:"uf2-family-id"`
 	BinaryFormat     string   `json:"binary-format"`
+	SigningKey       string   `json:"signing-key"`
+	ImageVersion     string   `json:"image-version"`
+	ImageHeaderSize  uint32   `json:"image-header-size"` // MCUboot header size, 0x200 by default; must be reserved by the linker script
 	OpenOCDInterface string   `json:"openocd-interface"`
 	OpenOCDTarget    string   `json:"openocd-target"`
 	OpenOCDTransport string   `json:"openocd-transport"`
@@ -251,6 +255,8 @@ func defaultTarget(goos, goarch, triple string) (*TargetSpec, error) {
 	if goarch != "wasm" {
 		spec.ExtraFiles = append(spec.ExtraFiles, "src/runtime/gc_"+goarch+".S")
 	}
+	// Weak defaults for optional hooks of the generic machine package.
+	spec.ExtraFiles = append(spec.ExtraFiles, "targets/machine-generic.c")
 	if goarch != runtime.GOARCH {
 		// Some educated guesses as to how to invoke helper programs.
 		spec.GDB = "gdb-multiarch"
@@ -0,0 +1,579 @@
+package compiler
+
+// This file implements the canonical WebAssembly ABI (-wasm-abi=canonical).
+// With this ABI, exported functions and functions imported with
+// //go:wasm-module may have string, slice and struct parameters and may have
+// multiple results. These values are lowered to plain i32, i64, f32 and f64
+// values at the boundary, in a way that is easy to implement for the host:
+//
+//   - A string is passed as a pointer and a length.
+//   - A slice of a basic type is passed as a pointer and a length (the capacity
+//     is not passed).
+//   - A struct is passed as the lowered values of each of its fields.
+//   - Booleans and integers smaller than 32 bits are widened to i32.
+//
+// A function that returns more than one (lowered) value returns a pointer to
+// a return area in linear memory, which contains the values as a C struct. For
+// imported functions, the pointer to the return area is passed as an extra
+// last parameter instead.
+//
+// Memory that the host needs to pass to Go (for example, the bytes of a string
+// parameter) is allocated with the exported cabi_realloc function. For every
+// canonical function, a JSON description of the signature is stored in the
+// module, which can be written out with CanonicalABIDescriptor to generate
+// bindings on the host side.
+
+import (
+	"encoding/json"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+	"tinygo.org/x/go-llvm"
+)
+
+// The maximum number of lowered parameters of a canonical function. This is
+// the same limit as the limit on flat parameters in the component model.
+const maxCanonicalParams = 16
+
+// Names of the function attributes that store the signature of canonical
+// exports and imports, in JSON form.
+const (
+	canonicalExportAttr = "tinygo-canonical-export"
+	canonicalImportAttr = "tinygo-canonical-import"
+)
+
+// canonicalSignature describes an exported or imported function in the
+// interface descriptor.
+type canonicalSignature struct {
+	Module  string           `json:"module,omitempty"`
+	Name    string           `json:"name"`
+	Params  []canonicalField `json:"params"`
+	Results []canonicalField `json:"results"`
+}
+
+// canonicalField is a single parameter, result or struct field in the
+// interface descriptor.
+type canonicalField struct {
+	Name string      `json:"name,omitempty"`
+	Type interface{} `json:"type"`
+}
+
+// canonicalRecord describes a struct type in the interface descriptor.
+type canonicalRecord struct {
+	Record []canonicalField `json:"record"`
+	Go     string           `json:"go,omitempty"`
+}
+
+// needsCanonicalABI returns whether the given function signature contains
+// values that are lowered differently in the canonical ABI than in the regular
+// (C-like) ABI of exported functions.
+func needsCanonicalABI(sig *types.Signature) bool {
+	if sig.Results().Len() > 1 {
+		return true
+	}
+	for _, tuple := range []*types.Tuple{sig.Params(), sig.Results()} {
+		for i := 0; i < tuple.Len(); i++ {
+			switch typ := tuple.At(i).Type().Underlying().(type) {
+			case *types.Basic:
+				if typ.Kind() == types.String {
+					return true
+				}
+			case *types.Slice, *types.Struct:
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// canonicalFlatTypes returns the list of WebAssembly value types (i32, i64,
+// f32 or f64) that a Go value of the given type is lowered to. It returns an
+// error message if the type cannot be used in the canonical ABI.
+func (c *compilerContext) canonicalFlatTypes(typ types.Type) ([]llvm.Type, string) {
+	i32 := c.ctx.Int32Type()
+	switch typ := typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case typ.Kind() == types.String:
+			return []llvm.Type{i32, i32}, ""
+		case typ.Kind() == types.UnsafePointer:
+			return []llvm.Type{i32}, ""
+		case typ.Info()&types.IsBoolean != 0:
+			return []llvm.Type{i32}, ""
+		case typ.Info()&types.IsInteger != 0:
+			if c.targetData.TypeAllocSize(c.getLLVMType(typ)) > 4 {
+				return []llvm.Type{c.ctx.Int64Type()}, ""
+			}
+			return []llvm.Type{i32}, ""
+		case typ.Kind() == types.Float32:
+			return []llvm.Type{c.ctx.FloatType()}, ""
+		case typ.Kind() == types.Float64:
+			return []llvm.Type{c.ctx.DoubleType()}, ""
+		}
+	case *types.Pointer:
+		return []llvm.Type{i32}, ""
+	case *types.Slice:
+		if elem, ok := typ.Elem().Underlying().(*types.Basic); ok && elem.Info()&(types.IsBoolean|types.IsInteger|types.IsFloat) != 0 {
+			return []llvm.Type{i32, i32}, ""
+		}
+		return nil, "only slices of booleans and numbers are supported in the canonical ABI, not " + typ.String()
+	case *types.Struct:
+		var flat []llvm.Type
+		for i := 0; i < typ.NumFields(); i++ {
+			fieldTypes, msg := c.canonicalFlatTypes(typ.Field(i).Type())
+			if msg != "" {
+				return nil, msg
+			}
+			flat = append(flat, fieldTypes...)
+		}
+		return flat, ""
+	}
+	return nil, "type is not supported in the canonical ABI: " + typ.String()
+}
+
+// canonicalTypeDescription returns the description of the given type in the
+// interface descriptor: a string for basic types and lists, and a
+// canonicalRecord for structs.
+func canonicalTypeDescription(typ types.Type) interface{} {
+	switch underlying := typ.Underlying().(type) {
+	case *types.Basic:
+		switch underlying.Kind() {
+		case types.Bool:
+			return "bool"
+		case types.Int8:
+			return "s8"
+		case types.Int16:
+			return "s16"
+		case types.Int32:
+			return "s32"
+		case types.Int64:
+			return "s64"
+		case types.Uint8:
+			return "u8"
+		case types.Uint16:
+			return "u16"
+		case types.Uint32:
+			return "u32"
+		case types.Uint64:
+			return "u64"
+		case types.Int:
+			// The canonical ABI is only used on wasm32.
+			return "s32"
+		case types.Uint, types.Uintptr:
+			return "u32"
+		case types.Float32:
+			return "f32"
+		case types.Float64:
+			return "f64"
+		case types.String:
+			return "string"
+		case types.UnsafePointer:
+			return "pointer"
+		}
+	case *types.Pointer:
+		return "pointer"
+	case *types.Slice:
+		return "list<" + canonicalTypeDescription(underlying.Elem()).(string) + ">"
+	case *types.Struct:
+		record := canonicalRecord{}
+		if named, ok := typ.(*types.Named); ok {
+			record.Go = named.String()
+		}
+		for i := 0; i < underlying.NumFields(); i++ {
+			field := underlying.Field(i)
+			record.Record = append(record.Record, canonicalField{
+				Name: field.Name(),
+				Type: canonicalTypeDescription(field.Type()),
+			})
+		}
+		return record
+	}
+	panic("unknown canonical type: " + typ.String())
+}
+
+// canonicalFunctionType returns the LLVM function type of a canonical export
+// or import with the given signature, and the LLVM type of the return area (or
+// the zero llvm.Type if the results are returned directly). It returns a
+// non-empty error message if the signature is not supported.
+func (c *compilerContext) canonicalFunctionType(sig *types.Signature, isImport bool) (llvm.Type, llvm.Type, string) {
+	var paramTypes []llvm.Type
+	for i := 0; i < sig.Params().Len(); i++ {
+		flat, msg := c.canonicalFlatTypes(sig.Params().At(i).Type())
+		if msg != "" {
+			return llvm.Type{}, llvm.Type{}, msg
+		}
+		paramTypes = append(paramTypes, flat...)
+	}
+	var resultTypes []llvm.Type
+	for i := 0; i < sig.Results().Len(); i++ {
+		flat, msg := c.canonicalFlatTypes(sig.Results().At(i).Type())
+		if msg != "" {
+			return llvm.Type{}, llvm.Type{}, msg
+		}
+		resultTypes = append(resultTypes, flat...)
+	}
+	if len(paramTypes) > maxCanonicalParams {
+		return llvm.Type{}, llvm.Type{}, "too many parameters for the canonical ABI"
+	}
+
+	var retType, retAreaType llvm.Type
+	switch len(resultTypes) {
+	case 0:
+		retType = c.ctx.VoidType()
+	case 1:
+		retType = resultTypes[0]
+	default:
+		retAreaType = c.ctx.StructType(resultTypes, false)
+		if isImport {
+			// The caller allocates the return area.
+			retType = c.ctx.VoidType()
+			paramTypes = append(paramTypes, c.ctx.Int32Type())
+		} else {
+			// The callee returns a pointer to the return area.
+			retType = c.ctx.Int32Type()
+		}
+	}
+	return llvm.FunctionType(retType, paramTypes, false), retAreaType, ""
+}
+
+// addSignatureAttr stores the description of the signature in the given
+// exported or imported function, for use in CanonicalABIDescriptor and
+// WasmExports.
+func (c *compilerContext) addSignatureAttr(llvmFn llvm.Value, attrName, module, name string, sig *types.Signature) {
+	desc := canonicalSignature{
+		Module:  module,
+		Name:    name,
+		Params:  []canonicalField{},
+		Results: []canonicalField{},
+	}
+	for i := 0; i < sig.Params().Len(); i++ {
+		param := sig.Params().At(i)
+		desc.Params = append(desc.Params, canonicalField{
+			Name: param.Name(),
+			Type: canonicalTypeDescription(param.Type()),
+		})
+	}
+	for i := 0; i < sig.Results().Len(); i++ {
+		result := sig.Results().At(i)
+		desc.Results = append(desc.Results, canonicalField{
+			Name: result.Name(),
+			Type: canonicalTypeDescription(result.Type()),
+		})
+	}
+	data, err := json.Marshal(desc)
+	if err != nil {
+		panic("could not encode canonical signature: " + err.Error())
+	}
+	llvmFn.AddFunctionAttr(c.ctx.CreateStringAttribute(attrName, string(data)))
+}
+
+// createCanonicalExport creates the function that is exported to the host for
+// a canonical export. It converts the lowered parameters to Go values, calls
+// the Go function and lowers the results.
+func (c *compilerContext) createCanonicalExport(fn *ssa.Function, info functionInfo, goFn llvm.Value) {
+	fnType, retAreaType, msg := c.canonicalFunctionType(fn.Signature, false)
+	if msg != "" {
+		c.addError(fn.Pos(), msg)
+		return
+	}
+	wrapper := llvm.AddFunction(c.mod, info.canonicalName, fnType)
+	c.addSignatureAttr(wrapper, canonicalExportAttr, info.module, info.canonicalName, fn.Signature)
+
+	// Create a new builder just to create this wrapper.
+	b := builder{
+		compilerContext: c,
+		Builder:         c.ctx.NewBuilder(),
+	}
+	defer b.Builder.Dispose()
+	if c.Debug {
+		pos := c.program.Fset.Position(fn.Pos())
+		difunc := c.attachDebugInfoRaw(fn, wrapper, "$canonical", pos.Filename, pos.Line)
+		b.SetCurrentDebugLocation(uint(pos.Line), uint(pos.Column), difunc, llvm.Metadata{})
+	}
+	block := c.ctx.AddBasicBlock(wrapper, "entry")
+	b.SetInsertPointAtEnd(block)
+
+	// Convert the parameters to Go values and call the Go function.
+	b.createRuntimeCall("cabiEnter", nil, "")
+	flat := wrapper.Params()
+	var args []llvm.Value
+	for _, param := range fn.Params {
+		var arg llvm.Value
+		arg, flat = b.canonicalLift(flat, param.Type())
+		args = append(args, arg)
+	}
+	result := b.createCall(goFn, args, "")
+	b.createRuntimeCall("cabiExit", nil, "")
+
+	// Lower the results.
+	var results []llvm.Value
+	resultTuple := fn.Signature.Results()
+	for i := 0; i < resultTuple.Len(); i++ {
+		value := result
+		if resultTuple.Len() > 1 {
+			value = b.CreateExtractValue(result, i, "")
+		}
+		results = append(results, b.canonicalLower(value, resultTuple.At(i).Type())...)
+	}
+	switch {
+	case len(results) == 0:
+		b.CreateRetVoid()
+	case retAreaType.IsNil():
+		b.CreateRet(results[0])
+	default:
+		// Store the results in a global, so that the host can read them
+		// after the call returns.
+		retArea := llvm.AddGlobal(c.mod, retAreaType, info.canonicalName+"$retarea")
+		retArea.SetInitializer(llvm.ConstNull(retAreaType))
+		c.setLocalLinkage(retArea, llvm.InternalLinkage)
+		for i, value := range results {
+			b.CreateStore(value, b.CreateStructGEP(retArea, i, ""))
+		}
+		b.CreateRet(b.CreatePtrToInt(retArea, c.ctx.Int32Type(), ""))
+	}
+}
+
+// createCanonicalImport implements a function imported with //go:wasm-module
+// using the canonical ABI. It is a regular Go function that lowers the
+// parameters, calls the imported function and converts the results back to Go
+// values.
+func (c *compilerContext) createCanonicalImport(fn *ssa.Function, info functionInfo, goFn llvm.Value) {
+	fnType, retAreaType, msg := c.canonicalFunctionType(fn.Signature, true)
+	if msg != "" {
+		c.addError(fn.Pos(), msg)
+		return
+	}
+	importFn := c.mod.NamedFunction(info.linkName + "$import")
+	if importFn.IsNil() {
+		importFn = llvm.AddFunction(c.mod, info.linkName+"$import", fnType)
+		importFn.AddFunctionAttr(c.ctx.CreateStringAttribute("wasm-import-module", info.module))
+		importFn.AddFunctionAttr(c.ctx.CreateStringAttribute("wasm-import-name", info.canonicalName))
+		c.addSignatureAttr(importFn, canonicalImportAttr, info.module, info.canonicalName, fn.Signature)
+	}
+	c.setLocalLinkage(goFn, llvm.InternalLinkage)
+	goFn.SetUnnamedAddr(true)
+
+	// Create a new builder just to create this function.
+	b := builder{
+		compilerContext: c,
+		Builder:         c.ctx.NewBuilder(),
+	}
+	defer b.Builder.Dispose()
+	if c.Debug {
+		pos := c.program.Fset.Position(fn.Pos())
+		difunc := c.attachDebugInfoRaw(fn, goFn, "", pos.Filename, pos.Line)
+		b.SetCurrentDebugLocation(uint(pos.Line), uint(pos.Column), difunc, llvm.Metadata{})
+	}
+	block := c.ctx.AddBasicBlock(goFn, "entry")
+	b.SetInsertPointAtEnd(block)
+
+	// Lower the parameters, which have been expanded in the Go calling
+	// convention.
+	var args []llvm.Value
+	goParams := goFn.Params()
+	for _, param := range fn.Params {
+		llvmType := c.getLLVMType(param.Type())
+		numFields := len(expandFormalParamType(llvmType, "", nil))
+		value := b.collapseFormalParam(llvmType, goParams[:numFields])
+		goParams = goParams[numFields:]
+		args = append(args, b.canonicalLower(value, param.Type())...)
+	}
+	var retArea llvm.Value
+	if !retAreaType.IsNil() {
+		retArea = b.CreateAlloca(retAreaType, "retarea")
+		args = append(args, b.CreatePtrToInt(retArea, c.ctx.Int32Type(), ""))
+	}
+
+	// Call the imported function. Any memory that the host allocates for the
+	// results is released once the results have been converted to Go values.
+	pinCount := b.createRuntimeCall("cabiPinCount", nil, "")
+	result := b.CreateCall(importFn, args, "")
+	var flat []llvm.Value
+	if !retAreaType.IsNil() {
+		for i := range retAreaType.StructElementTypes() {
+			flat = append(flat, b.CreateLoad(b.CreateStructGEP(retArea, i, ""), ""))
+		}
+	} else if fnType.ReturnType().TypeKind() != llvm.VoidTypeKind {
+		flat = []llvm.Value{result}
+	}
+	var results []llvm.Value
+	for i := 0; i < fn.Signature.Results().Len(); i++ {
+		var value llvm.Value
+		value, flat = b.canonicalLift(flat, fn.Signature.Results().At(i).Type())
+		results = append(results, value)
+	}
+	b.createRuntimeCall("cabiUnpin", []llvm.Value{pinCount}, "")
+
+	switch len(results) {
+	case 0:
+		b.CreateRetVoid()
+	case 1:
+		b.CreateRet(results[0])
+	default:
+		retval := llvm.Undef(goFn.Type().ElementType().ReturnType())
+		for i, value := range results {
+			retval = b.CreateInsertValue(retval, value, i, "")
+		}
+		b.CreateRet(retval)
+	}
+}
+
+// createCanonicalRealloc creates the cabi_realloc function, which the host
+// uses to allocate memory for values it passes to Go.
+func (c *compilerContext) createCanonicalRealloc() {
+	i32 := c.ctx.Int32Type()
+	fnType := llvm.FunctionType(i32, []llvm.Type{i32, i32, i32, i32}, false)
+	fn := llvm.AddFunction(c.mod, "cabi_realloc", fnType)
+	b := builder{
+		compilerContext: c,
+		Builder:         c.ctx.NewBuilder(),
+	}
+	defer b.Builder.Dispose()
+	block := c.ctx.AddBasicBlock(fn, "entry")
+	b.SetInsertPointAtEnd(block)
+	oldPtr := b.CreateIntToPtr(fn.Param(0), c.i8ptrType, "oldPtr")
+	var args = []llvm.Value{oldPtr}
+	for _, param := range fn.Params()[1:] {
+		args = append(args, b.CreateZExtOrBitCast(param, c.uintptrType, ""))
+	}
+	ptr := b.createRuntimeCall("cabiRealloc", args, "")
+	b.CreateRet(b.CreatePtrToInt(ptr, i32, ""))
+}
+
+// canonicalLower converts a Go value to the flat list of values it is lowered
+// to in the canonical ABI.
+func (b *builder) canonicalLower(value llvm.Value, typ types.Type) []llvm.Value {
+	i32 := b.ctx.Int32Type()
+	switch typ := typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case typ.Kind() == types.String:
+			ptr := b.CreateExtractValue(value, 0, "")
+			length := b.CreateExtractValue(value, 1, "")
+			return []llvm.Value{b.CreatePtrToInt(ptr, i32, ""), b.CreateZExtOrBitCast(length, i32, "")}
+		case typ.Kind() == types.UnsafePointer:
+			return []llvm.Value{b.CreatePtrToInt(value, i32, "")}
+		case typ.Info()&types.IsBoolean != 0:
+			return []llvm.Value{b.CreateZExt(value, i32, "")}
+		case typ.Info()&types.IsInteger != 0 && value.Type().IntTypeWidth() < 32:
+			if typ.Info()&types.IsUnsigned != 0 {
+				return []llvm.Value{b.CreateZExt(value, i32, "")}
+			}
+			return []llvm.Value{b.CreateSExt(value, i32, "")}
+		default:
+			return []llvm.Value{value}
+		}
+	case *types.Pointer:
+		return []llvm.Value{b.CreatePtrToInt(value, i32, "")}
+	case *types.Slice:
+		ptr := b.CreateExtractValue(value, 0, "")
+		length := b.CreateExtractValue(value, 1, "")
+		return []llvm.Value{b.CreatePtrToInt(ptr, i32, ""), b.CreateZExtOrBitCast(length, i32, "")}
+	case *types.Struct:
+		var flat []llvm.Value
+		for i := 0; i < typ.NumFields(); i++ {
+			field := b.CreateExtractValue(value, i, "")
+			flat = append(flat, b.canonicalLower(field, typ.Field(i).Type())...)
+		}
+		return flat
+	default:
+		panic("unknown canonical type: " + typ.String())
+	}
+}
+
+// canonicalLift converts the first values of the flat list of values back to a
+// Go value of the given type. It returns the converted value and the remaining
+// flat values.
+func (b *builder) canonicalLift(flat []llvm.Value, typ types.Type) (llvm.Value, []llvm.Value) {
+	llvmType := b.getLLVMType(typ)
+	switch typ := typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case typ.Kind() == types.String:
+			ptr := b.CreateIntToPtr(flat[0], b.i8ptrType, "")
+			length := b.CreateZExtOrBitCast(flat[1], b.uintptrType, "")
+			value := llvm.Undef(llvmType)
+			value = b.CreateInsertValue(value, ptr, 0, "")
+			value = b.CreateInsertValue(value, length, 1, "")
+			return value, flat[2:]
+		case typ.Kind() == types.UnsafePointer:
+			return b.CreateIntToPtr(flat[0], llvmType, ""), flat[1:]
+		case typ.Info()&types.IsBoolean != 0:
+			zero := llvm.ConstInt(flat[0].Type(), 0, false)
+			return b.CreateICmp(llvm.IntNE, flat[0], zero, ""), flat[1:]
+		case typ.Info()&types.IsInteger != 0 && llvmType.IntTypeWidth() < 32:
+			return b.CreateTrunc(flat[0], llvmType, ""), flat[1:]
+		default:
+			return flat[0], flat[1:]
+		}
+	case *types.Pointer:
+		return b.CreateIntToPtr(flat[0], llvmType, ""), flat[1:]
+	case *types.Slice:
+		ptr := b.CreateIntToPtr(flat[0], llvmType.StructElementTypes()[0], "")
+		length := b.CreateZExtOrBitCast(flat[1], b.uintptrType, "")
+		value := llvm.Undef(llvmType)
+		value = b.CreateInsertValue(value, ptr, 0, "")
+		value = b.CreateInsertValue(value, length, 1, "")
+		value = b.CreateInsertValue(value, length, 2, "") // cap == len
+		return value, flat[2:]
+	case *types.Struct:
+		value := llvm.Undef(llvmType)
+		for i := 0; i < typ.NumFields(); i++ {
+			var field llvm.Value
+			field, flat = b.canonicalLift(flat, typ.Field(i).Type())
+			value = b.CreateInsertValue(value, field, i, "")
+		}
+		return value, flat
+	default:
+		panic("unknown canonical type: " + typ.String())
+	}
+}
+
+// CanonicalABIDescriptor returns a JSON description of all functions that are
+// exported or imported using the canonical ABI in the given module. It can be
+// used to generate bindings for the host, and is written next to the output
+// file when building with -wasm-abi=canonical.
+func CanonicalABIDescriptor(mod llvm.Module) []byte {
+	descriptor := struct {
+		ABI       string            `json:"abi"`
+		Allocator string            `json:"allocator"`
+		Exports   []json.RawMessage `json:"exports"`
+		Imports   []json.RawMessage `json:"imports"`
+	}{
+		ABI:       "canonical",
+		Allocator: "cabi_realloc",
+		Exports:   []json.RawMessage{},
+		Imports:   []json.RawMessage{},
+	}
+	for fn := mod.FirstFunction(); !fn.IsNil(); fn = llvm.NextFunction(fn) {
+		if attr := fn.GetStringAttributeAtIndex(-1, canonicalExportAttr); !attr.IsNil() {
+			descriptor.Exports = append(descriptor.Exports, json.RawMessage(attr.GetStringValue()))
+		}
+		if attr := fn.GetStringAttributeAtIndex(-1, canonicalImportAttr); !attr.IsNil() {
+			descriptor.Imports = append(descriptor.Imports, json.RawMessage(attr.GetStringValue()))
+		}
+	}
+	data, err := json.MarshalIndent(descriptor, "", "\t")
+	if err != nil {
+		panic("could not encode canonical ABI descriptor: " + err.Error())
+	}
+	return append(data, '\n')
+}
+
+// isCanonicalFunction returns whether the given function must be exported or
+// imported using the canonical ABI. The function info must have been parsed
+// from the pragmas already.
+func (c *compilerContext) isCanonicalFunction(f *ssa.Function, info functionInfo) bool {
+	if c.WasmABI != "canonical" || !strings.HasPrefix(c.Triple, "wasm") {
+		return false
+	}
+	if !info.exported || strings.HasPrefix(f.Name(), "C.") {
+		return false
+	}
+	if f.Blocks == nil && info.module == "" {
+		// Function implemented in C, for example in wasi-libc.
+		return false
+	}
+	return needsCanonicalABI(f.Signature)
+}
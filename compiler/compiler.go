@@ -53,7 +53,13 @@ type Config struct {
 	AutomaticStackSize bool
 	DefaultStackSize   uint64
 	NeedsStackObjects  bool
-	Debug              bool // Whether to emit debug information in the LLVM module.
+	Debug              bool   // Whether to emit debug information in the LLVM module.
+	WasmABI            string // js, generic or canonical (WebAssembly only)
+	TrimPath           bool   // Remove machine-specific paths from debug information.
+
+	// Values of string globals, as set with -ldflags="-X pkg.name=value". The
+	// map is indexed by package path and then by global name.
+	GlobalValues map[string]map[string]string
 }
 
 // compilerContext contains function-independent data that should still be
@@ -67,6 +73,7 @@ type compilerContext struct {
 	dibuilder        *llvm.DIBuilder
 	cu               llvm.Metadata
 	difiles          map[string]llvm.Metadata
+	trimPath         func(string) string
 	ditypes          map[types.Type]llvm.Metadata
 	machine          llvm.TargetMachine
 	targetData       llvm.TargetData
@@ -78,6 +85,7 @@ type compilerContext struct {
 	diagnostics      []error
 	astComments      map[string]*ast.CommentGroup
 	runtimePkg       *types.Package
+	localSymbols     map[string]llvm.Linkage // only set when compiling a part of the program
 }
 
 // newCompilerContext returns a new compiler context ready for use, most
@@ -250,6 +258,9 @@ func CompileProgram(lprogram *loader.Program, machine llvm.TargetMachine, config
 	c.program = lprogram.LoadSSA()
 	c.program.Build()
 	c.runtimePkg = c.program.ImportedPackage("runtime").Pkg
+	if c.TrimPath {
+		c.trimPath = lprogram.TrimPath
+	}
 
 	// Run a simple dead code elimination pass.
 	functions, err := c.simpleDCE(lprogram)
@@ -257,42 +268,35 @@ func CompileProgram(lprogram *loader.Program, machine llvm.TargetMachine, config
 		return llvm.Module{}, []error{err}
 	}
 
+	// Check that globals set with -X are of type string.
+	if errs := c.checkGlobalValues(); errs != nil {
+		return llvm.Module{}, errs
+	}
+
 	// Initialize debug information.
 	if c.Debug {
-		c.cu = c.dibuilder.CreateCompileUnit(llvm.DICompileUnit{
-			Language:  0xb, // DW_LANG_C99 (0xc, off-by-one?)
-			File:      "<unknown>",
-			Dir:       "",
-			Producer:  "TinyGo",
-			Optimized: true,
-		})
+		c.initDebugInfo()
 	}
 
 	c.loadASTComments(lprogram)
 
-	// Predeclare the runtime.alloc function, which is used by the wordpack
-	// functionality.
-	c.getFunction(c.program.ImportedPackage("runtime").Members["alloc"].(*ssa.Function))
+	// Add definitions to declarations. Every package is compiled separately
+	// and in parallel, after which the resulting modules are linked into
+	// c.mod.
+	if errs := c.compileParts(functions); errs != nil {
+		return llvm.Module{}, errs
+	}
 
-	// Add definitions to declarations.
+	// After all packages are imported, add a synthetic initializer function
+	// that calls the initializer of each package.
 	var initFuncs []llvm.Value
-	irbuilder := c.ctx.NewBuilder()
-	defer irbuilder.Dispose()
 	for _, f := range functions {
 		if f.Synthetic == "package initializer" {
 			initFuncs = append(initFuncs, c.getFunction(f))
 		}
-		if f.Blocks == nil {
-			continue // external function
-		}
-
-		// Create the function definition.
-		b := newBuilder(c, irbuilder, f)
-		b.createFunction()
 	}
-
-	// After all packages are imported, add a synthetic initializer function
-	// that calls the initializer of each package.
+	irbuilder := c.ctx.NewBuilder()
+	defer irbuilder.Dispose()
 	initFn := c.program.ImportedPackage("runtime").Members["initAll"].(*ssa.Function)
 	llvmInitFn := c.getFunction(initFn)
 	llvmInitFn.SetLinkage(llvm.InternalLinkage)
@@ -311,28 +315,50 @@ func CompileProgram(lprogram *loader.Program, machine llvm.TargetMachine, config
 	}
 	irbuilder.CreateRetVoid()
 
-	// see: https://reviews.llvm.org/D18355
+	if c.WasmABI == "canonical" && strings.HasPrefix(c.Triple, "wasm") {
+		// Allocator used by the host to pass strings, slices etc. to Go.
+		c.createCanonicalRealloc()
+	}
+
 	if c.Debug {
-		c.mod.AddNamedMetadataOperand("llvm.module.flags",
-			c.ctx.MDNode([]llvm.Metadata{
-				llvm.ConstInt(c.ctx.Int32Type(), 1, false).ConstantAsMetadata(), // Error on mismatch
-				llvm.GlobalContext().MDString("Debug Info Version"),
-				llvm.ConstInt(c.ctx.Int32Type(), 3, false).ConstantAsMetadata(), // DWARF version
-			}),
-		)
-		c.mod.AddNamedMetadataOperand("llvm.module.flags",
-			c.ctx.MDNode([]llvm.Metadata{
-				llvm.ConstInt(c.ctx.Int32Type(), 1, false).ConstantAsMetadata(),
-				llvm.GlobalContext().MDString("Dwarf Version"),
-				llvm.ConstInt(c.ctx.Int32Type(), 4, false).ConstantAsMetadata(),
-			}),
-		)
 		c.dibuilder.Finalize()
 	}
 
 	return c.mod, c.diagnostics
 }
 
+// initDebugInfo creates the debug information compile unit of the module and
+// adds the module flags that are needed for debug information. The module flags
+// are also needed to load the module from bitcode without losing the debug
+// information.
+func (c *compilerContext) initDebugInfo() {
+	c.cu = c.dibuilder.CreateCompileUnit(llvm.DICompileUnit{
+		Language:  0xb, // DW_LANG_C99 (0xc, off-by-one?)
+		File:      "<unknown>",
+		Dir:       "",
+		Producer:  "TinyGo",
+		Optimized: true,
+	})
+
+	// see: https://reviews.llvm.org/D18355
+	// These flags use the same merge behavior as Clang, so that C code compiled
+	// to bitcode can be linked in with -lto.
+	c.mod.AddNamedMetadataOperand("llvm.module.flags",
+		c.ctx.MDNode([]llvm.Metadata{
+			llvm.ConstInt(c.ctx.Int32Type(), 2, false).ConstantAsMetadata(), // Warning on mismatch
+			c.ctx.MDString("Debug Info Version"),
+			llvm.ConstInt(c.ctx.Int32Type(), 3, false).ConstantAsMetadata(), // DWARF version
+		}),
+	)
+	c.mod.AddNamedMetadataOperand("llvm.module.flags",
+		c.ctx.MDNode([]llvm.Metadata{
+			llvm.ConstInt(c.ctx.Int32Type(), 7, false).ConstantAsMetadata(), // Max
+			c.ctx.MDString("Dwarf Version"),
+			llvm.ConstInt(c.ctx.Int32Type(), 4, false).ConstantAsMetadata(),
+		}),
+	)
+}
+
 // CompilePackage compiles a single package to a LLVM module.
 func CompilePackage(moduleName string, pkg *loader.Package, machine llvm.TargetMachine, config *Config, dumpSSA bool) (llvm.Module, []error) {
 	c := newCompilerContext(moduleName, machine, config, dumpSSA)
@@ -747,10 +773,15 @@ func (c *compilerContext) attachDebugInfoRaw(f *ssa.Function, llvmFn llvm.Value,
 
 // getDIFile returns a DIFile metadata node for the given filename. It tries to
 // use one that was already created, otherwise it falls back to creating a new
-// one.
+// one. With -trimpath, the filename is stripped of machine-specific prefixes
+// first so that the debug information is the same on every machine.
 func (c *compilerContext) getDIFile(filename string) llvm.Metadata {
 	if _, ok := c.difiles[filename]; !ok {
-		dir, file := filepath.Split(filename)
+		path := filename
+		if c.trimPath != nil {
+			path = c.trimPath(filename)
+		}
+		dir, file := filepath.Split(path)
 		if dir != "" {
 			dir = dir[:len(dir)-1]
 		}
@@ -776,8 +807,16 @@ func (b *builder) createFunction() {
 		return
 	}
 	if !b.info.exported {
-		b.llvmFn.SetLinkage(llvm.InternalLinkage)
+		b.setDefinitionLinkage(b.llvmFn, llvm.InternalLinkage)
+		b.llvmFn.SetUnnamedAddr(true)
+	} else if b.info.canonical {
+		// Only the wrapper using the canonical ABI is exported.
+		b.setDefinitionLinkage(b.llvmFn, llvm.InternalLinkage)
 		b.llvmFn.SetUnnamedAddr(true)
+		b.createCanonicalExport(b.fn, b.info, b.llvmFn)
+	} else {
+		// Record the signature for the JavaScript loader.
+		b.addWasmExportSignature(b.fn, b.info, b.llvmFn)
 	}
 
 	// Some functions have a pragma controlling the inlining level.
@@ -1064,6 +1103,13 @@ func (b *builder) createInstruction(instr ssa.Instruction) {
 	case *ssa.Send:
 		b.createChanSend(instr)
 	case *ssa.Store:
+		if global, ok := instr.Addr.(*ssa.Global); ok && b.fn.Synthetic == "package initializer" {
+			if _, ok := b.getGlobalValue(global); ok {
+				// The initial value was overridden using -X, so the
+				// initializer in the source code must be ignored.
+				return
+			}
+		}
 		llvmAddr := b.getValue(instr.Addr)
 		llvmVal := b.getValue(instr.Val)
 		b.createNilCheck(instr.Addr, llvmAddr, "store")
@@ -2242,18 +2288,7 @@ func (b *builder) createConst(prefix string, expr *ssa.Const) llvm.Value {
 			}
 			return llvm.ConstInt(llvmType, n, false)
 		} else if typ.Info()&types.IsString != 0 {
-			str := constant.StringVal(expr.Value)
-			strLen := llvm.ConstInt(b.uintptrType, uint64(len(str)), false)
-			objname := prefix + "$string"
-			global := llvm.AddGlobal(b.mod, llvm.ArrayType(b.ctx.Int8Type(), len(str)), objname)
-			global.SetInitializer(b.ctx.ConstString(str, false))
-			global.SetLinkage(llvm.InternalLinkage)
-			global.SetGlobalConstant(true)
-			global.SetUnnamedAddr(true)
-			zero := llvm.ConstInt(b.ctx.Int32Type(), 0, false)
-			strPtr := b.CreateInBoundsGEP(global, []llvm.Value{zero, zero}, "")
-			strObj := llvm.ConstNamedStruct(b.getLLVMRuntimeType("_string"), []llvm.Value{strPtr, strLen})
-			return strObj
+			return b.createStringConst(prefix, constant.StringVal(expr.Value))
 		} else if typ.Kind() == types.UnsafePointer {
 			if !expr.IsNil() {
 				value, _ := constant.Uint64Val(constant.ToInt(expr.Value))
@@ -1,22 +1,19 @@
-package compiler
+package compiler_test
 
 import (
-	"flag"
 	"go/types"
 	"io/ioutil"
-	"regexp"
-	"strconv"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/tinygo-org/tinygo/compileopts"
+	"github.com/tinygo-org/tinygo/compiler"
+	"github.com/tinygo-org/tinygo/compiler/irtest"
 	"github.com/tinygo-org/tinygo/loader"
 	"tinygo.org/x/go-llvm"
 )
 
-// Pass -update to go test to update the output of the test files.
-var flagUpdate = flag.Bool("update", false, "update tests based on test output")
-
 // Basic tests for the compiler. Build some Go files and compare the output with
 // the expected LLVM IR for regression testing.
 func TestCompiler(t *testing.T) {
@@ -28,7 +25,7 @@ func TestCompiler(t *testing.T) {
 		Options: &compileopts.Options{},
 		Target:  target,
 	}
-	compilerConfig := &Config{
+	compilerConfig := &compiler.Config{
 		Triple:             config.Triple(),
 		GOOS:               config.GOOS(),
 		GOARCH:             config.GOARCH(),
@@ -38,7 +35,7 @@ func TestCompiler(t *testing.T) {
 		FuncImplementation: config.FuncImplementation(),
 		AutomaticStackSize: config.AutomaticStackSize(),
 	}
-	machine, err := NewTargetMachine(compilerConfig)
+	machine, err := compiler.NewTargetMachine(compilerConfig)
 	if err != nil {
 		t.Fatal("failed to create target machine:", err)
 	}
@@ -54,7 +51,7 @@ func TestCompiler(t *testing.T) {
 		t.Run(testCase, func(t *testing.T) {
 			// Load entire program AST into memory.
 			lprogram, err := loader.Load(config, []string{"./testdata/" + testCase}, config.ClangHeaders, types.Config{
-				Sizes: Sizes(machine),
+				Sizes: compiler.Sizes(machine),
 			})
 			if err != nil {
 				t.Fatal("failed to create target machine:", err)
@@ -66,7 +63,7 @@ func TestCompiler(t *testing.T) {
 
 			// Compile AST to IR.
 			pkg := lprogram.MainPkg()
-			mod, errs := CompilePackage(testCase, pkg, machine, compilerConfig, false)
+			mod, errs := compiler.CompilePackage(testCase, pkg, machine, compilerConfig, false)
 			if errs != nil {
 				for _, err := range errs {
 					t.Log("error:", err)
@@ -85,88 +82,84 @@ func TestCompiler(t *testing.T) {
 			funcPasses.FinalizeFunc()
 
 			outfile := "./testdata/" + testCase[:len(testCase)-3] + ".ll"
-
-			// Update test if needed. Do not check the result.
-			if *flagUpdate {
-				err := ioutil.WriteFile(outfile, []byte(mod.String()), 0666)
-				if err != nil {
-					t.Error("failed to write updated output file:", err)
-				}
-				return
-			}
-
-			expected, err := ioutil.ReadFile(outfile)
-			if err != nil {
-				t.Fatal("failed to read golden file:", err)
-			}
-
-			if !fuzzyEqualIR(mod.String(), string(expected)) {
-				t.Errorf("output does not match expected output:\n%s", mod.String())
-			}
+			irtest.CheckGolden(t, outfile, mod.String())
 		})
 	}
 }
 
-var alignRegexp = regexp.MustCompile(", align [0-9]+$")
-
-// fuzzyEqualIR returns true if the two LLVM IR strings passed in are roughly
-// equal. That means, only relevant lines are compared (excluding comments
-// etc.).
-func fuzzyEqualIR(s1, s2 string) bool {
-	lines1 := filterIrrelevantIRLines(strings.Split(s1, "\n"))
-	lines2 := filterIrrelevantIRLines(strings.Split(s2, "\n"))
-	if len(lines1) != len(lines2) {
-		return false
+// Compile whole programs for all targets in irtest.Targets and compare the IR
+// of the main package with the expected IR. To add a test, add a Go file to
+// testdata/program and run the test with -update to create the golden files.
+func TestCompileProgram(t *testing.T) {
+	paths, err := filepath.Glob("testdata/program/*.go")
+	if err != nil {
+		t.Fatal(err)
 	}
-	for i, line1 := range lines1 {
-		line2 := lines2[i]
-		match1 := alignRegexp.MatchString(line1)
-		match2 := alignRegexp.MatchString(line2)
-		if match1 != match2 {
-			// Only one of the lines has the align keyword. Remove it.
-			// This is a change to make the test work in both LLVM 10 and LLVM
-			// 11 (LLVM 11 appears to automatically add alignment everywhere).
-			line1 = alignRegexp.ReplaceAllString(line1, "")
-			line2 = alignRegexp.ReplaceAllString(line2, "")
-		}
-		if line1 != line2 {
-			return false
-		}
+	if len(paths) == 0 {
+		t.Fatal("no test programs found in testdata/program")
+	}
+	for _, path := range paths {
+		path := path
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			irtest.RunProgram(t, strings.TrimSuffix(path, ".go"))
+		})
 	}
-
-	return true
 }
 
-// filterIrrelevantIRLines removes lines from the input slice of strings that
-// are not relevant in comparing IR. For example, empty lines and comments are
-// stripped out.
-func filterIrrelevantIRLines(lines []string) []string {
-	var out []string
-	llvmVersion, err := strconv.Atoi(strings.Split(llvm.Version, ".")[0])
-	if err != nil {
-		// Note: this should never happen and if it does, it will always happen
-		// for a particular build because llvm.Version is a constant.
-		panic(err)
-	}
-	for _, line := range lines {
-		line = strings.Split(line, ";")[0]    // strip out comments/info
-		line = strings.TrimRight(line, "\r ") // drop '\r' on Windows and remove trailing spaces from comments
-		if line == "" {
-			continue
+// Compile a program in parts (in parallel) and check that the result is the
+// same every time, and that all symbols that are local to the program got
+// their original linkage back after linking the parts.
+func TestCompileParts(t *testing.T) {
+	var results []string
+	for i := 0; i < 3; i++ {
+		mod := irtest.CompileProgram(t, "cortex-m-qemu", "./testdata/parts.go")
+		for global := mod.FirstGlobal(); !global.IsNil(); global = llvm.NextGlobal(global) {
+			if global.Linkage() == llvm.LinkOnceODRLinkage {
+				t.Errorf("global %s still has the linkage used while linking parts", global.Name())
+			}
 		}
-		if strings.HasPrefix(line, "source_filename = ") {
-			continue
+		for fn := mod.FirstFunction(); !fn.IsNil(); fn = llvm.NextFunction(fn) {
+			if fn.Linkage() == llvm.LinkOnceODRLinkage {
+				t.Errorf("function %s still has the linkage used while linking parts", fn.Name())
+			}
+			if strings.HasSuffix(fn.Name(), "gowrapper") && fn.Linkage() != llvm.InternalLinkage {
+				t.Errorf("goroutine wrapper %s is not internal", fn.Name())
+			}
 		}
-		if llvmVersion < 10 && strings.HasPrefix(line, "attributes ") {
-			// Ignore attribute groups. These may change between LLVM versions.
-			// Right now test outputs are for LLVM 10.
-			continue
+		results = append(results, mod.String())
+	}
+	for _, result := range results[1:] {
+		if result != results[0] {
+			t.Fatal("compiling in parts is not deterministic")
 		}
-		if llvmVersion < 10 && strings.HasPrefix(line, "target datalayout ") {
-			// Ignore the target layout. This may change between LLVM versions.
-			continue
+	}
+}
+
+// Compile functions exported and imported using the canonical ABI, and compare
+// the wrappers that lift and lower the parameters and results, and the
+// interface descriptor, with the expected output.
+func TestCanonicalABI(t *testing.T) {
+	options := &compileopts.Options{Target: "wasi", WasmAbi: "canonical"}
+	mod := irtest.CompileProgramWithOptions(t, options, "./testdata/canonical.go")
+
+	// The Go implementations of the imports are part of the main package, the
+	// exported wrappers and the imported functions are not.
+	ir := irtest.PackageIR(mod, "main") + irtest.FunctionIR(mod, "greet", "sum", "translate", "divmod", "main.hostReverse$import", "main.hostFill$import", "main.hostMirror$import", "cabi_realloc")
+	irtest.CheckGolden(t, "./testdata/canonical.ll", ir)
+
+	descriptor := compiler.CanonicalABIDescriptor(mod)
+	if irtest.Update() {
+		err := ioutil.WriteFile("./testdata/canonical.abi.json", descriptor, 0666)
+		if err != nil {
+			t.Error("failed to write updated golden file:", err)
 		}
-		out = append(out, line)
+		return
+	}
+	expected, err := ioutil.ReadFile("./testdata/canonical.abi.json")
+	if err != nil {
+		t.Fatal("could not read golden file (run with -update to create it):", err)
+	}
+	if string(expected) != string(descriptor) {
+		t.Errorf("descriptor does not match testdata/canonical.abi.json:\n%s", descriptor)
 	}
-	return out
 }
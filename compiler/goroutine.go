@@ -5,6 +5,7 @@ package compiler
 
 import (
 	"go/token"
+	"strings"
 
 	"github.com/tinygo-org/tinygo/compiler/llvmutil"
 	"golang.org/x/tools/go/ssa"
@@ -84,7 +85,7 @@ func (c *compilerContext) createGoroutineStartWrapper(fn llvm.Value, prefix stri
 		// Create the wrapper.
 		wrapperType := llvm.FunctionType(c.ctx.VoidType(), []llvm.Type{c.i8ptrType}, false)
 		wrapper = llvm.AddFunction(c.mod, name+"$gowrapper", wrapperType)
-		wrapper.SetLinkage(llvm.InternalLinkage)
+		c.setLocalLinkage(wrapper, llvm.InternalLinkage)
 		wrapper.SetUnnamedAddr(true)
 		wrapper.AddAttributeAtIndex(-1, c.ctx.CreateStringAttribute("tinygo-gowrapper", name))
 		entry := c.ctx.AddBasicBlock(wrapper, "entry")
@@ -119,6 +120,7 @@ func (c *compilerContext) createGoroutineStartWrapper(fn llvm.Value, prefix stri
 
 		// Create the call.
 		builder.CreateCall(fn, params, "")
+		c.createGoroutineExit(builder)
 
 	} else {
 		// For a function pointer like this:
@@ -141,7 +143,7 @@ func (c *compilerContext) createGoroutineStartWrapper(fn llvm.Value, prefix stri
 		// Create the wrapper.
 		wrapperType := llvm.FunctionType(c.ctx.VoidType(), []llvm.Type{c.i8ptrType}, false)
 		wrapper = llvm.AddFunction(c.mod, prefix+".gowrapper", wrapperType)
-		wrapper.SetLinkage(llvm.InternalLinkage)
+		c.setLocalLinkage(wrapper, llvm.InternalLinkage)
 		wrapper.SetUnnamedAddr(true)
 		wrapper.AddAttributeAtIndex(-1, c.ctx.CreateStringAttribute("tinygo-gowrapper", ""))
 		entry := c.ctx.AddBasicBlock(wrapper, "entry")
@@ -183,6 +185,7 @@ func (c *compilerContext) createGoroutineStartWrapper(fn llvm.Value, prefix stri
 
 		// Create the call.
 		builder.CreateCall(fnPtr, params, "")
+		c.createGoroutineExit(builder)
 	}
 
 	// Finish the function. Every basic block must end in a terminator, and
@@ -192,3 +195,16 @@ func (c *compilerContext) createGoroutineStartWrapper(fn llvm.Value, prefix stri
 	// Return a ptrtoint of the wrapper, not the function itself.
 	return builder.CreatePtrToInt(wrapper, c.uintptrType, "")
 }
+
+// createGoroutineExit inserts a call that stops the current goroutine at the
+// end of a goroutine start wrapper, if needed. With the tasks scheduler on
+// WebAssembly a goroutine is started from assembly that expects it to pause
+// instead of return (see src/internal/task/task_asyncify.go), so pause it
+// forever.
+func (c *compilerContext) createGoroutineExit(builder llvm.Builder) {
+	if c.Scheduler != "tasks" || !strings.HasPrefix(c.Triple, "wasm") {
+		return
+	}
+	deadlock := c.getFunction(c.program.ImportedPackage("runtime").Members["deadlock"].(*ssa.Function))
+	builder.CreateCall(deadlock, []llvm.Value{llvm.Undef(c.i8ptrType), llvm.Undef(c.i8ptrType)}, "")
+}
@@ -0,0 +1,166 @@
+package irtest
+
+import (
+	"go/types"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/tinygo-org/tinygo/compileopts"
+	"github.com/tinygo-org/tinygo/compiler"
+	"github.com/tinygo-org/tinygo/loader"
+	"tinygo.org/x/go-llvm"
+)
+
+// Targets is the list of targets to run program tests for. It includes one
+// target for every architecture family with its own lowering code.
+var Targets = []string{
+	"cortex-m-qemu",
+	"arduino", // atmega328p; the bare chip has no CPUFrequency
+	"wasm",
+	"riscv-qemu",
+}
+
+// CompileProgram compiles the program at path (a Go file or package) for the
+// given target with compiler.CompileProgram and returns the resulting module.
+// The module contains the whole program, including the runtime, but has not
+// been interpreted or optimized yet.
+func CompileProgram(t *testing.T, target, path string) llvm.Module {
+	t.Helper()
+	return CompileProgramWithOptions(t, &compileopts.Options{Target: target}, path)
+}
+
+// CompileProgramWithOptions is like CompileProgram, but uses the given options
+// (which must include the target) to configure the compiler. This can be used
+// to test options such as -wasm-abi.
+func CompileProgramWithOptions(t *testing.T, options *compileopts.Options, path string) llvm.Module {
+	t.Helper()
+	spec, err := compileopts.LoadTarget(options.Target)
+	if err != nil {
+		t.Fatal("failed to load target:", err)
+	}
+	config := &compileopts.Config{
+		Options: options,
+		Target:  spec,
+	}
+	compilerConfig := &compiler.Config{
+		Triple:             config.Triple(),
+		CPU:                config.CPU(),
+		Features:           config.Features(),
+		GOOS:               config.GOOS(),
+		GOARCH:             config.GOARCH(),
+		CodeModel:          config.CodeModel(),
+		RelocationModel:    config.RelocationModel(),
+		Scheduler:          config.Scheduler(),
+		FuncImplementation: config.FuncImplementation(),
+		AutomaticStackSize: config.AutomaticStackSize(),
+		DefaultStackSize:   config.Target.DefaultStackSize,
+		NeedsStackObjects:  config.NeedsStackObjects(),
+		WasmABI:            config.WasmAbi(),
+	}
+	machine, err := compiler.NewTargetMachine(compilerConfig)
+	if err != nil {
+		t.Fatal("failed to create target machine:", err)
+	}
+
+	lprogram, err := loader.Load(config, []string{path}, config.ClangHeaders, types.Config{
+		Sizes: compiler.Sizes(machine),
+	})
+	if err != nil {
+		t.Fatal("failed to load program:", err)
+	}
+	err = lprogram.Parse()
+	if err != nil {
+		t.Fatalf("could not parse %s: %s", path, err)
+	}
+
+	mod, errs := compiler.CompileProgram(lprogram, machine, compilerConfig, false)
+	if errs != nil {
+		for _, err := range errs {
+			t.Error("error:", err)
+		}
+		t.FailNow()
+	}
+	if llvm.VerifyModule(mod, llvm.PrintMessageAction) != nil {
+		t.Fatal("verification error after compiling", path)
+	}
+	return mod
+}
+
+// PackageIR returns the textual LLVM IR of all globals and functions defined
+// in the given package (for example, "main"), in the order in which they appear
+// in the module. This keeps golden files of whole programs small and
+// independent of changes in the runtime.
+func PackageIR(mod llvm.Module, pkgPath string) string {
+	isPackageSymbol := func(s string) bool {
+		return strings.HasPrefix(s, "@"+pkgPath+".") || strings.HasPrefix(s, "@\""+pkgPath+".")
+	}
+	buf := &strings.Builder{}
+	inFunction := false
+	for _, line := range strings.Split(mod.String(), "\n") {
+		switch {
+		case inFunction:
+			buf.WriteString(line + "\n")
+			if line == "}" {
+				buf.WriteString("\n")
+				inFunction = false
+			}
+		case isPackageSymbol(line):
+			buf.WriteString(line + "\n")
+		case strings.HasPrefix(line, "define ") && strings.Contains(line, "@"):
+			if isPackageSymbol(line[strings.Index(line, "@"):]) {
+				buf.WriteString("\n" + line + "\n")
+				inFunction = true
+			}
+		}
+	}
+	return buf.String()
+}
+
+var attributeGroupRegexp = regexp.MustCompile(` #[0-9]+`)
+
+// FunctionIR returns the textual LLVM IR of the functions with the given names,
+// which may be definitions or declarations, in the order in which they appear
+// in the module. References to attribute groups are removed, as their numbers
+// depend on the rest of the program.
+func FunctionIR(mod llvm.Module, names ...string) string {
+	isFunction := func(line string) bool {
+		for _, name := range names {
+			if strings.Contains(line, " @"+name+"(") || strings.Contains(line, " @\""+name+"\"(") {
+				return true
+			}
+		}
+		return false
+	}
+	buf := &strings.Builder{}
+	inFunction := false
+	for _, line := range strings.Split(mod.String(), "\n") {
+		switch {
+		case inFunction:
+			buf.WriteString(line + "\n")
+			if line == "}" {
+				buf.WriteString("\n")
+				inFunction = false
+			}
+		case strings.HasPrefix(line, "declare ") && isFunction(line):
+			buf.WriteString(attributeGroupRegexp.ReplaceAllString(line, "") + "\n\n")
+		case strings.HasPrefix(line, "define ") && isFunction(line):
+			buf.WriteString(attributeGroupRegexp.ReplaceAllString(line, "") + "\n")
+			inFunction = true
+		}
+	}
+	return buf.String()
+}
+
+// RunProgram compiles the Go program at pathPrefix+".go" for every target in
+// Targets, and compares the IR of the main package (see PackageIR) against the
+// golden file pathPrefix+"-"+target+".ll".
+func RunProgram(t *testing.T, pathPrefix string) {
+	for _, target := range Targets {
+		target := target
+		t.Run(target, func(t *testing.T) {
+			mod := CompileProgram(t, target, pathPrefix+".go")
+			CheckGolden(t, pathPrefix+"-"+target+".ll", PackageIR(mod, "main"))
+		})
+	}
+}
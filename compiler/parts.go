@@ -0,0 +1,181 @@
+package compiler
+
+// This file implements compiling a program in parts: the functions of every
+// package are compiled in a separate LLVM context, so that packages can be
+// compiled in parallel. The resulting modules are linked together afterwards.
+//
+// Symbols that are local to the program (internal or private linkage) need
+// special care, because they may be referenced from other parts or may even be
+// created in more than one part (type codes, method sets, wrappers, package
+// globals). While compiling a part they are therefore given a linkage that
+// allows the linker to resolve and merge them, and the original linkage is
+// restored after linking.
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+
+	"golang.org/x/tools/go/ssa"
+	"tinygo.org/x/go-llvm"
+)
+
+// compiledPart is the result of compiling a single part of the program.
+type compiledPart struct {
+	bitcode      llvm.MemoryBuffer
+	localSymbols map[string]llvm.Linkage
+	diagnostics  []error
+}
+
+// compileParts compiles the given functions, grouped by package, and links the
+// result into c.mod. Packages are compiled in parallel, unless the SSA is
+// dumped (to avoid interleaving the output).
+func (c *compilerContext) compileParts(functions []*ssa.Function) []error {
+	// Group functions by package, in the order in which they are encountered.
+	// Synthetic wrappers that don't belong to a package are grouped together.
+	var parts [][]*ssa.Function
+	partIndices := make(map[*ssa.Package]int)
+	for _, f := range functions {
+		if f.Blocks == nil {
+			continue // external function
+		}
+		index, ok := partIndices[f.Pkg]
+		if !ok {
+			index = len(parts)
+			partIndices[f.Pkg] = index
+			parts = append(parts, nil)
+		}
+		parts[index] = append(parts[index], f)
+	}
+
+	// Compile all parts.
+	results := make([]compiledPart, len(parts))
+	limit := runtime.NumCPU()
+	if c.DumpSSA {
+		limit = 1
+	}
+	limiter := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, part := range parts {
+		wg.Add(1)
+		go func(i int, part []*ssa.Function) {
+			defer wg.Done()
+			limiter <- struct{}{}
+			results[i] = c.compilePart(part)
+			<-limiter
+		}(i, part)
+	}
+	wg.Wait()
+
+	// Link all parts together, in a deterministic order.
+	var errs []error
+	localSymbols := make(map[string]llvm.Linkage)
+	for _, result := range results {
+		errs = append(errs, result.diagnostics...)
+		if errs != nil {
+			// Don't bother linking, the errors will be reported instead.
+			result.bitcode.Dispose()
+			continue
+		}
+		mod, err := c.ctx.ParseIR(result.bitcode)
+		if err != nil {
+			errs = append(errs, errors.New("could not read compiled package: "+err.Error()))
+			continue
+		}
+		err = llvm.LinkModules(c.mod, mod)
+		if err != nil {
+			errs = append(errs, errors.New("could not link compiled package: "+err.Error()))
+			continue
+		}
+		for name, linkage := range result.localSymbols {
+			localSymbols[name] = linkage
+		}
+	}
+	if errs != nil {
+		return errs
+	}
+
+	// Restore the linkage of all symbols that are local to the program.
+	for name, linkage := range localSymbols {
+		value := c.mod.NamedFunction(name)
+		if value.IsNil() {
+			value = c.mod.NamedGlobal(name)
+		}
+		if value.IsNil() || value.IsDeclaration() {
+			continue
+		}
+		value.SetLinkage(linkage)
+	}
+	return nil
+}
+
+// compilePart compiles the given functions in a new LLVM context and returns
+// the resulting module as bitcode, so that it can be loaded into the context of
+// c.mod. It is safe to call from multiple goroutines at the same time.
+func (c *compilerContext) compilePart(functions []*ssa.Function) compiledPart {
+	moduleName := "<wrappers>"
+	if pkg := functions[0].Pkg; pkg != nil {
+		moduleName = pkg.Pkg.Path()
+	}
+	pc := newCompilerContext(moduleName, c.machine, c.Config, c.DumpSSA)
+	defer pc.ctx.Dispose()
+	defer pc.targetData.Dispose()
+	pc.program = c.program
+	pc.runtimePkg = c.runtimePkg
+	pc.trimPath = c.trimPath
+	pc.astComments = c.astComments
+	pc.localSymbols = make(map[string]llvm.Linkage)
+	if pc.Debug {
+		pc.initDebugInfo()
+	}
+
+	// Predeclare the runtime.alloc and runtime.trackPointer functions, which
+	// are used by the wordpack functionality.
+	runtimePkg := pc.program.ImportedPackage("runtime")
+	pc.getFunction(runtimePkg.Members["alloc"].(*ssa.Function))
+	if pc.NeedsStackObjects {
+		pc.getFunction(runtimePkg.Members["trackPointer"].(*ssa.Function))
+	}
+
+	// Create all function definitions.
+	irbuilder := pc.ctx.NewBuilder()
+	defer irbuilder.Dispose()
+	for _, f := range functions {
+		b := newBuilder(pc, irbuilder, f)
+		b.createFunction()
+	}
+
+	if pc.Debug {
+		pc.dibuilder.Finalize()
+	}
+	return compiledPart{
+		bitcode:      llvm.WriteBitcodeToMemoryBuffer(pc.mod),
+		localSymbols: pc.localSymbols,
+		diagnostics:  pc.diagnostics,
+	}
+}
+
+// setLocalLinkage sets the linkage of a symbol that is local to the program,
+// usually llvm.InternalLinkage or llvm.PrivateLinkage. When compiling a part of
+// the program, the same symbol may also be created in other parts. Therefore,
+// it gets a linkage that allows the linker to merge these copies instead, until
+// all parts have been linked together.
+func (c *compilerContext) setLocalLinkage(value llvm.Value, linkage llvm.Linkage) {
+	if c.localSymbols != nil {
+		c.localSymbols[value.Name()] = linkage
+		linkage = llvm.LinkOnceODRLinkage
+	}
+	value.SetLinkage(linkage)
+}
+
+// setDefinitionLinkage sets the linkage of a function that is local to the
+// program and is only defined in one part of the program. When compiling a
+// part of the program, other parts may reference it so it stays externally
+// visible until all parts have been linked together.
+func (c *compilerContext) setDefinitionLinkage(value llvm.Value, linkage llvm.Linkage) {
+	if c.localSymbols != nil {
+		c.localSymbols[value.Name()] = linkage
+		return
+	}
+	value.SetLinkage(linkage)
+}
@@ -21,13 +21,15 @@ import (
 // The linkName value contains a valid link name, even if //go:linkname is not
 // present.
 type functionInfo struct {
-	module     string     // go:wasm-module
-	importName string     // go:linkname, go:export - The name the developer assigns
-	linkName   string     // go:linkname, go:export - The name that we map for the particular module -> importName
-	exported   bool       // go:export, CGo
-	nobounds   bool       // go:nobounds
-	variadic   bool       // go:variadic (CGo only)
-	inline     inlineType // go:inline
+	module        string     // go:wasm-module
+	importName    string     // go:linkname, go:export - The name the developer assigns
+	linkName      string     // go:linkname, go:export - The name that we map for the particular module -> importName
+	exported      bool       // go:export, CGo
+	canonical     bool       // exported or imported using -wasm-abi=canonical
+	canonicalName string     // name of the canonical export or import
+	nobounds      bool       // go:nobounds
+	variadic      bool       // go:variadic (CGo only)
+	inline        inlineType // go:inline
 }
 
 type inlineType int
@@ -147,6 +149,15 @@ func (c *compilerContext) getFunction(fn *ssa.Function) llvm.Value {
 		llvmFn.AddAttributeAtIndex(1, c.ctx.CreateEnumAttribute(llvm.AttributeKindID("readonly"), 0))
 	}
 
+	if info.canonical {
+		if fn.Blocks == nil {
+			// Imported function: implement it as a Go function that calls the
+			// canonical import.
+			c.createCanonicalImport(fn, info, llvmFn)
+		}
+		return llvmFn
+	}
+
 	// External/exported functions may not retain pointer values.
 	// https://golang.org/cmd/cgo/#hdr-Passing_pointers
 	if info.exported {
@@ -180,7 +191,11 @@ func (c *compilerContext) getFunction(fn *ssa.Function) llvm.Value {
 // exported.
 func (c *compilerContext) getFunctionInfo(f *ssa.Function) functionInfo {
 	info := functionInfo{}
-	if strings.HasPrefix(f.Name(), "C.") {
+	if strings.HasPrefix(f.Name(), "C.") && f.Blocks != nil {
+		// Helper function defined by CGo (like C.GoStringN), which is a
+		// regular Go function.
+		info.linkName = f.RelString(nil)
+	} else if strings.HasPrefix(f.Name(), "C.") {
 		// Created by CGo: such a name cannot be created by regular C code.
 		info.linkName = f.Name()[2:]
 		info.exported = true
@@ -190,6 +205,18 @@ func (c *compilerContext) getFunctionInfo(f *ssa.Function) functionInfo {
 	}
 	// Check for //go: pragmas, which may change the link name (among others).
 	info.parsePragmas(f)
+	if c.isCanonicalFunction(f, info) {
+		// The Go function uses the Go calling convention (without context
+		// parameter) and is wrapped by a function using the canonical ABI, see
+		// canonicalabi.go.
+		info.canonical = true
+		if info.module != "" {
+			info.canonicalName = info.importName
+		} else {
+			info.canonicalName = info.linkName
+		}
+		info.linkName = f.RelString(nil)
+	}
 	return info
 }
 
@@ -203,6 +230,7 @@ func (info *functionInfo) parsePragmas(f *ssa.Function) {
 
 		// Our importName for a wasm module (if we are compiling to wasm), or llvm link name
 		var importName string
+		var hasLinkName bool
 
 		for _, comment := range decl.Doc.List {
 			text := comment.Text
@@ -243,6 +271,7 @@ func (info *functionInfo) parsePragmas(f *ssa.Function) {
 				// whole.
 				if hasUnsafeImport(f.Pkg.Pkg) {
 					info.linkName = parts[2]
+					hasLinkName = true
 				}
 			case "//go:nobounds":
 				// Skip bounds checking in this function. Useful for some
@@ -265,6 +294,14 @@ func (info *functionInfo) parsePragmas(f *ssa.Function) {
 			}
 		}
 
+		if hasLinkName && importName == "" {
+			// The function links to a Go function, so it must use the Go
+			// calling convention. This is relevant for CGo helper functions
+			// like C.CString. A function that is also marked //export keeps
+			// the C calling convention.
+			info.exported = false
+		}
+
 		// Set the importName for our exported function if we have one
 		if importName != "" {
 			if info.module == "" {
@@ -328,7 +365,10 @@ func (c *compilerContext) getGlobal(g *ssa.Global) llvm.Value {
 		llvmGlobal = llvm.AddGlobal(c.mod, llvmType, info.linkName)
 		if !info.extern {
 			llvmGlobal.SetInitializer(llvm.ConstNull(llvmType))
-			llvmGlobal.SetLinkage(llvm.InternalLinkage)
+			c.setLocalLinkage(llvmGlobal, llvm.InternalLinkage)
+			if value, ok := c.getGlobalValue(g); ok {
+				llvmGlobal.SetInitializer(c.createStringConst(info.linkName, value))
+			}
 		}
 
 		// Set alignment from the //go:align comment.
@@ -366,6 +406,62 @@ func (c *compilerContext) getGlobal(g *ssa.Global) llvm.Value {
 	return llvmGlobal
 }
 
+// getGlobalValue returns the value of a string global as set on the command
+// line using -ldflags="-X importpath.name=value", if there is one. The main
+// package can be referred to as "main", like with the go toolchain.
+func (c *compilerContext) getGlobalValue(g *ssa.Global) (string, bool) {
+	pkg := g.Pkg.Pkg
+	if values, ok := c.GlobalValues[pkg.Path()]; ok {
+		if value, ok := values[g.Name()]; ok {
+			return value, true
+		}
+	}
+	if pkg.Name() == "main" {
+		if value, ok := c.GlobalValues["main"][g.Name()]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// checkGlobalValues checks whether all globals that are set using -X are
+// string variables. Globals that don't exist in the program are ignored, like
+// the Go linker does.
+func (c *compilerContext) checkGlobalValues() []error {
+	var errs []error
+	for _, pkg := range c.program.AllPackages() {
+		for name, member := range pkg.Members {
+			g, ok := member.(*ssa.Global)
+			if !ok {
+				continue
+			}
+			if _, ok := c.getGlobalValue(g); !ok {
+				continue
+			}
+			typ := g.Type().(*types.Pointer).Elem()
+			if basic, ok := typ.Underlying().(*types.Basic); !ok || basic.Kind() != types.String {
+				errs = append(errs, c.makeError(g.Pos(), pkg.Pkg.Path()+"."+name+": cannot set with -X: not a var of type string ("+typ.String()+")"))
+			}
+		}
+	}
+	return errs
+}
+
+// createStringConst creates a constant string object (of type runtime._string)
+// with the given contents. The string data is stored in a new global with the
+// given name prefix.
+func (c *compilerContext) createStringConst(prefix, str string) llvm.Value {
+	global := llvm.AddGlobal(c.mod, llvm.ArrayType(c.ctx.Int8Type(), len(str)), prefix+"$string")
+	global.SetInitializer(c.ctx.ConstString(str, false))
+	c.setLocalLinkage(global, llvm.InternalLinkage)
+	global.SetGlobalConstant(true)
+	global.SetUnnamedAddr(true)
+	zero := llvm.ConstInt(c.ctx.Int32Type(), 0, false)
+	strPtr := llvm.ConstInBoundsGEP(global, []llvm.Value{zero, zero})
+	strLen := llvm.ConstInt(c.uintptrType, uint64(len(str)), false)
+	return llvm.ConstNamedStruct(c.getLLVMRuntimeType("_string"), []llvm.Value{strPtr, strLen})
+}
+
 // getGlobalInfo returns some information about a specific global.
 func (c *compilerContext) getGlobalInfo(g *ssa.Global) globalInfo {
 	info := globalInfo{}
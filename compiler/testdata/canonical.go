@@ -0,0 +1,62 @@
+package main
+
+// Exports and imports using the canonical ABI (-wasm-abi=canonical). Exports
+// lift their parameters and lower their results, imports lower their
+// parameters and lift their results.
+
+type point struct {
+	X, Y int32
+}
+
+type record struct {
+	Name  string
+	Flags uint8
+	Pos   point
+	Ok    bool
+}
+
+//export greet
+func greet(name string) string {
+	return "hello, " + name
+}
+
+//export sum
+func sum(values []int32) int32 {
+	total := int32(0)
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+//export translate
+func translate(r record, dx int16, scale float64) record {
+	r.Pos.X += int32(dx)
+	r.Pos.Y = int32(float64(r.Pos.Y) * scale)
+	return r
+}
+
+//export divmod
+func divmod(a, b int64) (int64, int64) {
+	return a / b, a % b
+}
+
+//go:wasm-module host
+//export reverse
+func hostReverse(s string) string
+
+//go:wasm-module host
+//export fill
+func hostFill(buf []byte, value uint8) (n int32, ok bool)
+
+//go:wasm-module host
+//export mirror
+func hostMirror(r record) record
+
+func main() {
+	println(hostReverse("hello"))
+	n, ok := hostFill(make([]byte, 4), 3)
+	println(n, ok)
+	r := hostMirror(record{Name: "p", Pos: point{1, 2}})
+	println(r.Name, r.Pos.X, r.Pos.Y)
+}
@@ -0,0 +1,622 @@
+@"main.greet$string" = internal unnamed_addr constant [7 x i8] c"hello, "
+@"main.main$string" = internal unnamed_addr constant [5 x i8] c"hello"
+@"main.main$string.1" = internal unnamed_addr constant [1 x i8] c"p"
+
+define internal { i64, i64 } @main.divmod(i64 %a, i64 %b) unnamed_addr {
+entry:
+  %0 = sdiv i64 %a, %b
+  %1 = srem i64 %a, %b
+  %2 = insertvalue { i64, i64 } zeroinitializer, i64 %0, 0
+  %3 = insertvalue { i64, i64 } %2, i64 %1, 1
+  ret { i64, i64 } %3
+}
+
+
+define internal %runtime._string @main.greet(i8* %name.data, i32 %name.len) unnamed_addr {
+entry:
+  %0 = insertvalue %runtime._string zeroinitializer, i8* %name.data, 0
+  %1 = insertvalue %runtime._string %0, i32 %name.len, 1
+  %2 = extractvalue %runtime._string %1, 0
+  %3 = extractvalue %runtime._string %1, 1
+  %4 = call %runtime._string @runtime.stringConcat(i8* getelementptr inbounds ([7 x i8], [7 x i8]* @"main.greet$string", i32 0, i32 0), i32 7, i8* %2, i32 %3, i8* undef, i8* null)
+  %5 = extractvalue %runtime._string %4, 0
+  call void @runtime.trackPointer(i8* %5, i8* undef, i8* null)
+  %6 = extractvalue %runtime._string %4, 1
+  ret %runtime._string %4
+}
+
+
+define internal void @main.init(i8* %context, i8* %parentHandle) unnamed_addr {
+entry:
+  ret void
+}
+
+
+define internal void @main.main(i8* %context, i8* %parentHandle) {
+entry:
+  %complit = alloca %main.record, align 8
+  %r = alloca %main.record, align 8
+  %0 = call %runtime._string @main.hostReverse(i8* getelementptr inbounds ([5 x i8], [5 x i8]* @"main.main$string", i32 0, i32 0), i32 5)
+  %1 = extractvalue %runtime._string %0, 0
+  call void @runtime.trackPointer(i8* %1, i8* undef, i8* null)
+  %2 = extractvalue %runtime._string %0, 1
+  %3 = extractvalue %runtime._string %0, 0
+  %4 = extractvalue %runtime._string %0, 1
+  call void @runtime.printstring(i8* %3, i32 %4, i8* undef, i8* null)
+  call void @runtime.printnl(i8* undef, i8* null)
+  %makeslice = call i8* @runtime.alloc(i32 4, i8* undef, i8* null)
+  %5 = bitcast i8* %makeslice to [4 x i8]*
+  %6 = bitcast [4 x i8]* %5 to i8*
+  call void @runtime.trackPointer(i8* %6, i8* undef, i8* null)
+  %7 = icmp eq [4 x i8]* %5, null
+  br i1 %7, label %slice.throw, label %slice.next
+
+slice.throw:                                      ; preds = %entry
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+slice.next:                                       ; preds = %entry
+  %slice.ptr = getelementptr inbounds [4 x i8], [4 x i8]* %5, i32 0, i32 0
+  %8 = insertvalue { i8*, i32, i32 } undef, i8* %slice.ptr, 0
+  %9 = insertvalue { i8*, i32, i32 } %8, i32 4, 1
+  %10 = insertvalue { i8*, i32, i32 } %9, i32 4, 2
+  %11 = extractvalue { i8*, i32, i32 } %10, 0
+  %12 = extractvalue { i8*, i32, i32 } %10, 1
+  %13 = extractvalue { i8*, i32, i32 } %10, 2
+  %14 = call { i32, i1 } @main.hostFill(i8* %11, i32 %12, i32 %13, i8 3)
+  %15 = extractvalue { i32, i1 } %14, 0
+  %16 = extractvalue { i32, i1 } %14, 1
+  call void @runtime.printint32(i32 %15, i8* undef, i8* null)
+  call void @runtime.printspace(i8* undef, i8* null)
+  call void @runtime.printbool(i1 %16, i8* undef, i8* null)
+  call void @runtime.printnl(i8* undef, i8* null)
+  store %main.record zeroinitializer, %main.record* %r, align 4
+  %17 = bitcast %main.record* %r to i8*
+  call void @runtime.trackPointer(i8* %17, i8* undef, i8* null)
+  store %main.record zeroinitializer, %main.record* %complit, align 4
+  %18 = bitcast %main.record* %complit to i8*
+  call void @runtime.trackPointer(i8* %18, i8* undef, i8* null)
+  %19 = icmp eq %main.record* %complit, null
+  br i1 %19, label %gep.throw, label %gep.next
+
+gep.throw:                                        ; preds = %slice.next
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next:                                         ; preds = %slice.next
+  %20 = getelementptr inbounds %main.record, %main.record* %complit, i32 0, i32 0
+  %21 = icmp eq %main.record* %complit, null
+  br i1 %21, label %gep.throw1, label %gep.next2
+
+gep.throw1:                                       ; preds = %gep.next
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next2:                                        ; preds = %gep.next
+  %22 = getelementptr inbounds %main.record, %main.record* %complit, i32 0, i32 2
+  %23 = icmp eq %reflect.StringHeader* %22, null
+  br i1 %23, label %gep.throw3, label %gep.next4
+
+gep.throw3:                                       ; preds = %gep.next2
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next4:                                        ; preds = %gep.next2
+  %24 = getelementptr inbounds %reflect.StringHeader, %reflect.StringHeader* %22, i32 0, i32 0
+  %25 = icmp eq %reflect.StringHeader* %22, null
+  br i1 %25, label %gep.throw5, label %gep.next6
+
+gep.throw5:                                       ; preds = %gep.next4
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next6:                                        ; preds = %gep.next4
+  %26 = getelementptr inbounds %reflect.StringHeader, %reflect.StringHeader* %22, i32 0, i32 1
+  %27 = icmp eq %runtime._string* %20, null
+  br i1 %27, label %store.throw, label %store.next
+
+store.throw:                                      ; preds = %gep.next6
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+store.next:                                       ; preds = %gep.next6
+  store %runtime._string { i8* getelementptr inbounds ([1 x i8], [1 x i8]* @"main.main$string.1", i32 0, i32 0), i32 1 }, %runtime._string* %20, align 4
+  %28 = icmp eq i32* %24, null
+  br i1 %28, label %store.throw7, label %store.next8
+
+store.throw7:                                     ; preds = %store.next
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+store.next8:                                      ; preds = %store.next
+  store i32 1, i32* %24, align 4
+  %29 = icmp eq i32* %26, null
+  br i1 %29, label %store.throw9, label %store.next10
+
+store.throw9:                                     ; preds = %store.next8
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+store.next10:                                     ; preds = %store.next8
+  store i32 2, i32* %26, align 4
+  %30 = icmp eq %main.record* %complit, null
+  br i1 %30, label %deref.throw, label %deref.next
+
+deref.throw:                                      ; preds = %store.next10
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+deref.next:                                       ; preds = %store.next10
+  %31 = load %main.record, %main.record* %complit, align 4
+  %32 = extractvalue %main.record %31, 0
+  %33 = extractvalue %runtime._string %32, 0
+  call void @runtime.trackPointer(i8* %33, i8* undef, i8* null)
+  %34 = extractvalue %runtime._string %32, 1
+  %35 = extractvalue %main.record %31, 1
+  %36 = extractvalue %main.record %31, 2
+  %37 = extractvalue %main.record %31, 3
+  %38 = call %main.record @main.hostMirror(%main.record %31)
+  %39 = extractvalue %main.record %38, 0
+  %40 = extractvalue %runtime._string %39, 0
+  call void @runtime.trackPointer(i8* %40, i8* undef, i8* null)
+  %41 = extractvalue %runtime._string %39, 1
+  %42 = extractvalue %main.record %38, 1
+  %43 = extractvalue %main.record %38, 2
+  %44 = extractvalue %main.record %38, 3
+  %45 = icmp eq %main.record* %r, null
+  br i1 %45, label %store.throw11, label %store.next12
+
+store.throw11:                                    ; preds = %deref.next
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+store.next12:                                     ; preds = %deref.next
+  store %main.record %38, %main.record* %r, align 4
+  %46 = icmp eq %main.record* %r, null
+  br i1 %46, label %gep.throw13, label %gep.next14
+
+gep.throw13:                                      ; preds = %store.next12
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next14:                                       ; preds = %store.next12
+  %47 = getelementptr inbounds %main.record, %main.record* %r, i32 0, i32 0
+  %48 = icmp eq %runtime._string* %47, null
+  br i1 %48, label %deref.throw15, label %deref.next16
+
+deref.throw15:                                    ; preds = %gep.next14
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+deref.next16:                                     ; preds = %gep.next14
+  %49 = load %runtime._string, %runtime._string* %47, align 4
+  %50 = extractvalue %runtime._string %49, 0
+  call void @runtime.trackPointer(i8* %50, i8* undef, i8* null)
+  %51 = extractvalue %runtime._string %49, 1
+  %52 = icmp eq %main.record* %r, null
+  br i1 %52, label %gep.throw17, label %gep.next18
+
+gep.throw17:                                      ; preds = %deref.next16
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next18:                                       ; preds = %deref.next16
+  %53 = getelementptr inbounds %main.record, %main.record* %r, i32 0, i32 2
+  %54 = icmp eq %reflect.StringHeader* %53, null
+  br i1 %54, label %gep.throw19, label %gep.next20
+
+gep.throw19:                                      ; preds = %gep.next18
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next20:                                       ; preds = %gep.next18
+  %55 = getelementptr inbounds %reflect.StringHeader, %reflect.StringHeader* %53, i32 0, i32 0
+  %56 = icmp eq i32* %55, null
+  br i1 %56, label %deref.throw21, label %deref.next22
+
+deref.throw21:                                    ; preds = %gep.next20
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+deref.next22:                                     ; preds = %gep.next20
+  %57 = load i32, i32* %55, align 4
+  %58 = icmp eq %main.record* %r, null
+  br i1 %58, label %gep.throw23, label %gep.next24
+
+gep.throw23:                                      ; preds = %deref.next22
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next24:                                       ; preds = %deref.next22
+  %59 = getelementptr inbounds %main.record, %main.record* %r, i32 0, i32 2
+  %60 = icmp eq %reflect.StringHeader* %59, null
+  br i1 %60, label %gep.throw25, label %gep.next26
+
+gep.throw25:                                      ; preds = %gep.next24
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next26:                                       ; preds = %gep.next24
+  %61 = getelementptr inbounds %reflect.StringHeader, %reflect.StringHeader* %59, i32 0, i32 1
+  %62 = icmp eq i32* %61, null
+  br i1 %62, label %deref.throw27, label %deref.next28
+
+deref.throw27:                                    ; preds = %gep.next26
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+deref.next28:                                     ; preds = %gep.next26
+  %63 = load i32, i32* %61, align 4
+  %64 = extractvalue %runtime._string %49, 0
+  %65 = extractvalue %runtime._string %49, 1
+  call void @runtime.printstring(i8* %64, i32 %65, i8* undef, i8* null)
+  call void @runtime.printspace(i8* undef, i8* null)
+  call void @runtime.printint32(i32 %57, i8* undef, i8* null)
+  call void @runtime.printspace(i8* undef, i8* null)
+  call void @runtime.printint32(i32 %63, i8* undef, i8* null)
+  call void @runtime.printnl(i8* undef, i8* null)
+  ret void
+}
+
+
+define internal %runtime._string @main.hostReverse(i8* %0, i32 %1) unnamed_addr {
+entry:
+  %2 = insertvalue %runtime._string zeroinitializer, i8* %0, 0
+  %3 = insertvalue %runtime._string %2, i32 %1, 1
+  %4 = extractvalue %runtime._string %3, 0
+  %5 = extractvalue %runtime._string %3, 1
+  %6 = ptrtoint i8* %4 to i32
+  %retarea = alloca { i32, i32 }, align 8
+  %7 = ptrtoint { i32, i32 }* %retarea to i32
+  %8 = call i32 @runtime.cabiPinCount(i8* undef, i8* null)
+  call void @"main.hostReverse$import"(i32 %6, i32 %5, i32 %7)
+  %9 = getelementptr inbounds { i32, i32 }, { i32, i32 }* %retarea, i32 0, i32 0
+  %10 = load i32, i32* %9, align 4
+  %11 = getelementptr inbounds { i32, i32 }, { i32, i32 }* %retarea, i32 0, i32 1
+  %12 = load i32, i32* %11, align 4
+  %13 = inttoptr i32 %10 to i8*
+  %14 = insertvalue %runtime._string undef, i8* %13, 0
+  %15 = insertvalue %runtime._string %14, i32 %12, 1
+  call void @runtime.cabiUnpin(i32 %8, i8* undef, i8* null)
+  ret %runtime._string %15
+}
+
+
+define internal { i32, i1 } @main.hostFill(i8* %0, i32 %1, i32 %2, i8 %3) unnamed_addr {
+entry:
+  %4 = insertvalue { i8*, i32, i32 } zeroinitializer, i8* %0, 0
+  %5 = insertvalue { i8*, i32, i32 } %4, i32 %1, 1
+  %6 = insertvalue { i8*, i32, i32 } %5, i32 %2, 2
+  %7 = extractvalue { i8*, i32, i32 } %6, 0
+  %8 = extractvalue { i8*, i32, i32 } %6, 1
+  %9 = ptrtoint i8* %7 to i32
+  %10 = zext i8 %3 to i32
+  %retarea = alloca { i32, i32 }, align 8
+  %11 = ptrtoint { i32, i32 }* %retarea to i32
+  %12 = call i32 @runtime.cabiPinCount(i8* undef, i8* null)
+  call void @"main.hostFill$import"(i32 %9, i32 %8, i32 %10, i32 %11)
+  %13 = getelementptr inbounds { i32, i32 }, { i32, i32 }* %retarea, i32 0, i32 0
+  %14 = load i32, i32* %13, align 4
+  %15 = getelementptr inbounds { i32, i32 }, { i32, i32 }* %retarea, i32 0, i32 1
+  %16 = load i32, i32* %15, align 4
+  %17 = icmp ne i32 %16, 0
+  call void @runtime.cabiUnpin(i32 %12, i8* undef, i8* null)
+  %18 = insertvalue { i32, i1 } undef, i32 %14, 0
+  %19 = insertvalue { i32, i1 } %18, i1 %17, 1
+  ret { i32, i1 } %19
+}
+
+
+define internal %main.record @main.hostMirror(%main.record %0) unnamed_addr {
+entry:
+  %1 = extractvalue %main.record %0, 0
+  %2 = extractvalue %runtime._string %1, 0
+  %3 = extractvalue %runtime._string %1, 1
+  %4 = ptrtoint i8* %2 to i32
+  %5 = extractvalue %main.record %0, 1
+  %6 = zext i8 %5 to i32
+  %7 = extractvalue %main.record %0, 2
+  %8 = extractvalue %reflect.StringHeader %7, 0
+  %9 = extractvalue %reflect.StringHeader %7, 1
+  %10 = extractvalue %main.record %0, 3
+  %11 = zext i1 %10 to i32
+  %retarea = alloca { i32, i32, i32, i32, i32, i32 }, align 8
+  %12 = ptrtoint { i32, i32, i32, i32, i32, i32 }* %retarea to i32
+  %13 = call i32 @runtime.cabiPinCount(i8* undef, i8* null)
+  call void @"main.hostMirror$import"(i32 %4, i32 %3, i32 %6, i32 %8, i32 %9, i32 %11, i32 %12)
+  %14 = getelementptr inbounds { i32, i32, i32, i32, i32, i32 }, { i32, i32, i32, i32, i32, i32 }* %retarea, i32 0, i32 0
+  %15 = load i32, i32* %14, align 4
+  %16 = getelementptr inbounds { i32, i32, i32, i32, i32, i32 }, { i32, i32, i32, i32, i32, i32 }* %retarea, i32 0, i32 1
+  %17 = load i32, i32* %16, align 4
+  %18 = getelementptr inbounds { i32, i32, i32, i32, i32, i32 }, { i32, i32, i32, i32, i32, i32 }* %retarea, i32 0, i32 2
+  %19 = load i32, i32* %18, align 4
+  %20 = getelementptr inbounds { i32, i32, i32, i32, i32, i32 }, { i32, i32, i32, i32, i32, i32 }* %retarea, i32 0, i32 3
+  %21 = load i32, i32* %20, align 4
+  %22 = getelementptr inbounds { i32, i32, i32, i32, i32, i32 }, { i32, i32, i32, i32, i32, i32 }* %retarea, i32 0, i32 4
+  %23 = load i32, i32* %22, align 4
+  %24 = getelementptr inbounds { i32, i32, i32, i32, i32, i32 }, { i32, i32, i32, i32, i32, i32 }* %retarea, i32 0, i32 5
+  %25 = load i32, i32* %24, align 4
+  %26 = inttoptr i32 %15 to i8*
+  %27 = insertvalue %runtime._string undef, i8* %26, 0
+  %28 = insertvalue %runtime._string %27, i32 %17, 1
+  %29 = insertvalue %main.record undef, %runtime._string %28, 0
+  %30 = trunc i32 %19 to i8
+  %31 = insertvalue %main.record %29, i8 %30, 1
+  %32 = insertvalue %reflect.StringHeader undef, i32 %21, 0
+  %33 = insertvalue %reflect.StringHeader %32, i32 %23, 1
+  %34 = insertvalue %main.record %31, %reflect.StringHeader %33, 2
+  %35 = icmp ne i32 %25, 0
+  %36 = insertvalue %main.record %34, i1 %35, 3
+  call void @runtime.cabiUnpin(i32 %13, i8* undef, i8* null)
+  ret %main.record %36
+}
+
+
+define internal i32 @main.sum(i32* %values.data, i32 %values.len, i32 %values.cap) unnamed_addr {
+entry:
+  %0 = insertvalue { i32*, i32, i32 } zeroinitializer, i32* %values.data, 0
+  %1 = insertvalue { i32*, i32, i32 } %0, i32 %values.len, 1
+  %2 = insertvalue { i32*, i32, i32 } %1, i32 %values.cap, 2
+  %len = extractvalue { i32*, i32, i32 } %2, 1
+  br label %rangeindex.loop
+
+rangeindex.loop:                                  ; preds = %lookup.next, %entry
+  %3 = phi i32 [ 0, %entry ], [ %10, %lookup.next ]
+  %4 = phi i32 [ -1, %entry ], [ %5, %lookup.next ]
+  %5 = add i32 %4, 1
+  %6 = icmp slt i32 %5, %len
+  br i1 %6, label %rangeindex.body, label %rangeindex.done
+
+rangeindex.body:                                  ; preds = %rangeindex.loop
+  %indexaddr.ptr = extractvalue { i32*, i32, i32 } %2, 0
+  %indexaddr.len = extractvalue { i32*, i32, i32 } %2, 1
+  %7 = icmp uge i32 %5, %indexaddr.len
+  br i1 %7, label %lookup.throw, label %lookup.next
+
+rangeindex.done:                                  ; preds = %rangeindex.loop
+  ret i32 %3
+
+lookup.throw:                                     ; preds = %rangeindex.body
+  call void @runtime.lookupPanic(i8* undef, i8* null)
+  unreachable
+
+lookup.next:                                      ; preds = %rangeindex.body
+  %8 = getelementptr inbounds i32, i32* %indexaddr.ptr, i32 %5
+  %9 = load i32, i32* %8, align 4
+  %10 = add i32 %3, %9
+  br label %rangeindex.loop
+}
+
+
+define internal %main.record @main.translate(%main.record %r, i16 %dx, double %scale) unnamed_addr {
+entry:
+  %r1 = alloca %main.record, align 8
+  store %main.record zeroinitializer, %main.record* %r1, align 4
+  %0 = bitcast %main.record* %r1 to i8*
+  call void @runtime.trackPointer(i8* %0, i8* undef, i8* null)
+  %1 = icmp eq %main.record* %r1, null
+  br i1 %1, label %store.throw, label %store.next
+
+store.throw:                                      ; preds = %entry
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+store.next:                                       ; preds = %entry
+  store %main.record %r, %main.record* %r1, align 4
+  %2 = icmp eq %main.record* %r1, null
+  br i1 %2, label %gep.throw, label %gep.next
+
+gep.throw:                                        ; preds = %store.next
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next:                                         ; preds = %store.next
+  %3 = getelementptr inbounds %main.record, %main.record* %r1, i32 0, i32 2
+  %4 = icmp eq %reflect.StringHeader* %3, null
+  br i1 %4, label %gep.throw2, label %gep.next3
+
+gep.throw2:                                       ; preds = %gep.next
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next3:                                        ; preds = %gep.next
+  %5 = getelementptr inbounds %reflect.StringHeader, %reflect.StringHeader* %3, i32 0, i32 0
+  %6 = sext i16 %dx to i32
+  %7 = icmp eq i32* %5, null
+  br i1 %7, label %deref.throw, label %deref.next
+
+deref.throw:                                      ; preds = %gep.next3
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+deref.next:                                       ; preds = %gep.next3
+  %8 = load i32, i32* %5, align 4
+  %9 = add i32 %8, %6
+  %10 = icmp eq i32* %5, null
+  br i1 %10, label %store.throw4, label %store.next5
+
+store.throw4:                                     ; preds = %deref.next
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+store.next5:                                      ; preds = %deref.next
+  store i32 %9, i32* %5, align 4
+  %11 = icmp eq %main.record* %r1, null
+  br i1 %11, label %gep.throw6, label %gep.next7
+
+gep.throw6:                                       ; preds = %store.next5
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next7:                                        ; preds = %store.next5
+  %12 = getelementptr inbounds %main.record, %main.record* %r1, i32 0, i32 2
+  %13 = icmp eq %reflect.StringHeader* %12, null
+  br i1 %13, label %gep.throw8, label %gep.next9
+
+gep.throw8:                                       ; preds = %gep.next7
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next9:                                        ; preds = %gep.next7
+  %14 = getelementptr inbounds %reflect.StringHeader, %reflect.StringHeader* %12, i32 0, i32 1
+  %15 = icmp eq %main.record* %r1, null
+  br i1 %15, label %gep.throw10, label %gep.next11
+
+gep.throw10:                                      ; preds = %gep.next9
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next11:                                       ; preds = %gep.next9
+  %16 = getelementptr inbounds %main.record, %main.record* %r1, i32 0, i32 2
+  %17 = icmp eq %reflect.StringHeader* %16, null
+  br i1 %17, label %gep.throw12, label %gep.next13
+
+gep.throw12:                                      ; preds = %gep.next11
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next13:                                       ; preds = %gep.next11
+  %18 = getelementptr inbounds %reflect.StringHeader, %reflect.StringHeader* %16, i32 0, i32 1
+  %19 = icmp eq i32* %18, null
+  br i1 %19, label %deref.throw14, label %deref.next15
+
+deref.throw14:                                    ; preds = %gep.next13
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+deref.next15:                                     ; preds = %gep.next13
+  %20 = load i32, i32* %18, align 4
+  %21 = sitofp i32 %20 to double
+  %22 = fmul double %21, %scale
+  %abovemin = fcmp ole double 0xC1E0000000000000, %22
+  %belowmax = fcmp ole double %22, 0x41DFFFFFFFC00000
+  %inbounds = and i1 %abovemin, %belowmax
+  %saturated = select i1 %abovemin, i32 2147483647, i32 -2147483648
+  %isnan = fcmp uno double %22, %22
+  %remapped = select i1 %isnan, i32 0, i32 %saturated
+  %normal = fptosi double %22 to i32
+  %23 = select i1 %inbounds, i32 %normal, i32 %remapped
+  %24 = icmp eq i32* %14, null
+  br i1 %24, label %store.throw16, label %store.next17
+
+store.throw16:                                    ; preds = %deref.next15
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+store.next17:                                     ; preds = %deref.next15
+  store i32 %23, i32* %14, align 4
+  %25 = icmp eq %main.record* %r1, null
+  br i1 %25, label %deref.throw18, label %deref.next19
+
+deref.throw18:                                    ; preds = %store.next17
+  call void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+deref.next19:                                     ; preds = %store.next17
+  %26 = load %main.record, %main.record* %r1, align 4
+  %27 = extractvalue %main.record %26, 0
+  %28 = extractvalue %runtime._string %27, 0
+  call void @runtime.trackPointer(i8* %28, i8* undef, i8* null)
+  %29 = extractvalue %runtime._string %27, 1
+  %30 = extractvalue %main.record %26, 1
+  %31 = extractvalue %main.record %26, 2
+  %32 = extractvalue %main.record %26, 3
+  ret %main.record %26
+}
+
+define i32 @divmod(i64 %0, i64 %1) {
+entry:
+  call void @runtime.cabiEnter(i8* undef, i8* null)
+  %2 = call { i64, i64 } @main.divmod(i64 %0, i64 %1)
+  call void @runtime.cabiExit(i8* undef, i8* null)
+  %3 = extractvalue { i64, i64 } %2, 0
+  %4 = extractvalue { i64, i64 } %2, 1
+  store i64 %3, i64* getelementptr inbounds ({ i64, i64 }, { i64, i64 }* @"divmod$retarea", i32 0, i32 0), align 8
+  store i64 %4, i64* getelementptr inbounds ({ i64, i64 }, { i64, i64 }* @"divmod$retarea", i32 0, i32 1), align 8
+  ret i32 ptrtoint ({ i64, i64 }* @"divmod$retarea" to i32)
+}
+
+define i32 @greet(i32 %0, i32 %1) {
+entry:
+  call void @runtime.cabiEnter(i8* undef, i8* null)
+  %2 = inttoptr i32 %0 to i8*
+  %3 = insertvalue %runtime._string undef, i8* %2, 0
+  %4 = insertvalue %runtime._string %3, i32 %1, 1
+  %5 = extractvalue %runtime._string %4, 0
+  %6 = extractvalue %runtime._string %4, 1
+  %7 = call %runtime._string @main.greet(i8* %5, i32 %6)
+  call void @runtime.cabiExit(i8* undef, i8* null)
+  %8 = extractvalue %runtime._string %7, 0
+  %9 = extractvalue %runtime._string %7, 1
+  %10 = ptrtoint i8* %8 to i32
+  store i32 %10, i32* getelementptr inbounds ({ i32, i32 }, { i32, i32 }* @"greet$retarea", i32 0, i32 0), align 4
+  store i32 %9, i32* getelementptr inbounds ({ i32, i32 }, { i32, i32 }* @"greet$retarea", i32 0, i32 1), align 4
+  ret i32 ptrtoint ({ i32, i32 }* @"greet$retarea" to i32)
+}
+
+declare void @"main.hostMirror$import"(i32, i32, i32, i32, i32, i32, i32)
+
+declare void @"main.hostFill$import"(i32, i32, i32, i32)
+
+declare void @"main.hostReverse$import"(i32, i32, i32)
+
+define i32 @sum(i32 %0, i32 %1) {
+entry:
+  call void @runtime.cabiEnter(i8* undef, i8* null)
+  %2 = inttoptr i32 %0 to i32*
+  %3 = insertvalue { i32*, i32, i32 } undef, i32* %2, 0
+  %4 = insertvalue { i32*, i32, i32 } %3, i32 %1, 1
+  %5 = insertvalue { i32*, i32, i32 } %4, i32 %1, 2
+  %6 = extractvalue { i32*, i32, i32 } %5, 0
+  %7 = extractvalue { i32*, i32, i32 } %5, 1
+  %8 = extractvalue { i32*, i32, i32 } %5, 2
+  %9 = call i32 @main.sum(i32* %6, i32 %7, i32 %8)
+  call void @runtime.cabiExit(i8* undef, i8* null)
+  ret i32 %9
+}
+
+define i32 @translate(i32 %0, i32 %1, i32 %2, i32 %3, i32 %4, i32 %5, i32 %6, double %7) {
+entry:
+  call void @runtime.cabiEnter(i8* undef, i8* null)
+  %8 = inttoptr i32 %0 to i8*
+  %9 = insertvalue %runtime._string undef, i8* %8, 0
+  %10 = insertvalue %runtime._string %9, i32 %1, 1
+  %11 = insertvalue %main.record undef, %runtime._string %10, 0
+  %12 = trunc i32 %2 to i8
+  %13 = insertvalue %main.record %11, i8 %12, 1
+  %14 = insertvalue %reflect.StringHeader undef, i32 %3, 0
+  %15 = insertvalue %reflect.StringHeader %14, i32 %4, 1
+  %16 = insertvalue %main.record %13, %reflect.StringHeader %15, 2
+  %17 = icmp ne i32 %5, 0
+  %18 = insertvalue %main.record %16, i1 %17, 3
+  %19 = trunc i32 %6 to i16
+  %20 = call %main.record @main.translate(%main.record %18, i16 %19, double %7)
+  call void @runtime.cabiExit(i8* undef, i8* null)
+  %21 = extractvalue %main.record %20, 0
+  %22 = extractvalue %runtime._string %21, 0
+  %23 = extractvalue %runtime._string %21, 1
+  %24 = ptrtoint i8* %22 to i32
+  %25 = extractvalue %main.record %20, 1
+  %26 = zext i8 %25 to i32
+  %27 = extractvalue %main.record %20, 2
+  %28 = extractvalue %reflect.StringHeader %27, 0
+  %29 = extractvalue %reflect.StringHeader %27, 1
+  %30 = extractvalue %main.record %20, 3
+  %31 = zext i1 %30 to i32
+  store i32 %24, i32* getelementptr inbounds ({ i32, i32, i32, i32, i32, i32 }, { i32, i32, i32, i32, i32, i32 }* @"translate$retarea", i32 0, i32 0), align 4
+  store i32 %23, i32* getelementptr inbounds ({ i32, i32, i32, i32, i32, i32 }, { i32, i32, i32, i32, i32, i32 }* @"translate$retarea", i32 0, i32 1), align 4
+  store i32 %26, i32* getelementptr inbounds ({ i32, i32, i32, i32, i32, i32 }, { i32, i32, i32, i32, i32, i32 }* @"translate$retarea", i32 0, i32 2), align 4
+  store i32 %28, i32* getelementptr inbounds ({ i32, i32, i32, i32, i32, i32 }, { i32, i32, i32, i32, i32, i32 }* @"translate$retarea", i32 0, i32 3), align 4
+  store i32 %29, i32* getelementptr inbounds ({ i32, i32, i32, i32, i32, i32 }, { i32, i32, i32, i32, i32, i32 }* @"translate$retarea", i32 0, i32 4), align 4
+  store i32 %31, i32* getelementptr inbounds ({ i32, i32, i32, i32, i32, i32 }, { i32, i32, i32, i32, i32, i32 }* @"translate$retarea", i32 0, i32 5), align 4
+  ret i32 ptrtoint ({ i32, i32, i32, i32, i32, i32 }* @"translate$retarea" to i32)
+}
+
+define i32 @cabi_realloc(i32 %0, i32 %1, i32 %2, i32 %3) {
+entry:
+  %oldPtr = inttoptr i32 %0 to i8*
+  %4 = call i8* @runtime.cabiRealloc(i8* %oldPtr, i32 %1, i32 %2, i32 %3, i8* undef, i8* null)
+  %5 = ptrtoint i8* %4 to i32
+  ret i32 %5
+}
+
@@ -0,0 +1,33 @@
+package main
+
+// Test program for compiling a program in parts. It contains symbols that are
+// local to the program and may be created in more than one part: type codes,
+// string constants and goroutine wrappers.
+
+type point struct {
+	X, Y int
+}
+
+type notFoundError struct {
+	name string
+}
+
+func (e *notFoundError) Error() string {
+	return e.name + " not found"
+}
+
+var errNotFound error = &notFoundError{"foo"}
+
+var workFn = work
+
+func main() {
+	var p interface{} = point{1, 2}
+	println(p.(point).X)
+	go workFn(3)
+	go work(4)
+	println(errNotFound.Error())
+}
+
+func work(n int) {
+	println("work:", n)
+}
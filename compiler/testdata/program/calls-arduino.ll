@@ -0,0 +1,501 @@
+@"main.shape$interface" = private constant [1 x i8*] [i8* @"func area() int32"]
+@"main.rect$methodset" = private constant [1 x %runtime.interfaceMethodInfo] [%runtime.interfaceMethodInfo { i8* @"func area() int32", i16 ptrtoint (i32 (i8*, i8*, i8*) addrspace(1)* @"(main.rect).area$invoke" to i16) }]
+@"main.main$pack" = private unnamed_addr constant { i32, i8* } { i32 3, i8* undef }
+@"main.makeCounter$1$withSignature" = internal constant %runtime.funcValueWithSignature { i16 ptrtoint (i32 (i8*, i8*) addrspace(1)* @"main.makeCounter$1" to i16), %runtime.typecodeID* @"reflect/types.type:func:{}{basic:int}" }
+@"main.worker$string" = internal unnamed_addr constant [7 x i8] c"worker:"
+
+define internal i64 @main.add64(i64 %a, i64 %b, i8* %context, i8* %parentHandle) unnamed_addr addrspace(1) #2 {
+entry:
+  %0 = add i64 %a, %b
+  ret i64 %0
+}
+
+
+define internal %reflect.StringHeader @main.addPoint(i16 %a.X, i16 %a.Y, i16 %b.X, i16 %b.Y, i8* %context, i8* %parentHandle) unnamed_addr addrspace(1) #2 {
+entry:
+  %complit = alloca %reflect.StringHeader, align 1
+  %b = alloca %reflect.StringHeader, align 1
+  %a = alloca %reflect.StringHeader, align 1
+  %0 = insertvalue %reflect.StringHeader zeroinitializer, i16 %a.X, 0
+  %1 = insertvalue %reflect.StringHeader %0, i16 %a.Y, 1
+  %2 = insertvalue %reflect.StringHeader zeroinitializer, i16 %b.X, 0
+  %3 = insertvalue %reflect.StringHeader %2, i16 %b.Y, 1
+  store %reflect.StringHeader zeroinitializer, %reflect.StringHeader* %a, align 1
+  %4 = icmp eq %reflect.StringHeader* %a, null
+  br i1 %4, label %store.throw, label %store.next
+
+store.throw:                                      ; preds = %entry
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+store.next:                                       ; preds = %entry
+  store %reflect.StringHeader %1, %reflect.StringHeader* %a, align 1
+  store %reflect.StringHeader zeroinitializer, %reflect.StringHeader* %b, align 1
+  %5 = icmp eq %reflect.StringHeader* %b, null
+  br i1 %5, label %store.throw1, label %store.next2
+
+store.throw1:                                     ; preds = %store.next
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+store.next2:                                      ; preds = %store.next
+  store %reflect.StringHeader %3, %reflect.StringHeader* %b, align 1
+  store %reflect.StringHeader zeroinitializer, %reflect.StringHeader* %complit, align 1
+  %6 = icmp eq %reflect.StringHeader* %complit, null
+  br i1 %6, label %gep.throw, label %gep.next
+
+gep.throw:                                        ; preds = %store.next2
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next:                                         ; preds = %store.next2
+  %7 = getelementptr inbounds %reflect.StringHeader, %reflect.StringHeader* %complit, i32 0, i32 0
+  %8 = icmp eq %reflect.StringHeader* %a, null
+  br i1 %8, label %gep.throw3, label %gep.next4
+
+gep.throw3:                                       ; preds = %gep.next
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next4:                                        ; preds = %gep.next
+  %9 = getelementptr inbounds %reflect.StringHeader, %reflect.StringHeader* %a, i32 0, i32 0
+  %10 = icmp eq i16* %9, null
+  br i1 %10, label %deref.throw, label %deref.next
+
+deref.throw:                                      ; preds = %gep.next4
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+deref.next:                                       ; preds = %gep.next4
+  %11 = load i16, i16* %9, align 1
+  %12 = icmp eq %reflect.StringHeader* %b, null
+  br i1 %12, label %gep.throw5, label %gep.next6
+
+gep.throw5:                                       ; preds = %deref.next
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next6:                                        ; preds = %deref.next
+  %13 = getelementptr inbounds %reflect.StringHeader, %reflect.StringHeader* %b, i32 0, i32 0
+  %14 = icmp eq i16* %13, null
+  br i1 %14, label %deref.throw7, label %deref.next8
+
+deref.throw7:                                     ; preds = %gep.next6
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+deref.next8:                                      ; preds = %gep.next6
+  %15 = load i16, i16* %13, align 1
+  %16 = add i16 %11, %15
+  %17 = icmp eq %reflect.StringHeader* %complit, null
+  br i1 %17, label %gep.throw9, label %gep.next10
+
+gep.throw9:                                       ; preds = %deref.next8
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next10:                                       ; preds = %deref.next8
+  %18 = getelementptr inbounds %reflect.StringHeader, %reflect.StringHeader* %complit, i32 0, i32 1
+  %19 = icmp eq %reflect.StringHeader* %a, null
+  br i1 %19, label %gep.throw11, label %gep.next12
+
+gep.throw11:                                      ; preds = %gep.next10
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next12:                                       ; preds = %gep.next10
+  %20 = getelementptr inbounds %reflect.StringHeader, %reflect.StringHeader* %a, i32 0, i32 1
+  %21 = icmp eq i16* %20, null
+  br i1 %21, label %deref.throw13, label %deref.next14
+
+deref.throw13:                                    ; preds = %gep.next12
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+deref.next14:                                     ; preds = %gep.next12
+  %22 = load i16, i16* %20, align 1
+  %23 = icmp eq %reflect.StringHeader* %b, null
+  br i1 %23, label %gep.throw15, label %gep.next16
+
+gep.throw15:                                      ; preds = %deref.next14
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next16:                                       ; preds = %deref.next14
+  %24 = getelementptr inbounds %reflect.StringHeader, %reflect.StringHeader* %b, i32 0, i32 1
+  %25 = icmp eq i16* %24, null
+  br i1 %25, label %deref.throw17, label %deref.next18
+
+deref.throw17:                                    ; preds = %gep.next16
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+deref.next18:                                     ; preds = %gep.next16
+  %26 = load i16, i16* %24, align 1
+  %27 = add i16 %22, %26
+  %28 = icmp eq i16* %7, null
+  br i1 %28, label %store.throw19, label %store.next20
+
+store.throw19:                                    ; preds = %deref.next18
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+store.next20:                                     ; preds = %deref.next18
+  store i16 %16, i16* %7, align 1
+  %29 = icmp eq i16* %18, null
+  br i1 %29, label %store.throw21, label %store.next22
+
+store.throw21:                                    ; preds = %store.next20
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+store.next22:                                     ; preds = %store.next20
+  store i16 %27, i16* %18, align 1
+  %30 = icmp eq %reflect.StringHeader* %complit, null
+  br i1 %30, label %deref.throw23, label %deref.next24
+
+deref.throw23:                                    ; preds = %store.next22
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+deref.next24:                                     ; preds = %store.next22
+  %31 = load %reflect.StringHeader, %reflect.StringHeader* %complit, align 1
+  ret %reflect.StringHeader %31
+}
+
+
+define internal i32 @main.area(i16 %s.typecode, i8* %s.value, i8* %context, i8* %parentHandle) unnamed_addr addrspace(1) #2 {
+entry:
+  %0 = insertvalue %runtime._interface zeroinitializer, i16 %s.typecode, 0
+  %1 = insertvalue %runtime._interface %0, i8* %s.value, 1
+  %invoke.typecode = extractvalue %runtime._interface %1, 0
+  %invoke.func = call addrspace(1) i16 @runtime.interfaceMethod(i16 %invoke.typecode, i8** getelementptr inbounds ([1 x i8*], [1 x i8*]* @"main.shape$interface", i32 0, i32 0), i8* @"func area() int32", i8* undef, i8* null)
+  %invoke.func.cast = inttoptr i16 %invoke.func to i32 (i8*, i8*, i8*) addrspace(1)*
+  %invoke.func.receiver = extractvalue %runtime._interface %1, 1
+  %2 = call addrspace(1) i32 %invoke.func.cast(i8* %invoke.func.receiver, i8* undef, i8* undef)
+  ret i32 %2
+}
+
+
+define internal void @main.init(i8* %context, i8* %parentHandle) unnamed_addr addrspace(1) {
+entry:
+  ret void
+}
+
+
+define internal void @main.main(i8* %context, i8* %parentHandle) addrspace(1) {
+entry:
+  %complit16 = alloca %main.rect, align 1
+  %complit5 = alloca %reflect.StringHeader, align 1
+  %complit = alloca %reflect.StringHeader, align 1
+  %0 = call addrspace(1) i64 @main.add64(i64 3, i64 5, i8* undef, i8* undef)
+  call addrspace(1) void @runtime.printuint64(i64 %0, i8* undef, i8* null)
+  call addrspace(1) void @runtime.printnl(i8* undef, i8* null)
+  store %reflect.StringHeader zeroinitializer, %reflect.StringHeader* %complit, align 1
+  %1 = icmp eq %reflect.StringHeader* %complit, null
+  br i1 %1, label %gep.throw, label %gep.next
+
+gep.throw:                                        ; preds = %entry
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next:                                         ; preds = %entry
+  %2 = getelementptr inbounds %reflect.StringHeader, %reflect.StringHeader* %complit, i32 0, i32 0
+  %3 = icmp eq %reflect.StringHeader* %complit, null
+  br i1 %3, label %gep.throw1, label %gep.next2
+
+gep.throw1:                                       ; preds = %gep.next
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next2:                                        ; preds = %gep.next
+  %4 = getelementptr inbounds %reflect.StringHeader, %reflect.StringHeader* %complit, i32 0, i32 1
+  %5 = icmp eq i16* %2, null
+  br i1 %5, label %store.throw, label %store.next
+
+store.throw:                                      ; preds = %gep.next2
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+store.next:                                       ; preds = %gep.next2
+  store i16 1, i16* %2, align 1
+  %6 = icmp eq i16* %4, null
+  br i1 %6, label %store.throw3, label %store.next4
+
+store.throw3:                                     ; preds = %store.next
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+store.next4:                                      ; preds = %store.next
+  store i16 2, i16* %4, align 1
+  %7 = icmp eq %reflect.StringHeader* %complit, null
+  br i1 %7, label %deref.throw, label %deref.next
+
+deref.throw:                                      ; preds = %store.next4
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+deref.next:                                       ; preds = %store.next4
+  %8 = load %reflect.StringHeader, %reflect.StringHeader* %complit, align 1
+  store %reflect.StringHeader zeroinitializer, %reflect.StringHeader* %complit5, align 1
+  %9 = icmp eq %reflect.StringHeader* %complit5, null
+  br i1 %9, label %gep.throw6, label %gep.next7
+
+gep.throw6:                                       ; preds = %deref.next
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next7:                                        ; preds = %deref.next
+  %10 = getelementptr inbounds %reflect.StringHeader, %reflect.StringHeader* %complit5, i32 0, i32 0
+  %11 = icmp eq %reflect.StringHeader* %complit5, null
+  br i1 %11, label %gep.throw8, label %gep.next9
+
+gep.throw8:                                       ; preds = %gep.next7
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next9:                                        ; preds = %gep.next7
+  %12 = getelementptr inbounds %reflect.StringHeader, %reflect.StringHeader* %complit5, i32 0, i32 1
+  %13 = icmp eq i16* %10, null
+  br i1 %13, label %store.throw10, label %store.next11
+
+store.throw10:                                    ; preds = %gep.next9
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+store.next11:                                     ; preds = %gep.next9
+  store i16 3, i16* %10, align 1
+  %14 = icmp eq i16* %12, null
+  br i1 %14, label %store.throw12, label %store.next13
+
+store.throw12:                                    ; preds = %store.next11
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+store.next13:                                     ; preds = %store.next11
+  store i16 4, i16* %12, align 1
+  %15 = icmp eq %reflect.StringHeader* %complit5, null
+  br i1 %15, label %deref.throw14, label %deref.next15
+
+deref.throw14:                                    ; preds = %store.next13
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+deref.next15:                                     ; preds = %store.next13
+  %16 = load %reflect.StringHeader, %reflect.StringHeader* %complit5, align 1
+  %17 = extractvalue %reflect.StringHeader %8, 0
+  %18 = extractvalue %reflect.StringHeader %8, 1
+  %19 = extractvalue %reflect.StringHeader %16, 0
+  %20 = extractvalue %reflect.StringHeader %16, 1
+  %21 = call addrspace(1) %reflect.StringHeader @main.addPoint(i16 %17, i16 %18, i16 %19, i16 %20, i8* undef, i8* undef)
+  %22 = extractvalue %reflect.StringHeader %21, 0
+  call addrspace(1) void @runtime.printint16(i16 %22, i8* undef, i8* null)
+  call addrspace(1) void @runtime.printnl(i8* undef, i8* null)
+  store %main.rect zeroinitializer, %main.rect* %complit16, align 1
+  %23 = icmp eq %main.rect* %complit16, null
+  br i1 %23, label %gep.throw17, label %gep.next18
+
+gep.throw17:                                      ; preds = %deref.next15
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next18:                                       ; preds = %deref.next15
+  %24 = getelementptr inbounds %main.rect, %main.rect* %complit16, i32 0, i32 0
+  %25 = icmp eq %reflect.StringHeader* %24, null
+  br i1 %25, label %gep.throw19, label %gep.next20
+
+gep.throw19:                                      ; preds = %gep.next18
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next20:                                       ; preds = %gep.next18
+  %26 = getelementptr inbounds %reflect.StringHeader, %reflect.StringHeader* %24, i32 0, i32 0
+  %27 = icmp eq %reflect.StringHeader* %24, null
+  br i1 %27, label %gep.throw21, label %gep.next22
+
+gep.throw21:                                      ; preds = %gep.next20
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next22:                                       ; preds = %gep.next20
+  %28 = getelementptr inbounds %reflect.StringHeader, %reflect.StringHeader* %24, i32 0, i32 1
+  %29 = icmp eq %main.rect* %complit16, null
+  br i1 %29, label %gep.throw23, label %gep.next24
+
+gep.throw23:                                      ; preds = %gep.next22
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next24:                                       ; preds = %gep.next22
+  %30 = getelementptr inbounds %main.rect, %main.rect* %complit16, i32 0, i32 1
+  %31 = icmp eq %reflect.StringHeader* %30, null
+  br i1 %31, label %gep.throw25, label %gep.next26
+
+gep.throw25:                                      ; preds = %gep.next24
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next26:                                       ; preds = %gep.next24
+  %32 = getelementptr inbounds %reflect.StringHeader, %reflect.StringHeader* %30, i32 0, i32 0
+  %33 = icmp eq %reflect.StringHeader* %30, null
+  br i1 %33, label %gep.throw27, label %gep.next28
+
+gep.throw27:                                      ; preds = %gep.next26
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+gep.next28:                                       ; preds = %gep.next26
+  %34 = getelementptr inbounds %reflect.StringHeader, %reflect.StringHeader* %30, i32 0, i32 1
+  %35 = icmp eq i16* %26, null
+  br i1 %35, label %store.throw29, label %store.next30
+
+store.throw29:                                    ; preds = %gep.next28
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+store.next30:                                     ; preds = %gep.next28
+  store i16 0, i16* %26, align 1
+  %36 = icmp eq i16* %28, null
+  br i1 %36, label %store.throw31, label %store.next32
+
+store.throw31:                                    ; preds = %store.next30
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+store.next32:                                     ; preds = %store.next30
+  store i16 0, i16* %28, align 1
+  %37 = icmp eq i16* %32, null
+  br i1 %37, label %store.throw33, label %store.next34
+
+store.throw33:                                    ; preds = %store.next32
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+store.next34:                                     ; preds = %store.next32
+  store i16 3, i16* %32, align 1
+  %38 = icmp eq i16* %34, null
+  br i1 %38, label %store.throw35, label %store.next36
+
+store.throw35:                                    ; preds = %store.next34
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+store.next36:                                     ; preds = %store.next34
+  store i16 4, i16* %34, align 1
+  %39 = icmp eq %main.rect* %complit16, null
+  br i1 %39, label %deref.throw37, label %deref.next38
+
+deref.throw37:                                    ; preds = %store.next36
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+deref.next38:                                     ; preds = %store.next36
+  %40 = load %main.rect, %main.rect* %complit16, align 1
+  %41 = call addrspace(1) i8* @runtime.alloc(i16 8, i8* undef, i8* null)
+  %42 = bitcast i8* %41 to { %main.rect }*
+  %43 = getelementptr inbounds { %main.rect }, { %main.rect }* %42, i32 0, i32 0
+  store %main.rect %40, %main.rect* %43, align 1
+  %44 = insertvalue %runtime._interface { i16 ptrtoint (%runtime.typeInInterface* @"typeInInterface:reflect/types.type:named:main.rect" to i16), i8* undef }, i8* %41, 1
+  %45 = extractvalue %runtime._interface %44, 0
+  %46 = extractvalue %runtime._interface %44, 1
+  %47 = call addrspace(1) i32 @main.area(i16 %45, i8* %46, i8* undef, i8* undef)
+  call addrspace(1) void @runtime.printint32(i32 %47, i8* undef, i8* null)
+  call addrspace(1) void @runtime.printnl(i8* undef, i8* null)
+  %48 = call addrspace(1) %runtime.interfaceMethodInfo @main.makeCounter(i8* undef, i8* undef)
+  %49 = extractvalue %runtime.interfaceMethodInfo %48, 0
+  %50 = extractvalue %runtime.interfaceMethodInfo %48, 0
+  %51 = extractvalue %runtime.interfaceMethodInfo %48, 1
+  %52 = call addrspace(1) i16 @runtime.getFuncPtr(i8* %50, i16 %51, %runtime.typecodeID* @"reflect/types.type:func:{}{basic:int}", i8* undef, i8* null)
+  %53 = inttoptr i16 %52 to i32 (i8*, i8*) addrspace(1)*
+  %54 = icmp eq i32 (i8*, i8*) addrspace(1)* %53, null
+  br i1 %54, label %fpcall.throw, label %fpcall.next
+
+fpcall.throw:                                     ; preds = %deref.next38
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+fpcall.next:                                      ; preds = %deref.next38
+  %55 = call addrspace(1) i32 %53(i8* %49, i8* undef)
+  call addrspace(1) void @runtime.printint32(i32 %55, i8* undef, i8* null)
+  call addrspace(1) void @runtime.printnl(i8* undef, i8* null)
+  call addrspace(1) void @"internal/task.start"(i16 ptrtoint (void (i8*) addrspace(1)* @"main.worker$gowrapper" to i16), i8* bitcast ({ i32, i8* }* @"main.main$pack" to i8*), i16 256, i8* undef, i8* null)
+  ret void
+}
+
+
+define internal %runtime.interfaceMethodInfo @main.makeCounter(i8* %context, i8* %parentHandle) unnamed_addr addrspace(1) {
+entry:
+  %n = call addrspace(1) i8* @runtime.alloc(i16 4, i8* undef, i8* null)
+  %0 = bitcast i8* %n to i32*
+  %1 = icmp eq i32* %0, null
+  br i1 %1, label %store.throw, label %store.next
+
+store.throw:                                      ; preds = %entry
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+store.next:                                       ; preds = %entry
+  store i32 0, i32* %0, align 1
+  %pack.ptr = bitcast i32* %0 to i8*
+  %2 = insertvalue %runtime.interfaceMethodInfo undef, i8* %pack.ptr, 0
+  %3 = insertvalue %runtime.interfaceMethodInfo %2, i16 ptrtoint (%runtime.funcValueWithSignature* @"main.makeCounter$1$withSignature" to i16), 1
+  ret %runtime.interfaceMethodInfo %3
+}
+
+
+define internal void @"main.worker$gowrapper"(i8* %0) unnamed_addr addrspace(1) #6 {
+entry:
+  %unpack.raw.ptr = bitcast i8* %0 to { i32, i8* }*
+  %1 = getelementptr inbounds { i32, i8* }, { i32, i8* }* %unpack.raw.ptr, i32 0, i32 0
+  %2 = load i32, i32* %1, align 1
+  %3 = getelementptr inbounds { i32, i8* }, { i32, i8* }* %unpack.raw.ptr, i32 0, i32 1
+  %4 = load i8*, i8** %3, align 1
+  call addrspace(1) void @main.worker(i32 %2, i8* %4, i8* undef)
+  ret void
+}
+
+
+define internal void @main.worker(i32 %n, i8* %context, i8* %parentHandle) unnamed_addr addrspace(1) {
+entry:
+  call addrspace(1) void @runtime.printstring(i8* getelementptr inbounds ([7 x i8], [7 x i8]* @"main.worker$string", i32 0, i32 0), i16 7, i8* undef, i8* null)
+  call addrspace(1) void @runtime.printspace(i8* undef, i8* null)
+  call addrspace(1) void @runtime.printint32(i32 %n, i8* undef, i8* null)
+  call addrspace(1) void @runtime.printnl(i8* undef, i8* null)
+  ret void
+}
+
+
+define internal i32 @"main.makeCounter$1"(i8* %context, i8* %parentHandle) unnamed_addr addrspace(1) {
+entry:
+  %unpack.ptr = bitcast i8* %context to i32*
+  %0 = icmp eq i32* %unpack.ptr, null
+  br i1 %0, label %deref.throw, label %deref.next
+
+deref.throw:                                      ; preds = %entry
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+deref.next:                                       ; preds = %entry
+  %1 = load i32, i32* %unpack.ptr, align 1
+  %2 = add i32 %1, 1
+  %3 = icmp eq i32* %unpack.ptr, null
+  br i1 %3, label %store.throw, label %store.next
+
+store.throw:                                      ; preds = %deref.next
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+store.next:                                       ; preds = %deref.next
+  store i32 %2, i32* %unpack.ptr, align 1
+  %4 = icmp eq i32* %unpack.ptr, null
+  br i1 %4, label %deref.throw1, label %deref.next2
+
+deref.throw1:                                     ; preds = %store.next
+  call addrspace(1) void @runtime.nilPanic(i8* undef, i8* null)
+  unreachable
+
+deref.next2:                                      ; preds = %store.next
+  %5 = load i32, i32* %unpack.ptr, align 1
+  ret i32 %5
+}
+
@@ -0,0 +1,56 @@
+package main
+
+// Test calling conventions and lowering of common operations for each target:
+// integer arithmetic of different widths, structs passed by value, interfaces,
+// closures and goroutines.
+
+type point struct {
+	X, Y int16
+}
+
+type shape interface {
+	area() int32
+}
+
+type rect struct {
+	min, max point
+}
+
+func (r rect) area() int32 {
+	return int32(r.max.X-r.min.X) * int32(r.max.Y-r.min.Y)
+}
+
+func main() {
+	println(add64(3, 5))
+	println(addPoint(point{1, 2}, point{3, 4}).X)
+	println(area(rect{point{0, 0}, point{3, 4}}))
+	println(makeCounter()())
+	go worker(3)
+}
+
+//go:noinline
+func add64(a, b uint64) uint64 {
+	return a + b
+}
+
+//go:noinline
+func addPoint(a, b point) point {
+	return point{a.X + b.X, a.Y + b.Y}
+}
+
+//go:noinline
+func area(s shape) int32 {
+	return s.area()
+}
+
+func makeCounter() func() int {
+	n := 0
+	return func() int {
+		n++
+		return n
+	}
+}
+
+func worker(n int) {
+	println("worker:", n)
+}
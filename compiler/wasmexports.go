@@ -0,0 +1,97 @@
+package compiler
+
+// This file records the Go signature of functions exported from a WebAssembly
+// module, so that the builder can generate a JavaScript loader with typed
+// wrappers (and TypeScript declarations) for them.
+
+import (
+	"encoding/json"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+	"tinygo.org/x/go-llvm"
+)
+
+// Name of the function attribute that stores the signature of a regular
+// (non-canonical) exported function, in JSON form.
+const wasmExportAttr = "tinygo-wasm-export"
+
+// WasmExport describes a function that is exported from a WebAssembly module.
+type WasmExport struct {
+	Name      string
+	Params    []WasmValue
+	Results   []WasmValue
+	Canonical bool // exported using -wasm-abi=canonical
+}
+
+// WasmValue describes a parameter or result of an exported function. The type
+// is a type name as used in the canonical ABI descriptor, such as "s32",
+// "string" or "list<u8>", or "record" for structs.
+type WasmValue struct {
+	Name string
+	Type string
+}
+
+// addWasmExportSignature stores the signature of a regular exported function,
+// if it can be described. Exports from the runtime (such as _start and
+// resume) are internal and are skipped.
+func (c *compilerContext) addWasmExportSignature(fn *ssa.Function, info functionInfo, llvmFn llvm.Value) {
+	if !strings.HasPrefix(c.Triple, "wasm") || info.module != "" || strings.HasPrefix(fn.Name(), "C.") {
+		return
+	}
+	if fn.Pkg != nil && fn.Pkg.Pkg == c.runtimePkg {
+		return
+	}
+	for _, tuple := range []*types.Tuple{fn.Signature.Params(), fn.Signature.Results()} {
+		for i := 0; i < tuple.Len(); i++ {
+			if _, msg := c.canonicalFlatTypes(tuple.At(i).Type()); msg != "" {
+				return
+			}
+		}
+	}
+	c.addSignatureAttr(llvmFn, wasmExportAttr, "", info.linkName, fn.Signature)
+}
+
+// WasmExports returns all exported functions in the module of which the
+// signature is known, in the order in which they appear in the module.
+func WasmExports(mod llvm.Module) []WasmExport {
+	var exports []WasmExport
+	for fn := mod.FirstFunction(); !fn.IsNil(); fn = llvm.NextFunction(fn) {
+		canonical := true
+		attr := fn.GetStringAttributeAtIndex(-1, canonicalExportAttr)
+		if attr.IsNil() {
+			canonical = false
+			attr = fn.GetStringAttributeAtIndex(-1, wasmExportAttr)
+		}
+		if attr.IsNil() || fn.IsDeclaration() {
+			continue
+		}
+		var desc canonicalSignature
+		if err := json.Unmarshal([]byte(attr.GetStringValue()), &desc); err != nil {
+			panic("could not decode export signature: " + err.Error())
+		}
+		export := WasmExport{
+			Name:      desc.Name,
+			Canonical: canonical,
+		}
+		for _, param := range desc.Params {
+			export.Params = append(export.Params, wasmValue(param))
+		}
+		for _, result := range desc.Results {
+			export.Results = append(export.Results, wasmValue(result))
+		}
+		exports = append(exports, export)
+	}
+	return exports
+}
+
+// wasmValue converts a decoded parameter or result description to a
+// WasmValue.
+func wasmValue(field canonicalField) WasmValue {
+	typ, ok := field.Type.(string)
+	if !ok {
+		typ = "record"
+	}
+	return WasmValue{Name: field.Name, Type: typ}
+}
@@ -0,0 +1,169 @@
+// Package flash implements a few simple bootloader protocols that are commonly
+// used to flash microcontrollers over a serial port. They replace external
+// tools like bossac and avrdude for the most common boards.
+package flash
+
+import (
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/marcinbor85/gohex"
+)
+
+// Segment is a single contiguous block of memory in a firmware image.
+type Segment struct {
+	Address uint32
+	Data    []byte
+}
+
+// LoadHex reads an Intel hex file (as produced by the builder package) and
+// returns all data segments in it, sorted by address.
+func LoadHex(path string) ([]Segment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	mem := gohex.NewMemory()
+	err = mem.ParseIntelHex(f)
+	if err != nil {
+		return nil, err
+	}
+	var segments []Segment
+	for _, seg := range mem.GetDataSegments() {
+		segments = append(segments, Segment{
+			Address: seg.Address,
+			Data:    seg.Data,
+		})
+	}
+	if len(segments) == 0 {
+		return nil, errors.New("firmware image is empty: " + path)
+	}
+	return segments, nil
+}
+
+// errNoImage is returned when there is nothing to flash.
+var errNoImage = errors.New("no firmware image to flash")
+
+// padPage pads the data with 0xff bytes (erased flash) to a multiple of the
+// page size. The original slice is not modified.
+func padPage(data []byte, pageSize int) []byte {
+	rest := len(data) % pageSize
+	if rest == 0 {
+		return data
+	}
+	padded := make([]byte, len(data)+pageSize-rest)
+	copy(padded, data)
+	for i := len(data); i < len(padded); i++ {
+		padded[i] = 0xff
+	}
+	return padded
+}
+
+// errTimeout is returned when the bootloader did not respond in time.
+var errTimeout = errors.New("timeout while waiting for bootloader response")
+
+// port wraps a serial port (or anything that looks like one) to allow reads
+// with a timeout. Serial ports usually don't support read deadlines, so a
+// separate goroutine reads all incoming data and passes it on over a channel.
+// This goroutine exits once the underlying port is closed.
+type port struct {
+	w        io.Writer
+	incoming chan portData
+	buf      []byte
+	err      error
+}
+
+type portData struct {
+	data []byte
+	err  error
+}
+
+func newPort(rw io.ReadWriter) *port {
+	p := &port{
+		w:        rw,
+		incoming: make(chan portData, 16),
+	}
+	go func() {
+		for {
+			buf := make([]byte, 512)
+			n, err := rw.Read(buf)
+			if n != 0 {
+				p.incoming <- portData{data: buf[:n]}
+			}
+			if err != nil {
+				p.incoming <- portData{err: err}
+				close(p.incoming)
+				return
+			}
+		}
+	}()
+	return p
+}
+
+// Write sends the given bytes to the bootloader.
+func (p *port) Write(buf []byte) error {
+	_, err := p.w.Write(buf)
+	return err
+}
+
+// read returns exactly n bytes, or an error when these bytes did not arrive
+// within the timeout.
+func (p *port) read(n int, timeout time.Duration) ([]byte, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for len(p.buf) < n {
+		if p.err != nil {
+			return nil, p.err
+		}
+		select {
+		case msg, ok := <-p.incoming:
+			if !ok {
+				return nil, io.ErrUnexpectedEOF
+			}
+			p.buf = append(p.buf, msg.data...)
+			p.err = msg.err
+		case <-timer.C:
+			return nil, errTimeout
+		}
+	}
+	buf := p.buf[:n]
+	p.buf = p.buf[n:]
+	return buf, nil
+}
+
+// readUntil reads data until the given suffix is found, and returns all data
+// including the suffix.
+func (p *port) readUntil(suffix string, timeout time.Duration) ([]byte, error) {
+	var buf []byte
+	for {
+		b, err := p.read(1, timeout)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, b[0])
+		if len(buf) >= len(suffix) && string(buf[len(buf)-len(suffix):]) == suffix {
+			return buf, nil
+		}
+	}
+}
+
+// drain discards all data that has been received but not yet read, for
+// example garbage sent by a bootloader while it was starting.
+func (p *port) drain() {
+	p.buf = nil
+	for {
+		select {
+		case msg, ok := <-p.incoming:
+			if !ok {
+				return
+			}
+			p.err = msg.err
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,291 @@
+// +build linux
+
+package flash
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPTY opens a new pseudo-terminal in raw mode. The master side is used by
+// the fake bootloader, the slave side is used as the serial port.
+func openPTY(t *testing.T) (master, slave *os.File) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Skip("could not open pseudo-terminal:", err)
+	}
+	err = unix.IoctlSetPointerInt(int(master.Fd()), unix.TIOCSPTLCK, 0)
+	if err != nil {
+		t.Fatal("could not unlock pseudo-terminal:", err)
+	}
+	n, err := unix.IoctlGetInt(int(master.Fd()), unix.TIOCGPTN)
+	if err != nil {
+		t.Fatal("could not get pseudo-terminal number:", err)
+	}
+	slave, err = os.OpenFile("/dev/pts/"+strconv.Itoa(n), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Fatal("could not open pseudo-terminal slave:", err)
+	}
+
+	// Put the terminal in raw mode, like a real serial port would be.
+	termios, err := unix.IoctlGetTermios(int(slave.Fd()), unix.TCGETS)
+	if err != nil {
+		t.Fatal("could not read terminal settings:", err)
+	}
+	termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	termios.Oflag &^= unix.OPOST
+	termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cflag &^= unix.CSIZE | unix.PARENB
+	termios.Cflag |= unix.CS8
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+	err = unix.IoctlSetTermios(int(slave.Fd()), unix.TCSETS, termios)
+	if err != nil {
+		t.Fatal("could not set terminal settings:", err)
+	}
+	return master, slave
+}
+
+// readByte reads a single byte from the given reader.
+func readByte(r io.Reader) (byte, error) {
+	buf := make([]byte, 1)
+	_, err := io.ReadFull(r, buf)
+	return buf[0], err
+}
+
+// fakeSAMBA emulates a SAM-BA bootloader with the Arduino extensions on an
+// atsamd21 chip with 256kB of flash and 64-byte pages.
+type fakeSAMBA struct {
+	flash  [256 * 1024]byte
+	ram    map[uint32][]byte
+	buffer uint32
+	reset  bool
+}
+
+func (b *fakeSAMBA) run(rw io.ReadWriter) error {
+	for i := range b.flash {
+		b.flash[i] = 0xff
+	}
+	b.ram = make(map[uint32][]byte)
+	for {
+		// Read a command, which is terminated by '#'.
+		var cmd []byte
+		for {
+			c, err := readByte(rw)
+			if err != nil {
+				return err
+			}
+			if c == '#' {
+				break
+			}
+			cmd = append(cmd, c)
+		}
+		var args []uint64
+		if len(cmd) > 1 {
+			for _, arg := range strings.Split(string(cmd[1:]), ",") {
+				n, err := strconv.ParseUint(arg, 16, 32)
+				if err != nil {
+					return err
+				}
+				args = append(args, n)
+			}
+		}
+		var response []byte
+		switch cmd[0] {
+		case 'N':
+			response = []byte("\n\r")
+		case 'V':
+			response = []byte("v1.1 [Arduino:XYZ] Mar  5 2016 17:42:37\n\r")
+		case 'w':
+			if args[0] != sambaNVMParamAddr {
+				return fmt.Errorf("unexpected read at 0x%x", args[0])
+			}
+			response = make([]byte, 4)
+			binary.LittleEndian.PutUint32(response, 3<<16|4096) // 64-byte pages
+		case 'S':
+			data := make([]byte, args[1])
+			_, err := io.ReadFull(rw, data)
+			if err != nil {
+				return err
+			}
+			b.ram[uint32(args[0])] = data
+		case 'X':
+			for i := args[0]; i < uint64(len(b.flash)); i++ {
+				b.flash[i] = 0xff
+			}
+			response = []byte("X\n\r")
+		case 'Y':
+			if args[1] == 0 {
+				b.buffer = uint32(args[0])
+			} else {
+				data := b.ram[b.buffer]
+				if uint64(len(data)) < args[1] || args[1]%64 != 0 {
+					return fmt.Errorf("invalid write size: %d", args[1])
+				}
+				copy(b.flash[args[0]:], data[:args[1]])
+			}
+			response = []byte("Y\n\r")
+		case 'Z':
+			crc := crc16(b.flash[args[0] : args[0]+args[1]])
+			response = []byte(fmt.Sprintf("Z%08X#\n\r", crc))
+		case 'W':
+			if args[0] == 0xE000ED0C && args[1] == 0x05FA0004 {
+				b.reset = true
+				return nil
+			}
+		default:
+			return fmt.Errorf("unknown command: %q", cmd)
+		}
+		_, err := rw.Write(response)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// fakeOptiboot emulates the Optiboot bootloader running on an atmega328p.
+type fakeOptiboot struct {
+	flash   [32 * 1024]byte
+	address int
+	done    bool
+}
+
+func (b *fakeOptiboot) run(rw io.ReadWriter) error {
+	for {
+		cmd, err := readByte(rw)
+		if err != nil {
+			return err
+		}
+		// Number of parameter bytes, excluding CRC_EOP.
+		var params []byte
+		switch cmd {
+		case stkLoadAddress:
+			params = make([]byte, 2)
+		case stkProgPage, stkReadPage:
+			params = make([]byte, 3)
+		}
+		_, err = io.ReadFull(rw, params)
+		if err != nil {
+			return err
+		}
+		var data []byte
+		if cmd == stkProgPage {
+			data = make([]byte, int(params[0])<<8|int(params[1]))
+			_, err = io.ReadFull(rw, data)
+			if err != nil {
+				return err
+			}
+		}
+		eop, err := readByte(rw)
+		if err != nil {
+			return err
+		}
+		if eop != stkCRCEOP {
+			return fmt.Errorf("expected CRC_EOP, got 0x%02x", eop)
+		}
+		response := []byte{stkInSync}
+		switch cmd {
+		case stkGetSync, stkEnterProgmode:
+		case stkReadSign:
+			response = append(response, 0x1e, 0x95, 0x0f)
+		case stkLoadAddress:
+			b.address = (int(params[0]) | int(params[1])<<8) * 2
+		case stkProgPage:
+			if len(data) != 128 {
+				return fmt.Errorf("expected a full page, got %d bytes", len(data))
+			}
+			copy(b.flash[b.address:], data)
+		case stkReadPage:
+			size := int(params[0])<<8 | int(params[1])
+			response = append(response, b.flash[b.address:b.address+size]...)
+		case stkLeaveProgmode:
+			b.done = true
+		default:
+			return fmt.Errorf("unknown command: 0x%02x", cmd)
+		}
+		response = append(response, stkOK)
+		_, err = rw.Write(response)
+		if err != nil {
+			return err
+		}
+		if b.done {
+			return nil
+		}
+	}
+}
+
+// testImage returns a firmware image of the given size with some non-trivial
+// contents.
+func testImage(address uint32, size int) []Segment {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	return []Segment{{Address: address, Data: data}}
+}
+
+func TestSAMBA(t *testing.T) {
+	master, slave := openPTY(t)
+	defer master.Close()
+	defer slave.Close()
+
+	bootloader := &fakeSAMBA{}
+	result := make(chan error, 1)
+	go func() {
+		result <- bootloader.run(master)
+	}()
+
+	// Use a size that needs multiple blocks and padding of the last page.
+	image := testImage(0x2000, 5000)
+	err := SAMBA(slave, image)
+	if err != nil {
+		t.Fatal("failed to flash:", err)
+	}
+	if err := <-result; err != nil {
+		t.Fatal("bootloader failed:", err)
+	}
+	if !bootloader.reset {
+		t.Error("chip was not reset after flashing")
+	}
+	if !bytes.Equal(bootloader.flash[0x2000:0x2000+5000], image[0].Data) {
+		t.Error("flash contents do not match the firmware image")
+	}
+}
+
+func TestSTK500v1(t *testing.T) {
+	master, slave := openPTY(t)
+	defer master.Close()
+	defer slave.Close()
+
+	bootloader := &fakeOptiboot{}
+	result := make(chan error, 1)
+	go func() {
+		result <- bootloader.run(master)
+	}()
+
+	image := testImage(0, 1000)
+	err := STK500v1(slave, image, "atmega328p")
+	if err != nil {
+		t.Fatal("failed to flash:", err)
+	}
+	if err := <-result; err != nil {
+		t.Fatal("bootloader failed:", err)
+	}
+	if !bytes.Equal(bootloader.flash[:1000], image[0].Data) {
+		t.Error("flash contents do not match the firmware image")
+	}
+
+	// Chips that are not known to use Optiboot must be rejected.
+	err = STK500v1(bytes.NewBuffer(nil), image, "attiny85")
+	if err == nil || err.Error() != "stk500: unsupported chip: attiny85" {
+		t.Error("unexpected error for unsupported chip:", err)
+	}
+}
@@ -0,0 +1,254 @@
+package flash
+
+// This file implements the SAM-BA protocol, as used by the bootloaders on
+// Arduino and Adafruit boards with an atsamd21 or atsamd51 chip. It works like
+// `bossac -e -w -v -R`.
+//
+// Only bootloaders that implement the Arduino extensions to SAM-BA (the X, Y
+// and Z commands) are supported. Those bootloaders can erase and program flash
+// themselves so that no applet needs to be uploaded to the chip first.
+//
+// Protocol documentation, as far as it exists:
+// https://github.com/shumatech/BOSSA/blob/master/src/Samba.cpp
+// https://github.com/arduino/ArduinoCore-samd/blob/master/bootloaders/zero/sam_ba_monitor.c
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Address of the NVMCTRL PARAM register on atsamd21 and atsamd51 chips.
+	// It contains the page size and the number of pages.
+	sambaNVMParamAddr = 0x41004008
+
+	// RAM buffer used to transfer data to the bootloader before it is written
+	// to flash. It must not overlap with the memory used by the bootloader
+	// itself, which lives at the start and end of RAM.
+	sambaBufferAddr = 0x20005000
+	sambaBufferSize = 4096
+
+	sambaTimeout = time.Second
+)
+
+// SAMBA flashes the given firmware image using the SAM-BA protocol. The
+// bootloader must already be running, usually this means the serial port must
+// first be touched at 1200 baud.
+func SAMBA(rw io.ReadWriter, image []Segment) error {
+	if len(image) == 0 {
+		return errNoImage
+	}
+	p := &sambaPort{newPort(rw)}
+
+	// Switch to binary mode. The bootloader may have been left in terminal
+	// mode, so discard any data sent before this command.
+	err := p.command("N#")
+	if err != nil {
+		return err
+	}
+	_, err = p.read(2, sambaTimeout)
+	if err != nil {
+		return fmt.Errorf("samba: could not switch to binary mode: %w", err)
+	}
+	p.drain()
+
+	// Check whether this bootloader supports the required extensions.
+	version, err := p.version()
+	if err != nil {
+		return err
+	}
+	if !sambaSupportsExtensions(version, "XYZ") {
+		return fmt.Errorf("samba: bootloader does not support the Arduino extensions: %s", version)
+	}
+
+	// Read the flash geometry.
+	param, err := p.readWord(sambaNVMParamAddr)
+	if err != nil {
+		return err
+	}
+	pageSize := uint32(8 << ((param >> 16) & 7))
+	numPages := param & 0xffff
+	flashSize := pageSize * numPages
+	last := image[len(image)-1]
+	if last.Address+uint32(len(last.Data)) > flashSize {
+		return fmt.Errorf("samba: firmware image (ends at 0x%x) does not fit in flash (%d bytes)", last.Address+uint32(len(last.Data)), flashSize)
+	}
+
+	// Erase all flash, starting at the first address in the image. The
+	// bootloader itself is before this address and thus won't be erased.
+	err = p.commandResponse(fmt.Sprintf("X%08X#", image[0].Address), "X\n\r", 30*time.Second)
+	if err != nil {
+		return fmt.Errorf("samba: could not erase flash: %w", err)
+	}
+
+	// Write the firmware image in blocks, and verify the written data.
+	for _, segment := range image {
+		if segment.Address%pageSize != 0 {
+			return fmt.Errorf("samba: segment at 0x%x is not aligned to a page boundary", segment.Address)
+		}
+		for offset := 0; offset < len(segment.Data); offset += sambaBufferSize {
+			block := segment.Data[offset:]
+			if len(block) > sambaBufferSize {
+				block = block[:sambaBufferSize]
+			}
+			block = padPage(block, int(pageSize))
+			addr := segment.Address + uint32(offset)
+			err := p.writeFlash(addr, block)
+			if err != nil {
+				return err
+			}
+			crc, err := p.checksum(addr, uint32(len(block)))
+			if err != nil {
+				return err
+			}
+			if expected := crc16(block); crc != expected {
+				return fmt.Errorf("samba: verification failed at 0x%x: expected checksum 0x%04x, got 0x%04x", addr, expected, crc)
+			}
+		}
+	}
+
+	// Reset the chip by writing to the AIRCR register (SYSRESETREQ). The
+	// bootloader won't respond to this command.
+	return p.command(fmt.Sprintf("W%08X,%08X#", 0xE000ED0C, 0x05FA0004))
+}
+
+// sambaPort is a serial port connected to a SAM-BA bootloader.
+type sambaPort struct {
+	*port
+}
+
+// command sends a single command to the bootloader without waiting for a
+// response.
+func (p *sambaPort) command(cmd string) error {
+	err := p.Write([]byte(cmd))
+	if err != nil {
+		return fmt.Errorf("samba: could not send command %#v: %w", cmd, err)
+	}
+	return nil
+}
+
+// commandResponse sends a single command and waits for the given response.
+func (p *sambaPort) commandResponse(cmd, response string, timeout time.Duration) error {
+	err := p.command(cmd)
+	if err != nil {
+		return err
+	}
+	buf, err := p.read(len(response), timeout)
+	if err != nil {
+		return fmt.Errorf("samba: no response to command %#v: %w", cmd, err)
+	}
+	if string(buf) != response {
+		return fmt.Errorf("samba: unexpected response to command %#v: %#v", cmd, string(buf))
+	}
+	return nil
+}
+
+// version returns the version string of the bootloader, for example:
+//     v1.1 [Arduino:XYZ] Mar  5 2016 17:42:37
+func (p *sambaPort) version() (string, error) {
+	err := p.command("V#")
+	if err != nil {
+		return "", err
+	}
+	buf, err := p.readUntil("\n\r", sambaTimeout)
+	if err != nil {
+		return "", fmt.Errorf("samba: could not read bootloader version: %w", err)
+	}
+	return strings.TrimSpace(string(buf)), nil
+}
+
+// readWord reads a single 32-bit word from the given address.
+func (p *sambaPort) readWord(addr uint32) (uint32, error) {
+	err := p.command(fmt.Sprintf("w%08X,4#", addr))
+	if err != nil {
+		return 0, err
+	}
+	buf, err := p.read(4, sambaTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("samba: could not read word at 0x%x: %w", addr, err)
+	}
+	return binary.LittleEndian.Uint32(buf), nil
+}
+
+// writeFlash copies the data to the RAM buffer and then lets the bootloader
+// write it to flash at the given address.
+func (p *sambaPort) writeFlash(addr uint32, data []byte) error {
+	err := p.command(fmt.Sprintf("S%08X,%08X#", sambaBufferAddr, len(data)))
+	if err != nil {
+		return err
+	}
+	err = p.Write(data)
+	if err != nil {
+		return fmt.Errorf("samba: could not send data: %w", err)
+	}
+	err = p.commandResponse(fmt.Sprintf("Y%08X,0#", sambaBufferAddr), "Y\n\r", sambaTimeout)
+	if err != nil {
+		return err
+	}
+	err = p.commandResponse(fmt.Sprintf("Y%08X,%08X#", addr, len(data)), "Y\n\r", 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("samba: could not write flash at 0x%x: %w", addr, err)
+	}
+	return nil
+}
+
+// checksum returns the CRC16 checksum of the given memory region, as
+// calculated by the bootloader.
+func (p *sambaPort) checksum(addr, size uint32) (uint16, error) {
+	err := p.command(fmt.Sprintf("Z%08X,%08X#", addr, size))
+	if err != nil {
+		return 0, err
+	}
+	// Response looks like: Z0000ABCD#\n\r
+	buf, err := p.read(12, 5*time.Second)
+	if err != nil {
+		return 0, fmt.Errorf("samba: could not read checksum: %w", err)
+	}
+	if buf[0] != 'Z' || string(buf[9:]) != "#\n\r" {
+		return 0, fmt.Errorf("samba: invalid checksum response: %#v", string(buf))
+	}
+	crc, err := strconv.ParseUint(string(buf[1:9]), 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("samba: invalid checksum response: %#v", string(buf))
+	}
+	return uint16(crc), nil
+}
+
+// sambaSupportsExtensions returns whether all the given extension commands are
+// listed in the version string of the bootloader.
+func sambaSupportsExtensions(version, commands string) bool {
+	index := strings.Index(version, "[Arduino:")
+	if index < 0 {
+		return false
+	}
+	extensions := version[index+len("[Arduino:"):]
+	if end := strings.IndexByte(extensions, ']'); end >= 0 {
+		extensions = extensions[:end]
+	}
+	for _, c := range commands {
+		if !strings.ContainsRune(extensions, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// crc16 calculates the CRC-16/XMODEM checksum used by the SAM-BA Z command.
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,171 @@
+package flash
+
+// This file implements the subset of the STK500 version 1 protocol that is
+// implemented by the Optiboot bootloader, as used on the Arduino Uno and
+// similar boards. It works like `avrdude -c arduino`.
+//
+// Protocol documentation:
+// https://ww1.microchip.com/downloads/en/Appnotes/doc2525.pdf
+// https://github.com/Optiboot/optiboot/blob/master/optiboot/bootloaders/optiboot/optiboot.c
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	stkOK            = 0x10
+	stkInSync        = 0x14
+	stkCRCEOP        = 0x20
+	stkGetSync       = 0x30
+	stkEnterProgmode = 0x50
+	stkLeaveProgmode = 0x51
+	stkLoadAddress   = 0x55
+	stkProgPage      = 0x64
+	stkReadPage      = 0x74
+	stkReadSign      = 0x75
+
+	stkTimeout = 500 * time.Millisecond
+)
+
+// stk500Chip describes a single AVR chip as far as it is relevant for the
+// STK500 protocol.
+type stk500Chip struct {
+	signature [3]byte
+	pageSize  int
+	flashSize int // flash size without bootloader
+}
+
+// stk500Chips lists all chips that are known to be flashed with Optiboot or a
+// compatible bootloader. The chip name is the same as the LLVM CPU name.
+var stk500Chips = map[string]stk500Chip{
+	"atmega328p":  {signature: [3]byte{0x1e, 0x95, 0x0f}, pageSize: 128, flashSize: 32*1024 - 512},
+	"atmega1284p": {signature: [3]byte{0x1e, 0x97, 0x05}, pageSize: 256, flashSize: 128*1024 - 1024},
+}
+
+// STK500v1 flashes the given firmware image using the STK500 version 1
+// protocol. The chip must have been reset just before calling this function
+// (usually by toggling DTR), as the bootloader only runs for a short time after
+// reset.
+func STK500v1(rw io.ReadWriter, image []Segment, cpu string) error {
+	if len(image) == 0 {
+		return errNoImage
+	}
+	chip, ok := stk500Chips[cpu]
+	if !ok {
+		return fmt.Errorf("stk500: unsupported chip: %s", cpu)
+	}
+	last := image[len(image)-1]
+	if int(last.Address)+len(last.Data) > chip.flashSize {
+		return fmt.Errorf("stk500: firmware image (%d bytes) does not fit in flash (%d bytes)", int(last.Address)+len(last.Data), chip.flashSize)
+	}
+	p := &stk500Port{newPort(rw)}
+
+	// The bootloader may have sent some garbage while starting, or may not be
+	// started yet. Try a few times to get in sync.
+	var err error
+	for i := 0; i < 10; i++ {
+		p.drain()
+		_, err = p.command([]byte{stkGetSync}, 0)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("stk500: could not get in sync with bootloader: %w", err)
+	}
+
+	// Check that the expected chip is connected.
+	signature, err := p.command([]byte{stkReadSign}, 3)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(signature, chip.signature[:]) {
+		return fmt.Errorf("stk500: unexpected device signature %x, expected %x for %s", signature, chip.signature, cpu)
+	}
+
+	_, err = p.command([]byte{stkEnterProgmode}, 0)
+	if err != nil {
+		return err
+	}
+
+	// Write and verify all pages. Optiboot erases each page before writing, so
+	// no separate erase step is necessary.
+	for _, segment := range image {
+		if int(segment.Address)%chip.pageSize != 0 {
+			return fmt.Errorf("stk500: segment at 0x%x is not aligned to a page boundary", segment.Address)
+		}
+		data := padPage(segment.Data, chip.pageSize)
+		for offset := 0; offset < len(data); offset += chip.pageSize {
+			addr := int(segment.Address) + offset
+			page := data[offset : offset+chip.pageSize]
+			err := p.loadAddress(addr)
+			if err != nil {
+				return err
+			}
+			cmd := append([]byte{stkProgPage, byte(len(page) >> 8), byte(len(page)), 'F'}, page...)
+			_, err = p.command(cmd, 0)
+			if err != nil {
+				return fmt.Errorf("stk500: could not write page at 0x%x: %w", addr, err)
+			}
+		}
+		for offset := 0; offset < len(data); offset += chip.pageSize {
+			addr := int(segment.Address) + offset
+			page := data[offset : offset+chip.pageSize]
+			err := p.loadAddress(addr)
+			if err != nil {
+				return err
+			}
+			readback, err := p.command([]byte{stkReadPage, byte(len(page) >> 8), byte(len(page)), 'F'}, len(page))
+			if err != nil {
+				return fmt.Errorf("stk500: could not read page at 0x%x: %w", addr, err)
+			}
+			if !bytes.Equal(readback, page) {
+				return fmt.Errorf("stk500: verification failed at 0x%x", addr)
+			}
+		}
+	}
+
+	// Leaving programming mode starts the application.
+	_, err = p.command([]byte{stkLeaveProgmode}, 0)
+	return err
+}
+
+// stk500Port is a serial port connected to a STK500v1 bootloader.
+type stk500Port struct {
+	*port
+}
+
+// command sends a single command (without the trailing CRC_EOP) and returns
+// the response data, which has the given length.
+func (p *stk500Port) command(cmd []byte, responseLength int) ([]byte, error) {
+	err := p.Write(append(cmd, stkCRCEOP))
+	if err != nil {
+		return nil, err
+	}
+	buf, err := p.read(1, stkTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if buf[0] != stkInSync {
+		return nil, fmt.Errorf("stk500: not in sync after command 0x%02x: got 0x%02x", cmd[0], buf[0])
+	}
+	data, err := p.read(responseLength+1, stkTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if data[responseLength] != stkOK {
+		return nil, fmt.Errorf("stk500: command 0x%02x failed", cmd[0])
+	}
+	return data[:responseLength], nil
+}
+
+// loadAddress sets the address for the next page read or write command.
+func (p *stk500Port) loadAddress(addr int) error {
+	// The address is in words, not bytes.
+	addr /= 2
+	_, err := p.command([]byte{stkLoadAddress, byte(addr), byte(addr >> 8)}, 0)
+	return err
+}
@@ -20,10 +20,11 @@ var (
 	errUnsupportedInst        = errors.New("interp: unsupported instruction")
 	errUnsupportedRuntimeInst = errors.New("interp: unsupported instruction (to be emitted at runtime)")
 	errMapAlreadyCreated      = errors.New("interp: map already created")
+	errChanAlreadyCreated     = errors.New("interp: channel already created")
 )
 
 func isRecoverableError(err error) bool {
-	return err == errExpectedPointer || err == errUnsupportedInst || err == errUnsupportedRuntimeInst || err == errMapAlreadyCreated
+	return err == errExpectedPointer || err == errUnsupportedInst || err == errUnsupportedRuntimeInst || err == errMapAlreadyCreated || err == errChanAlreadyCreated
 }
 
 // ErrorLine is one line in a traceback. The position may be missing.
@@ -40,6 +41,7 @@ type Error struct {
 	Pos        token.Position
 	Err        error
 	Traceback  []ErrorLine
+	instString string // human readable version of Inst, for the Report
 }
 
 // Error returns the string of the first error in the list of errors.
@@ -57,6 +59,7 @@ func (r *runner) errorAt(inst instruction, err error) *Error {
 		Pos:        pos,
 		Err:        err,
 		Traceback:  []ErrorLine{{pos, inst.llvmInst}},
+		instString: inst.String(),
 	}
 }
 
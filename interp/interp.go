@@ -25,6 +25,7 @@ type runner struct {
 	maxAlign      int                      // maximum alignment of an object, alignment of runtime.alloc() result
 	debug         bool                     // log debug messages
 	pkgName       string                   // package name of the currently executing package
+	report        *PackageReport           // report for the currently executing package
 	functionCache map[llvm.Value]*function // cache of compiled functions
 	objects       []object                 // slice of objects in memory
 	globals       map[llvm.Value]int       // map from global to index in objects slice
@@ -35,6 +36,15 @@ type runner struct {
 // Run evaluates runtime.initAll function as much as possible at compile time.
 // Set debug to true if it should print output while running.
 func Run(mod llvm.Module, debug bool) error {
+	_, err := RunWithReport(mod, debug)
+	return err
+}
+
+// RunWithReport is like Run, but also returns a report of which package
+// initializers were evaluated at compile time and which (partially) run at
+// runtime.
+func RunWithReport(mod llvm.Module, debug bool) (*Report, error) {
+	report := &Report{}
 	r := runner{
 		mod:           mod,
 		targetData:    llvm.NewTargetData(mod.DataLayout()),
@@ -81,7 +91,7 @@ func Run(mod llvm.Module, debug bool) error {
 			break // ret void
 		}
 		if inst.IsACallInst().IsNil() || inst.CalledValue().IsAFunction().IsNil() {
-			return errorAt(inst, "interp: expected all instructions in "+initAll.Name()+" to be direct calls")
+			return nil, errorAt(inst, "interp: expected all instructions in "+initAll.Name()+" to be direct calls")
 		}
 		initCalls = append(initCalls, inst)
 	}
@@ -91,9 +101,11 @@ func Run(mod llvm.Module, debug bool) error {
 	for _, call := range initCalls {
 		initName := call.CalledValue().Name()
 		if !strings.HasSuffix(initName, ".init") {
-			return errorAt(call, "interp: expected all instructions in "+initAll.Name()+" to be *.init() calls")
+			return nil, errorAt(call, "interp: expected all instructions in "+initAll.Name()+" to be *.init() calls")
 		}
 		r.pkgName = initName[:len(initName)-len(".init")]
+		r.report = &PackageReport{ImportPath: r.pkgName}
+		report.Packages = append(report.Packages, r.report)
 		fn := call.CalledValue()
 		if r.debug {
 			fmt.Fprintln(os.Stderr, "call:", fn.Name())
@@ -105,16 +117,28 @@ func Run(mod llvm.Module, debug bool) error {
 					fmt.Fprintln(os.Stderr, "not interpretring", r.pkgName, "because of error:", callErr.Err)
 				}
 				mem.revert()
+				r.report.Reverted = true
+				r.report.RuntimeInstructions = 0
+				r.report.Inst = ""
+				r.report.setReason(callErr.instString, callErr.Pos, callErr.Err)
+				r.report.GlobalBytes = r.globalBytes(mem, 0)
 				continue
 			}
-			return callErr
+			return nil, callErr
 		}
 		call.EraseFromParentAsInstruction()
+		for _, inst := range mem.instructions {
+			if !inst.IsAInstruction().IsNil() {
+				r.report.RuntimeInstructions++
+			}
+		}
+		r.report.GlobalBytes = r.globalBytes(mem, 2)
 		for index, obj := range mem.objects {
 			r.objects[index] = obj
 		}
 	}
 	r.pkgName = ""
+	r.report = nil
 
 	// Update all global variables in the LLVM module.
 	mem := memoryView{r: &r}
@@ -132,7 +156,25 @@ func Run(mod llvm.Module, debug bool) error {
 		obj.llvmGlobal.SetInitializer(initializer)
 	}
 
-	return nil
+	return report, nil
+}
+
+// globalBytes returns the total size of the global variables that were changed
+// in the given memory view and that were not marked before with at least the
+// given mark. With mark 0, all changed globals are counted, otherwise only
+// globals that are newly marked.
+func (r *runner) globalBytes(mem memoryView, mark uint8) uint64 {
+	var size uint64
+	for index, obj := range mem.objects {
+		if obj.llvmGlobal.IsNil() || obj.llvmGlobal.IsAGlobalVariable().IsNil() {
+			continue
+		}
+		if mark != 0 && (obj.marked < mark || r.objects[index].marked >= mark) {
+			continue
+		}
+		size += uint64(obj.size)
+	}
+	return size
 }
 
 // getFunction returns the compiled version of the given LLVM function. It
@@ -1,12 +1,14 @@
 package interp
 
 import (
+	"bytes"
 	"io/ioutil"
-	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
 
+	"github.com/tinygo-org/tinygo/compiler/irtest"
 	"tinygo.org/x/go-llvm"
 )
 
@@ -16,6 +18,8 @@ func TestInterp(t *testing.T) {
 		"slice-copy",
 		"consteval",
 		"map",
+		"map-ops",
+		"chan",
 		"interface",
 	} {
 		name := name // make tc local to this closure
@@ -26,21 +30,54 @@ func TestInterp(t *testing.T) {
 	}
 }
 
-func runTest(t *testing.T, pathPrefix string) {
-	// Read the input IR.
-	ctx := llvm.NewContext()
-	buf, err := llvm.NewMemoryBufferFromFile(pathPrefix + ".ll")
-	os.Stat(pathPrefix + ".ll") // make sure this file is tracked by `go test` caching
+// Test that the report of compile-time package initialization describes why a
+// package initializer could not be interpreted.
+func TestReport(t *testing.T) {
+	mod := irtest.LoadModule(t, "testdata/report.ll")
+	report, err := RunWithReport(mod, false)
 	if err != nil {
-		t.Fatalf("could not read file %s: %v", pathPrefix+".ll", err)
+		t.Fatal(err)
 	}
-	mod, err := ctx.ParseIR(buf)
-	if err != nil {
-		t.Fatalf("could not load module:\n%v", err)
+	if len(report.Packages) != 2 {
+		t.Fatalf("expected a report of 2 packages, got %d", len(report.Packages))
+	}
+
+	foo := report.Packages[0]
+	if foo.ImportPath != "foo" || !foo.Evaluated() {
+		t.Errorf("expected package foo to be evaluated at compile time: %#v", foo)
 	}
 
+	main := report.Packages[1]
+	if main.ImportPath != "main" || !main.Reverted {
+		t.Fatalf("expected package main to be reverted: %#v", main)
+	}
+	if main.Err != errExpectedPointer {
+		t.Errorf("unexpected error for package main: %v", main.Err)
+	}
+	if pos := main.Pos.String(); pos != filepath.Join("/src", "main.go")+":12:7" {
+		t.Errorf("unexpected position for package main: %s", pos)
+	}
+	if !strings.HasPrefix(main.Inst, "load ") {
+		t.Errorf("unexpected instruction for package main: %s", main.Inst)
+	}
+	if main.GlobalBytes != 8 {
+		t.Errorf("expected 8 bytes of globals kept in RAM for package main, got %d", main.GlobalBytes)
+	}
+
+	buf := &bytes.Buffer{}
+	report.Print(buf)
+	expected := "    " + main.Pos.String() + ": " + errExpectedPointer.Error() + "\n"
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("report does not contain %#v:\n%s", expected, buf.String())
+	}
+}
+
+func runTest(t *testing.T, pathPrefix string) {
+	// Read the input IR.
+	mod := irtest.LoadModule(t, pathPrefix+".ll")
+
 	// Perform the transform.
-	err = Run(mod, false)
+	err := Run(mod, false)
 	if err != nil {
 		if err, match := err.(*Error); match {
 			println(err.Error())
@@ -72,6 +109,16 @@ func runTest(t *testing.T, pathPrefix string) {
 	pm.AddDeadStoreEliminationPass()
 	pm.Run(mod)
 
+	// Update the expected output IR, if requested.
+	actual := irtest.ModuleString(mod)
+	if irtest.Update() {
+		err := ioutil.WriteFile(pathPrefix+".out.ll", []byte(actual), 0666)
+		if err != nil {
+			t.Error("failed to write updated golden file:", err)
+		}
+		return
+	}
+
 	// Read the expected output IR.
 	out, err := ioutil.ReadFile(pathPrefix + ".out.ll")
 	if err != nil {
@@ -79,8 +126,10 @@ func runTest(t *testing.T, pathPrefix string) {
 	}
 
 	// See whether the transform output matches with the expected output IR.
+	// This comparison is stricter than irtest.FuzzyEqualIR: only whole-line
+	// comments are ignored, so that the interp tests also check the comments
+	// and attributes that follow an instruction.
 	expected := string(out)
-	actual := mod.String()
 	if !fuzzyEqualIR(expected, actual) {
 		t.Logf("output does not match expected output:\n%s", actual)
 		t.Fail()
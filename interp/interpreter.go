@@ -155,7 +155,7 @@ func (r *runner) run(fn *function, params []value, parentMem *memoryView, indent
 				// which case this call won't even get to this point but will
 				// already be emitted in initAll.
 				continue
-			case callFn.name == "(reflect.Type).Elem" || strings.HasPrefix(callFn.name, "runtime.print") || callFn.name == "runtime._panic" || callFn.name == "runtime.hashmapGet":
+			case callFn.name == "(reflect.Type).Elem" || strings.HasPrefix(callFn.name, "runtime.print") || callFn.name == "runtime._panic" || callFn.name == "runtime.hashmapGet" || callFn.name == "internal/task.start":
 				// These functions should be run at runtime. Specifically:
 				//   * (reflect.Type).Elem is a special function. It should
 				//     eventually be interpreted, but fall back to a runtime call
@@ -165,7 +165,12 @@ func (r *runner) run(fn *function, params []value, parentMem *memoryView, indent
 				//     calls.
 				//   * runtime.hashmapGet tries to access the map value directly.
 				//     This is not possible as the map value is treated as a special
-				//     kind of object in this package.
+				//     kind of object in this package. Lookups with string and
+				//     binary keys are handled below, so this is only reached for
+				//     other key types (such as interfaces).
+				//   * internal/task.start starts a new goroutine. Goroutines do
+				//     not run while package initializers run, so starting it at
+				//     runtime doesn't change the order of operations.
 				err := r.runAtRuntime(fn, inst, locals, &mem, indent)
 				if err != nil {
 					return nil, mem, err
@@ -381,6 +386,15 @@ func (r *runner) run(fn *function, params []value, parentMem *memoryView, indent
 				if err != nil {
 					return nil, mem, r.errorAt(inst, err)
 				}
+				if mem.hasExternalLoadOrStore(mapPtr) {
+					// The map may have been used at runtime already, so it
+					// must also be modified at runtime.
+					err := r.runAtRuntime(fn, inst, locals, &mem, indent)
+					if err != nil {
+						return nil, mem, err
+					}
+					continue
+				}
 				m := mem.getWritable(mapPtr.index()).buffer.(*mapValue)
 				keyPtr, err := operands[2].asPointer(r)
 				if err != nil {
@@ -397,12 +411,21 @@ func (r *runner) run(fn *function, params []value, parentMem *memoryView, indent
 			case callFn.name == "runtime.hashmapStringSet":
 				// Do a mapassign operation with a string key.
 				if r.debug {
-					fmt.Fprintln(os.Stderr, indent+"runtime.hashmapBinarySet:", operands[1:])
+					fmt.Fprintln(os.Stderr, indent+"runtime.hashmapStringSet:", operands[1:])
 				}
 				mapPtr, err := operands[1].asPointer(r)
 				if err != nil {
 					return nil, mem, r.errorAt(inst, err)
 				}
+				if mem.hasExternalLoadOrStore(mapPtr) {
+					// The map may have been used at runtime already, so it
+					// must also be modified at runtime.
+					err := r.runAtRuntime(fn, inst, locals, &mem, indent)
+					if err != nil {
+						return nil, mem, err
+					}
+					continue
+				}
 				m := mem.getWritable(mapPtr.index()).buffer.(*mapValue)
 				stringPtr, err := operands[2].asPointer(r)
 				if err != nil {
@@ -417,6 +440,226 @@ func (r *runner) run(fn *function, params []value, parentMem *memoryView, indent
 				if err != nil {
 					return nil, mem, r.errorAt(inst, err)
 				}
+			case callFn.name == "runtime.hashmapBinaryGet" || callFn.name == "runtime.hashmapStringGet":
+				// Do a map lookup operation (with or without comma-ok).
+				if r.debug {
+					fmt.Fprintln(os.Stderr, indent+callFn.name+":", operands[1:])
+				}
+				mapPtr, err := operands[1].asPointer(r)
+				if err != nil {
+					return nil, mem, r.errorAt(inst, err)
+				}
+				if mem.hasExternalStore(mapPtr) {
+					// The map may have been modified at runtime.
+					err := r.runAtRuntime(fn, inst, locals, &mem, indent)
+					if err != nil {
+						return nil, mem, err
+					}
+					continue
+				}
+				m := mem.get(mapPtr.index()).buffer.(*mapValue)
+				var found bool
+				if callFn.name == "runtime.hashmapStringGet" {
+					stringPtr, err := operands[2].asPointer(r)
+					if err != nil {
+						return nil, mem, r.errorAt(inst, err)
+					}
+					valuePtr, err := operands[4].asPointer(r)
+					if err != nil {
+						return nil, mem, r.errorAt(inst, err)
+					}
+					found = m.getString(&mem, stringPtr, operands[3].Uint(), valuePtr)
+				} else {
+					keyPtr, err := operands[2].asPointer(r)
+					if err != nil {
+						return nil, mem, r.errorAt(inst, err)
+					}
+					valuePtr, err := operands[3].asPointer(r)
+					if err != nil {
+						return nil, mem, r.errorAt(inst, err)
+					}
+					found = m.getBinary(&mem, keyPtr, valuePtr)
+				}
+				if found {
+					locals[inst.localIndex] = literalValue{uint8(1)}
+				} else {
+					locals[inst.localIndex] = literalValue{uint8(0)}
+				}
+			case callFn.name == "runtime.hashmapBinaryDelete" || callFn.name == "runtime.hashmapStringDelete":
+				// Do a map delete operation.
+				if r.debug {
+					fmt.Fprintln(os.Stderr, indent+callFn.name+":", operands[1:])
+				}
+				mapPtr, err := operands[1].asPointer(r)
+				if err != nil {
+					return nil, mem, r.errorAt(inst, err)
+				}
+				if mem.hasExternalLoadOrStore(mapPtr) {
+					// The map may have been used at runtime already, so it
+					// must also be modified at runtime.
+					err := r.runAtRuntime(fn, inst, locals, &mem, indent)
+					if err != nil {
+						return nil, mem, err
+					}
+					continue
+				}
+				obj := mem.getWritable(mapPtr.index())
+				m := obj.buffer.(*mapValue)
+				keyPtr, err := operands[2].asPointer(r)
+				if err != nil {
+					return nil, mem, r.errorAt(inst, err)
+				}
+				if callFn.name == "runtime.hashmapStringDelete" {
+					err = m.deleteString(&mem, keyPtr, operands[3].Uint())
+				} else {
+					err = m.deleteBinary(&mem, keyPtr)
+				}
+				if err != nil {
+					return nil, mem, r.errorAt(inst, err)
+				}
+				mem.put(mapPtr.index(), obj)
+			case callFn.name == "runtime.hashmapLen":
+				// Return the number of entries in a map.
+				mapPtr, err := operands[1].asPointer(r)
+				if err != nil {
+					return nil, mem, r.errorAt(inst, err)
+				}
+				if mem.hasExternalStore(mapPtr) {
+					err := r.runAtRuntime(fn, inst, locals, &mem, indent)
+					if err != nil {
+						return nil, mem, err
+					}
+					continue
+				}
+				m := mem.get(mapPtr.index()).buffer.(*mapValue)
+				locals[inst.localIndex] = makeLiteralInt(uint64(len(m.keys)), inst.llvmInst.Type().IntTypeWidth())
+			case callFn.name == "runtime.hashmapNext":
+				// Do a single iteration of a range loop over a map. The
+				// iterator (which is zero-initialized by the compiler) is used
+				// to store the index of the next key, in place of the bucket
+				// number.
+				if r.debug {
+					fmt.Fprintln(os.Stderr, indent+"runtime.hashmapNext:", operands[1:])
+				}
+				mapPtr, err := operands[1].asPointer(r)
+				if err != nil {
+					return nil, mem, r.errorAt(inst, err)
+				}
+				if mem.hasExternalStore(mapPtr) {
+					// The iterator can't be shared with the runtime
+					// implementation, so run the whole loop at runtime.
+					return nil, mem, r.errorAt(inst, errUnsupportedRuntimeInst)
+				}
+				m := mem.get(mapPtr.index()).buffer.(*mapValue)
+				itPtr, err := operands[2].asPointer(r)
+				if err != nil {
+					return nil, mem, r.errorAt(inst, err)
+				}
+				keyPtr, err := operands[3].asPointer(r)
+				if err != nil {
+					return nil, mem, r.errorAt(inst, err)
+				}
+				valuePtr, err := operands[4].asPointer(r)
+				if err != nil {
+					return nil, mem, r.errorAt(inst, err)
+				}
+				index := mem.load(itPtr, r.pointerSize).Uint()
+				if m.next(&mem, index, keyPtr, valuePtr) {
+					mem.store(makeLiteralInt(index+1, int(r.pointerSize)*8), itPtr)
+					locals[inst.localIndex] = literalValue{uint8(1)}
+				} else {
+					locals[inst.localIndex] = literalValue{uint8(0)}
+				}
+			case callFn.name == "runtime.chanMake":
+				// Create a new channel.
+				channelPointerType := inst.llvmInst.Type()
+				elementSize := uint32(operands[1].Uint())
+				bufSize := uint32(operands[2].Uint())
+				ch := newChannelValue(r, channelPointerType, elementSize, bufSize)
+				alloc := object{
+					llvmType:   channelPointerType,
+					globalName: r.pkgName + "$chan",
+					buffer:     ch,
+					size:       ch.len(r),
+				}
+				index := len(r.objects)
+				r.objects = append(r.objects, alloc)
+
+				// Channels are reference types, so are implemented as
+				// pointers.
+				ptr := newPointerValue(r, index, 0)
+				if r.debug {
+					fmt.Fprintln(os.Stderr, indent+"runtime.chanMake:", elementSize, bufSize, "->", ptr)
+				}
+				locals[inst.localIndex] = ptr
+			case callFn.name == "runtime.chanSend" || callFn.name == "runtime.chanRecv" || callFn.name == "runtime.chanClose":
+				// Do a channel operation. Operations that would block or panic
+				// are done at runtime, as are operations on channels that may
+				// have been used at runtime already.
+				if r.debug {
+					fmt.Fprintln(os.Stderr, indent+callFn.name+":", operands[1:])
+				}
+				chPtr, err := operands[1].asPointer(r)
+				if err != nil {
+					return nil, mem, r.errorAt(inst, err)
+				}
+				done := false
+				if !mem.hasExternalLoadOrStore(chPtr) {
+					obj := mem.getWritable(chPtr.index())
+					ch := obj.buffer.(*channelValue)
+					switch callFn.name {
+					case "runtime.chanSend":
+						var valuePtr pointerValue
+						valuePtr, err = operands[2].asPointer(r)
+						if err == nil {
+							done, err = ch.send(&mem, valuePtr)
+						}
+					case "runtime.chanRecv":
+						var valuePtr pointerValue
+						var commaOk bool
+						valuePtr, err = operands[2].asPointer(r)
+						if err == nil {
+							done, commaOk, err = ch.recv(&mem, valuePtr)
+						}
+						if commaOk {
+							locals[inst.localIndex] = literalValue{uint8(1)}
+						} else {
+							locals[inst.localIndex] = literalValue{uint8(0)}
+						}
+					case "runtime.chanClose":
+						done, err = ch.close()
+					}
+					if err != nil {
+						return nil, mem, r.errorAt(inst, err)
+					}
+					mem.put(chPtr.index(), obj)
+				}
+				if !done {
+					err := r.runAtRuntime(fn, inst, locals, &mem, indent)
+					if err != nil {
+						return nil, mem, err
+					}
+				}
+			case callFn.name == "runtime.chanLen" || callFn.name == "runtime.chanCap":
+				// Return the number of buffered values or the buffer size of a
+				// channel.
+				chPtr, err := operands[1].asPointer(r)
+				if err != nil {
+					return nil, mem, r.errorAt(inst, err)
+				}
+				if mem.hasExternalStore(chPtr) {
+					err := r.runAtRuntime(fn, inst, locals, &mem, indent)
+					if err != nil {
+						return nil, mem, err
+					}
+					continue
+				}
+				ch := mem.get(chPtr.index()).buffer.(*channelValue)
+				n := uint64(ch.bufSize)
+				if callFn.name == "runtime.chanLen" {
+					n = uint64(len(ch.buffer))
+				}
+				locals[inst.localIndex] = makeLiteralInt(n, inst.llvmInst.Type().IntTypeWidth())
 			default:
 				if len(callFn.blocks) == 0 {
 					// Call to a function declaration without a definition
@@ -436,6 +679,7 @@ func (r *runner) run(fn *function, params []value, parentMem *memoryView, indent
 					}
 					fmt.Fprintln(os.Stderr, indent+"call:", callFn.name+"("+strings.Join(argStrings, ", ")+")")
 				}
+				hasReason := r.report.Inst != ""
 				retval, callMem, callErr := r.run(callFn, operands[1:], &mem, indent+"    ")
 				if callErr != nil {
 					if isRecoverableError(callErr.Err) {
@@ -446,6 +690,12 @@ func (r *runner) run(fn *function, params []value, parentMem *memoryView, indent
 							fmt.Fprintln(os.Stderr, indent+"!! revert because of error:", callErr.Err)
 						}
 						callMem.revert()
+						if !hasReason {
+							// The error is the reason this call is done at
+							// runtime, not anything inside the reverted call.
+							r.report.Inst = ""
+							r.report.setReason(callErr.instString, callErr.Pos, callErr.Err)
+						}
 						err := r.runAtRuntime(fn, inst, locals, &mem, indent)
 						if err != nil {
 							return nil, mem, err
@@ -828,6 +1078,7 @@ func (r *runner) runAtRuntime(fn *function, inst instruction, locals []value, me
 	if r.debug {
 		fmt.Fprintln(os.Stderr, indent+inst.String())
 	}
+	r.report.setReason(inst.String(), getPosition(inst.llvmInst), nil)
 	var result llvm.Value
 	switch inst.opcode {
 	case llvm.Call:
@@ -889,6 +1140,22 @@ func (r *runner) runAtRuntime(fn *function, inst instruction, locals []value, me
 	return nil
 }
 
+// makeLiteralInt returns a literal integer value of the given bit width.
+func makeLiteralInt(value uint64, bitwidth int) literalValue {
+	switch bitwidth {
+	case 64:
+		return literalValue{value}
+	case 32:
+		return literalValue{uint32(value)}
+	case 16:
+		return literalValue{uint16(value)}
+	case 8:
+		return literalValue{uint8(value)}
+	default:
+		panic("unknown integer type width")
+	}
+}
+
 func intPredicateString(predicate llvm.IntPredicate) string {
 	switch predicate {
 	case llvm.IntEQ:
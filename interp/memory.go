@@ -239,7 +239,7 @@ func (mv *memoryView) getWritable(index uint32) object {
 	}
 	// Object is not currently in this view. Get it, and clone it for use.
 	obj := mv.get(index).clone()
-	mv.r.objects[index] = obj
+	mv.put(index, obj)
 	return obj
 }
 
@@ -764,12 +764,7 @@ func (v *mapValue) toLLVMValue(hashmapType llvm.Type, mem *memoryView) llvm.Valu
 		switch key := key.(type) {
 		case mapStringKey:
 			data = key.data
-			keyValue = newRawValue(v.keySize)
-			// runtime._string is {ptr, length}
-			for i := uint32(0); i < v.keySize/2; i++ {
-				keyValue.buf[i] = key.buf.pointer
-			}
-			copy(keyValue.buf[v.keySize/2:], literalValue{key.size}.asRawValue(v.r).buf)
+			keyValue = v.stringKeyValue(key)
 		case rawValue:
 			if key.hasPointer() {
 				panic("todo: map key with pointer")
@@ -837,8 +832,13 @@ func (v *mapValue) putString(mem *memoryView, stringBuf pointerValue, stringLen
 		panic("interp: string contains pointer")
 	}
 
-	// TODO: avoid duplicate keys
-	v.keys = append(v.keys, mapStringKey{stringBuf, stringLen, stringValue.buf})
+	key := mapStringKey{stringBuf, stringLen, stringValue.buf}
+	if i := v.findString(key.data); i >= 0 {
+		// Overwrite the existing value, keeping the existing key.
+		v.values[i] = value.asRawValue(v.r)
+		return nil
+	}
+	v.keys = append(v.keys, key)
 	v.values = append(v.values, value.asRawValue(v.r))
 	v.keyIsString = true
 
@@ -864,13 +864,132 @@ func (v *mapValue) putBinary(mem *memoryView, keyPtr, valuePtr pointerValue) err
 		panic("cannot put binary keys in string map")
 	}
 
-	// TODO: avoid duplicate keys
+	if i := v.findBinary(key.asRawValue(v.r)); i >= 0 {
+		// Overwrite the existing value.
+		v.values[i] = value.asRawValue(v.r)
+		return nil
+	}
 	v.keys = append(v.keys, key.asRawValue(v.r))
 	v.values = append(v.values, value.asRawValue(v.r))
 
 	return nil
 }
 
+// getString does a map lookup operation, assuming that the map is of type
+// map[string]T. The value is stored in valuePtr (the zero value if the key is
+// not present) and the return value indicates whether the key was found.
+func (v *mapValue) getString(mem *memoryView, stringBuf pointerValue, stringLen uint64, valuePtr pointerValue) bool {
+	stringValue := mem.load(stringBuf, uint32(stringLen)).asRawValue(v.r)
+	if stringValue.hasPointer() {
+		panic("interp: string contains pointer")
+	}
+	return v.get(mem, v.findString(stringValue.buf), valuePtr)
+}
+
+// getBinary does a map lookup operation for binary data, see getString.
+func (v *mapValue) getBinary(mem *memoryView, keyPtr, valuePtr pointerValue) bool {
+	key := mem.load(keyPtr, v.keySize).asRawValue(v.r)
+	return v.get(mem, v.findBinary(key), valuePtr)
+}
+
+// get stores the value at the given index (or the zero value if the index is
+// negative) in valuePtr and returns whether the index was valid.
+func (v *mapValue) get(mem *memoryView, index int, valuePtr pointerValue) bool {
+	if index < 0 {
+		mem.store(newRawValue(v.valueSize), valuePtr)
+		return false
+	}
+	mem.store(v.values[index].clone(), valuePtr)
+	return true
+}
+
+// deleteString does a map delete operation, assuming that the map is of type
+// map[string]T.
+func (v *mapValue) deleteString(mem *memoryView, stringBuf pointerValue, stringLen uint64) error {
+	if !v.hashmap.IsNil() {
+		return errMapAlreadyCreated
+	}
+	stringValue := mem.load(stringBuf, uint32(stringLen)).asRawValue(v.r)
+	if stringValue.hasPointer() {
+		panic("interp: string contains pointer")
+	}
+	v.remove(v.findString(stringValue.buf))
+	return nil
+}
+
+// deleteBinary does a map delete operation for binary data.
+func (v *mapValue) deleteBinary(mem *memoryView, keyPtr pointerValue) error {
+	if !v.hashmap.IsNil() {
+		return errMapAlreadyCreated
+	}
+	key := mem.load(keyPtr, v.keySize).asRawValue(v.r)
+	v.remove(v.findBinary(key))
+	return nil
+}
+
+// remove removes the key/value pair at the given index, if it is not negative.
+func (v *mapValue) remove(index int) {
+	if index < 0 {
+		return
+	}
+	v.keys = append(v.keys[:index], v.keys[index+1:]...)
+	v.values = append(v.values[:index], v.values[index+1:]...)
+}
+
+// next implements a single iteration of a range loop over the map. The index
+// of the next key/value pair is passed in, and the key and value are stored in
+// keyPtr and valuePtr. It returns false when there are no more keys.
+func (v *mapValue) next(mem *memoryView, index uint64, keyPtr, valuePtr pointerValue) bool {
+	if index >= uint64(len(v.keys)) {
+		return false
+	}
+	switch key := v.keys[index].(type) {
+	case mapStringKey:
+		mem.store(v.stringKeyValue(key), keyPtr)
+	case rawValue:
+		mem.store(key.clone(), keyPtr)
+	}
+	mem.store(v.values[index].clone(), valuePtr)
+	return true
+}
+
+// stringKeyValue returns the runtime._string value of a string key.
+func (v *mapValue) stringKeyValue(key mapStringKey) rawValue {
+	// runtime._string is {ptr, length}
+	keyValue := newRawValue(v.keySize)
+	for i := uint32(0); i < v.keySize/2; i++ {
+		keyValue.buf[i] = key.buf.pointer
+	}
+	copy(keyValue.buf[v.keySize/2:], literalValue{key.size}.asRawValue(v.r).buf)
+	return keyValue
+}
+
+// findString returns the index of the given string key, or -1 if the key is not
+// present in the map.
+func (v *mapValue) findString(data []uint64) int {
+	for i, key := range v.keys {
+		key, ok := key.(mapStringKey)
+		if !ok || len(key.data) != len(data) {
+			continue
+		}
+		if (rawValue{key.data}).equal(rawValue{data}) {
+			return i
+		}
+	}
+	return -1
+}
+
+// findBinary returns the index of the given binary key, or -1 if the key is not
+// present in the map.
+func (v *mapValue) findBinary(key rawValue) int {
+	for i, k := range v.keys {
+		if k, ok := k.(rawValue); ok && k.equal(key) {
+			return i
+		}
+	}
+	return -1
+}
+
 // Get FNV-1a hash of this string.
 //
 // https://en.wikipedia.org/wiki/Fowler%E2%80%93Noll%E2%80%93Vo_hash_function#FNV-1a_hash
@@ -897,6 +1016,170 @@ func (v *mapValue) String() string {
 	return "<map keySize=" + strconv.Itoa(int(v.keySize)) + " valueSize=" + strconv.Itoa(int(v.valueSize)) + ">"
 }
 
+// channelValue implements a Go channel which is created at compile time and
+// stored as a global variable. Only operations that do not block are simulated:
+// sending to a channel with space left in the buffer, receiving from a channel
+// with buffered values and closing a channel. Like mapValue, it can only be
+// used as part of an object.
+type channelValue struct {
+	r           *runner
+	pkgName     string
+	size        uint32 // byte size of runtime.channel
+	channel     llvm.Value
+	elementSize uint32
+	bufSize     uint32
+	buffer      []rawValue // values currently in the buffer, oldest first
+	closed      bool
+}
+
+// Values of runtime.chanState.
+const (
+	chanStateEmpty  = 0
+	chanStateBuf    = 3
+	chanStateClosed = 4
+)
+
+func newChannelValue(r *runner, channelPointerType llvm.Type, elementSize, bufSize uint32) *channelValue {
+	size := uint32(r.targetData.TypeAllocSize(channelPointerType.ElementType()))
+	return &channelValue{
+		r:           r,
+		pkgName:     r.pkgName,
+		size:        size,
+		elementSize: elementSize,
+		bufSize:     bufSize,
+	}
+}
+
+func (v *channelValue) len(r *runner) uint32 {
+	return v.size
+}
+
+func (v *channelValue) clone() value {
+	// Return a copy of channelValue.
+	clone := *v
+	clone.buffer = append([]rawValue{}, clone.buffer...)
+	return &clone
+}
+
+func (v *channelValue) asPointer(r *runner) (pointerValue, error) {
+	panic("interp: channelValue.asPointer")
+}
+
+func (v *channelValue) asRawValue(r *runner) rawValue {
+	panic("interp: channelValue.asRawValue")
+}
+
+func (v *channelValue) Uint() uint64 {
+	panic("interp: channelValue.Uint")
+}
+
+func (v *channelValue) Int() int64 {
+	panic("interp: channelValue.Int")
+}
+
+// toLLVMValue returns a value structurally equivalent to runtime.channel, with
+// the buffered values stored in a separate global.
+func (v *channelValue) toLLVMValue(channelType llvm.Type, mem *memoryView) llvm.Value {
+	if !v.channel.IsNil() {
+		return v.channel
+	}
+	fieldTypes := channelType.StructElementTypes()
+
+	// Create the buffer, with the values in the order in which they will be
+	// received.
+	buf := llvm.ConstNull(fieldTypes[7])
+	if v.bufSize != 0 {
+		bufValue := newRawValue(v.elementSize * v.bufSize)
+		for i, value := range v.buffer {
+			copy(bufValue.buf[uint32(i)*v.elementSize:], value.buf)
+		}
+		initializer := bufValue.rawLLVMValue(mem)
+		global := llvm.AddGlobal(v.r.mod, initializer.Type(), v.pkgName+"$chanbuf")
+		global.SetInitializer(initializer)
+		global.SetAlignment(v.r.maxAlign)
+		global.SetLinkage(llvm.InternalLinkage)
+		buf = llvm.ConstBitCast(global, fieldTypes[7])
+	}
+
+	state := uint64(chanStateEmpty)
+	if v.closed {
+		state = chanStateClosed
+	} else if len(v.buffer) != 0 {
+		state = chanStateBuf
+	}
+	bufHead := uint64(0)
+	if v.bufSize != 0 {
+		bufHead = uint64(len(v.buffer)) % uint64(v.bufSize)
+	}
+
+	v.channel = llvm.ConstNamedStruct(channelType, []llvm.Value{
+		llvm.ConstInt(fieldTypes[0], uint64(v.elementSize), false), // elementSize
+		llvm.ConstInt(fieldTypes[1], uint64(v.bufSize), false),     // bufSize
+		llvm.ConstInt(fieldTypes[2], state, false),                 // state
+		llvm.ConstNull(fieldTypes[3]),                              // blocked
+		llvm.ConstInt(fieldTypes[4], bufHead, false),               // bufHead
+		llvm.ConstInt(fieldTypes[5], 0, false),                     // bufTail
+		llvm.ConstInt(fieldTypes[6], uint64(len(v.buffer)), false), // bufUsed
+		buf, // buf
+	})
+	return v.channel
+}
+
+// send does a non-blocking send operation. It returns false if the send cannot
+// be done at compile time, because it would block or panic.
+func (v *channelValue) send(mem *memoryView, valuePtr pointerValue) (bool, error) {
+	if !v.channel.IsNil() {
+		return false, errChanAlreadyCreated
+	}
+	if v.closed || uint32(len(v.buffer)) >= v.bufSize {
+		return false, nil
+	}
+	value := mem.load(valuePtr, v.elementSize)
+	v.buffer = append(v.buffer, value.asRawValue(v.r))
+	return true, nil
+}
+
+// recv does a non-blocking receive operation. The received value is stored in
+// valuePtr and commaOk indicates whether the value was sent (instead of being
+// the zero value of a closed channel). It returns false if the receive cannot
+// be done at compile time, because it would block.
+func (v *channelValue) recv(mem *memoryView, valuePtr pointerValue) (ok, commaOk bool, err error) {
+	if !v.channel.IsNil() {
+		return false, false, errChanAlreadyCreated
+	}
+	if len(v.buffer) != 0 {
+		if v.elementSize != 0 {
+			mem.store(v.buffer[0], valuePtr)
+		}
+		v.buffer = v.buffer[1:]
+		return true, true, nil
+	}
+	if v.closed {
+		if v.elementSize != 0 {
+			mem.store(newRawValue(v.elementSize), valuePtr)
+		}
+		return true, false, nil
+	}
+	return false, false, nil
+}
+
+// close closes the channel. It returns false if the channel was already closed,
+// which results in a panic at runtime.
+func (v *channelValue) close() (bool, error) {
+	if !v.channel.IsNil() {
+		return false, errChanAlreadyCreated
+	}
+	if v.closed {
+		return false, nil
+	}
+	v.closed = true
+	return true, nil
+}
+
+func (v *channelValue) String() string {
+	return "<chan elementSize=" + strconv.Itoa(int(v.elementSize)) + " bufSize=" + strconv.Itoa(int(v.bufSize)) + ">"
+}
+
 // rawValue is a raw memory buffer that can store either pointers or regular
 // data. This is the fallback data for everything that isn't clearly a
 // literalValue or pointerValue.
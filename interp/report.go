@@ -0,0 +1,87 @@
+package interp
+
+// This file implements a report of which package initializers could be
+// evaluated at compile time, and if not, why.
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+)
+
+// Report describes, for every package initializer in runtime.initAll, whether
+// it was evaluated at compile time.
+type Report struct {
+	Packages []*PackageReport
+}
+
+// PackageReport describes the result of interpreting a single package
+// initializer.
+type PackageReport struct {
+	ImportPath string
+
+	// Reverted is true if the package initializer couldn't be interpreted at
+	// all, and is left to run entirely at runtime.
+	Reverted bool
+
+	// Number of instructions that were emitted to run at runtime, in addition
+	// to the package initializer itself if it was reverted.
+	RuntimeInstructions int
+
+	// The reason why (part of) the package initializer runs at runtime: the
+	// first instruction that could not be evaluated at compile time, its
+	// source position (if known) and an error describing the problem (if
+	// any). Inst is empty if the package initializer was fully evaluated.
+	Inst string
+	Pos  token.Position
+	Err  error
+
+	// Size in bytes of global variables that are written at runtime as a
+	// result. They cannot be turned into constants and are kept in RAM. For
+	// reverted package initializers, only globals written before the error
+	// was encountered are included.
+	GlobalBytes uint64
+}
+
+// Evaluated returns whether the package initializer was fully evaluated at
+// compile time.
+func (pr *PackageReport) Evaluated() bool {
+	return !pr.Reverted && pr.RuntimeInstructions == 0
+}
+
+// setReason records the instruction that forces runtime execution of the
+// package initializer, unless a reason was already recorded.
+func (pr *PackageReport) setReason(inst string, pos token.Position, err error) {
+	if pr.Inst != "" {
+		return
+	}
+	pr.Inst = inst
+	pr.Pos = pos
+	pr.Err = err
+}
+
+// Print writes a human readable version of the report to w.
+func (r *Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "%-32s %s\n", "package", "initialization")
+	for _, pr := range r.Packages {
+		switch {
+		case pr.Evaluated():
+			fmt.Fprintf(w, "%-32s compile time\n", pr.ImportPath)
+			continue
+		case pr.Reverted:
+			fmt.Fprintf(w, "%-32s runtime, %d bytes of globals kept in RAM\n", pr.ImportPath, pr.GlobalBytes)
+		default:
+			fmt.Fprintf(w, "%-32s partially at runtime (%d instructions), %d bytes of globals kept in RAM\n", pr.ImportPath, pr.RuntimeInstructions, pr.GlobalBytes)
+		}
+		pos := "unknown position"
+		if pr.Pos.IsValid() {
+			pos = pr.Pos.String()
+		}
+		if pr.Err != nil {
+			fmt.Fprintf(w, "    %s: %s\n", pos, pr.Err)
+		} else {
+			fmt.Fprintf(w, "    %s: instruction must run at runtime\n", pos)
+		}
+		fmt.Fprintf(w, "        %s\n", pr.Inst)
+	}
+}
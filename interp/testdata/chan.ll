@@ -0,0 +1,87 @@
+target datalayout = "e-m:e-p:32:32-Fi8-i64:64-v128:64:128-a:0:32-n32-S64"
+target triple = "armv6m-none-eabi"
+
+%runtime.channel = type { i32, i32, i8, %runtime.channelBlockedList*, i32, i32, i32, i8* }
+%runtime.channelBlockedList = type opaque
+
+@main.buffered = global %runtime.channel* null
+@main.closed = global %runtime.channel* null
+@main.full = global %runtime.channel* null
+@main.received = global i32 0
+@main.len = global i32 0
+@main.cap = global i32 0
+@main.closedOk = global i1 true
+
+declare %runtime.channel* @runtime.chanMake(i32, i32, i8* %context, i8* %parentHandle)
+declare void @runtime.chanSend(%runtime.channel*, i8*, %runtime.channelBlockedList*, i8* %context, i8* %parentHandle)
+declare i1 @runtime.chanRecv(%runtime.channel*, i8*, %runtime.channelBlockedList*, i8* %context, i8* %parentHandle)
+declare void @runtime.chanClose(%runtime.channel*, i8* %context, i8* %parentHandle)
+declare i32 @runtime.chanLen(%runtime.channel*, i8* %context, i8* %parentHandle)
+declare i32 @runtime.chanCap(%runtime.channel*, i8* %context, i8* %parentHandle)
+
+define void @runtime.initAll() unnamed_addr {
+entry:
+  call void @main.init(i8* undef, i8* null)
+  ret void
+}
+
+define internal void @main.init(i8* %context, i8* %parentHandle) unnamed_addr {
+entry:
+  call void @main.testBuffered()
+  call void @main.testClosed()
+  call void @main.testFull()
+  ret void
+}
+
+; Test sending to and receiving from a buffered channel. The values that remain
+; in the buffer must be part of the channel global.
+define internal void @main.testBuffered() {
+entry:
+  %value = alloca i32
+  %value.bitcast = bitcast i32* %value to i8*
+  %ch = call %runtime.channel* @runtime.chanMake(i32 4, i32 3, i8* undef, i8* null)
+  store %runtime.channel* %ch, %runtime.channel** @main.buffered
+  store i32 5, i32* %value
+  call void @runtime.chanSend(%runtime.channel* %ch, i8* %value.bitcast, %runtime.channelBlockedList* null, i8* undef, i8* null)
+  store i32 7, i32* %value
+  call void @runtime.chanSend(%runtime.channel* %ch, i8* %value.bitcast, %runtime.channelBlockedList* null, i8* undef, i8* null)
+  store i32 9, i32* %value
+  call void @runtime.chanSend(%runtime.channel* %ch, i8* %value.bitcast, %runtime.channelBlockedList* null, i8* undef, i8* null)
+  %ok = call i1 @runtime.chanRecv(%runtime.channel* %ch, i8* %value.bitcast, %runtime.channelBlockedList* null, i8* undef, i8* null)
+  %received = load i32, i32* %value
+  store i32 %received, i32* @main.received
+  %len = call i32 @runtime.chanLen(%runtime.channel* %ch, i8* undef, i8* null)
+  store i32 %len, i32* @main.len
+  %cap = call i32 @runtime.chanCap(%runtime.channel* %ch, i8* undef, i8* null)
+  store i32 %cap, i32* @main.cap
+  ret void
+}
+
+; Test closing a channel and receiving from it.
+define internal void @main.testClosed() {
+entry:
+  %value = alloca i32
+  %value.bitcast = bitcast i32* %value to i8*
+  %ch = call %runtime.channel* @runtime.chanMake(i32 4, i32 0, i8* undef, i8* null)
+  store %runtime.channel* %ch, %runtime.channel** @main.closed
+  call void @runtime.chanClose(%runtime.channel* %ch, i8* undef, i8* null)
+  %ok = call i1 @runtime.chanRecv(%runtime.channel* %ch, i8* %value.bitcast, %runtime.channelBlockedList* null, i8* undef, i8* null)
+  store i1 %ok, i1* @main.closedOk
+  ret void
+}
+
+; Test that a send that would block is done at runtime, and that all following
+; operations on the channel are also done at runtime.
+define internal void @main.testFull() {
+entry:
+  %value = alloca i32
+  %value.bitcast = bitcast i32* %value to i8*
+  %ch = call %runtime.channel* @runtime.chanMake(i32 4, i32 1, i8* undef, i8* null)
+  store %runtime.channel* %ch, %runtime.channel** @main.full
+  store i32 1, i32* %value
+  call void @runtime.chanSend(%runtime.channel* %ch, i8* %value.bitcast, %runtime.channelBlockedList* null, i8* undef, i8* null)
+  store i32 2, i32* %value
+  call void @runtime.chanSend(%runtime.channel* %ch, i8* %value.bitcast, %runtime.channelBlockedList* null, i8* undef, i8* null)
+  %len = call i32 @runtime.chanLen(%runtime.channel* %ch, i8* undef, i8* null)
+  ret void
+}
@@ -0,0 +1,30 @@
+target datalayout = "e-m:e-p:32:32-Fi8-i64:64-v128:64:128-a:0:32-n32-S64"
+target triple = "armv6m-none-eabi"
+
+%runtime.channel = type { i32, i32, i8, %runtime.channelBlockedList*, i32, i32, i32, i8* }
+%runtime.channelBlockedList = type opaque
+
+@main.buffered = local_unnamed_addr global %runtime.channel* @"main$chan.1"
+@main.closed = local_unnamed_addr global %runtime.channel* @"main$chan.3"
+@main.full = local_unnamed_addr global %runtime.channel* @"main$chan"
+@main.received = local_unnamed_addr global i32 5
+@main.len = local_unnamed_addr global i32 2
+@main.cap = local_unnamed_addr global i32 3
+@main.closedOk = local_unnamed_addr global i1 false
+@"main$chan" = internal global %runtime.channel { i32 4, i32 1, i8 3, %runtime.channelBlockedList* null, i32 0, i32 0, i32 1, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @"main$chanbuf", i32 0, i32 0) }
+@"main$chanbuf" = internal global [4 x i8] c"\01\00\00\00", align 4
+@"main$alloca" = internal global i32 2
+@"main$chan.1" = internal global %runtime.channel { i32 4, i32 3, i8 3, %runtime.channelBlockedList* null, i32 2, i32 0, i32 2, i8* getelementptr inbounds ([12 x i8], [12 x i8]* @"main$chanbuf.2", i32 0, i32 0) }
+@"main$chanbuf.2" = internal global [12 x i8] c"\07\00\00\00\09\00\00\00\00\00\00\00", align 4
+@"main$chan.3" = internal global %runtime.channel { i32 4, i32 0, i8 4, %runtime.channelBlockedList* null, i32 0, i32 0, i32 0, i8* null }
+
+declare void @runtime.chanSend(%runtime.channel*, i8*, %runtime.channelBlockedList*, i8*, i8*) local_unnamed_addr
+
+declare i32 @runtime.chanLen(%runtime.channel*, i8*, i8*) local_unnamed_addr
+
+define void @runtime.initAll() unnamed_addr {
+entry:
+  call void @runtime.chanSend(%runtime.channel* @"main$chan", i8* bitcast (i32* @"main$alloca" to i8*), %runtime.channelBlockedList* null, i8* undef, i8* null)
+  %len = call i32 @runtime.chanLen(%runtime.channel* @"main$chan", i8* undef, i8* null)
+  ret void
+}
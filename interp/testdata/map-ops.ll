@@ -0,0 +1,138 @@
+target datalayout = "e-m:e-p:32:32-Fi8-i64:64-v128:64:128-a:0:32-n32-S64"
+target triple = "armv6m-none-eabi"
+
+%runtime._string = type { i8*, i32 }
+%runtime.hashmap = type { %runtime.hashmap*, i8*, i32, i8, i8, i8 }
+%runtime.hashmapIterator = type { i32, i8*, i8 }
+
+@main.binaryMap = global %runtime.hashmap* null
+@main.stringMap = global %runtime.hashmap* null
+@main.externalMap = global %runtime.hashmap* null
+@main.got = global i8 0
+@main.gotOk = global i1 false
+@main.missingOk = global i1 true
+@main.gotString = global i8 0
+@main.len = global i32 0
+@main.sum = global i8 0
+@main.string.a = internal unnamed_addr constant [1 x i8] c"a"
+@main.string.b = internal unnamed_addr constant [1 x i8] c"b"
+
+declare %runtime.hashmap* @runtime.hashmapMake(i8, i8, i32, i8* %context, i8* %parentHandle)
+declare void @runtime.hashmapBinarySet(%runtime.hashmap*, i8*, i8*, i8* %context, i8* %parentHandle)
+declare void @runtime.hashmapStringSet(%runtime.hashmap*, i8*, i32, i8*, i8* %context, i8* %parentHandle)
+declare i1 @runtime.hashmapBinaryGet(%runtime.hashmap*, i8*, i8*, i32, i8* %context, i8* %parentHandle)
+declare i1 @runtime.hashmapStringGet(%runtime.hashmap*, i8*, i32, i8*, i32, i8* %context, i8* %parentHandle)
+declare void @runtime.hashmapBinaryDelete(%runtime.hashmap*, i8*, i8* %context, i8* %parentHandle)
+declare void @runtime.hashmapStringDelete(%runtime.hashmap*, i8*, i32, i8* %context, i8* %parentHandle)
+declare i32 @runtime.hashmapLen(%runtime.hashmap*, i8* %context, i8* %parentHandle)
+declare i1 @runtime.hashmapNext(%runtime.hashmap*, i8*, i8*, i8*, i8* %context, i8* %parentHandle)
+declare void @main.useMap(%runtime.hashmap*)
+
+define void @runtime.initAll() unnamed_addr {
+entry:
+  call void @main.init(i8* undef, i8* null)
+  ret void
+}
+
+define internal void @main.init(i8* %context, i8* %parentHandle) unnamed_addr {
+entry:
+  call void @main.testBinary()
+  call void @main.testString()
+  call void @main.testRange()
+  call void @main.testExternal()
+  ret void
+}
+
+; Test lookups, deletes and len() on a map with binary keys.
+define internal void @main.testBinary() {
+entry:
+  %key = alloca i8
+  %value = alloca i8
+  %map = call %runtime.hashmap* @runtime.hashmapMake(i8 1, i8 1, i32 0, i8* undef, i8* null)
+  store %runtime.hashmap* %map, %runtime.hashmap** @main.binaryMap
+  store i8 1, i8* %key
+  store i8 10, i8* %value
+  call void @runtime.hashmapBinarySet(%runtime.hashmap* %map, i8* %key, i8* %value, i8* undef, i8* null)
+  store i8 2, i8* %key
+  store i8 20, i8* %value
+  call void @runtime.hashmapBinarySet(%runtime.hashmap* %map, i8* %key, i8* %value, i8* undef, i8* null)
+  store i8 3, i8* %key
+  store i8 30, i8* %value
+  call void @runtime.hashmapBinarySet(%runtime.hashmap* %map, i8* %key, i8* %value, i8* undef, i8* null)
+  ; v, ok := m[1]
+  store i8 1, i8* %key
+  %ok = call i1 @runtime.hashmapBinaryGet(%runtime.hashmap* %map, i8* %key, i8* %value, i32 1, i8* undef, i8* null)
+  %got = load i8, i8* %value
+  store i8 %got, i8* @main.got
+  store i1 %ok, i1* @main.gotOk
+  ; delete(m, 2)
+  store i8 2, i8* %key
+  call void @runtime.hashmapBinaryDelete(%runtime.hashmap* %map, i8* %key, i8* undef, i8* null)
+  ; _, ok := m[2]
+  %missingOk = call i1 @runtime.hashmapBinaryGet(%runtime.hashmap* %map, i8* %key, i8* %value, i32 1, i8* undef, i8* null)
+  store i1 %missingOk, i1* @main.missingOk
+  %len = call i32 @runtime.hashmapLen(%runtime.hashmap* %map, i8* undef, i8* null)
+  store i32 %len, i32* @main.len
+  ret void
+}
+
+; Test lookups and deletes on a map with string keys.
+define internal void @main.testString() {
+entry:
+  %value = alloca i8
+  %map = call %runtime.hashmap* @runtime.hashmapMake(i8 8, i8 1, i32 0, i8* undef, i8* null)
+  store %runtime.hashmap* %map, %runtime.hashmap** @main.stringMap
+  store i8 1, i8* %value
+  call void @runtime.hashmapStringSet(%runtime.hashmap* %map, i8* getelementptr inbounds ([1 x i8], [1 x i8]* @main.string.a, i32 0, i32 0), i32 1, i8* %value, i8* undef, i8* null)
+  store i8 2, i8* %value
+  call void @runtime.hashmapStringSet(%runtime.hashmap* %map, i8* getelementptr inbounds ([1 x i8], [1 x i8]* @main.string.b, i32 0, i32 0), i32 1, i8* %value, i8* undef, i8* null)
+  call void @runtime.hashmapStringDelete(%runtime.hashmap* %map, i8* getelementptr inbounds ([1 x i8], [1 x i8]* @main.string.a, i32 0, i32 0), i32 1, i8* undef, i8* null)
+  %ok = call i1 @runtime.hashmapStringGet(%runtime.hashmap* %map, i8* getelementptr inbounds ([1 x i8], [1 x i8]* @main.string.b, i32 0, i32 0), i32 1, i8* %value, i32 1, i8* undef, i8* null)
+  %got = load i8, i8* %value
+  store i8 %got, i8* @main.gotString
+  ret void
+}
+
+; Test ranging over a map: sum all values of @main.binaryMap.
+define internal void @main.testRange() {
+entry:
+  %it = alloca %runtime.hashmapIterator
+  %it.bitcast = bitcast %runtime.hashmapIterator* %it to i8*
+  %key = alloca i8
+  %value = alloca i8
+  store %runtime.hashmapIterator zeroinitializer, %runtime.hashmapIterator* %it
+  %map = load %runtime.hashmap*, %runtime.hashmap** @main.binaryMap
+  br label %loop
+
+loop:
+  %sum = phi i8 [ 0, %entry ], [ %sum.next, %body ]
+  %ok = call i1 @runtime.hashmapNext(%runtime.hashmap* %map, i8* %it.bitcast, i8* %key, i8* %value, i8* undef, i8* null)
+  br i1 %ok, label %body, label %done
+
+body:
+  %v = load i8, i8* %value
+  %sum.next = add i8 %sum, %v
+  br label %loop
+
+done:
+  store i8 %sum, i8* @main.sum
+  ret void
+}
+
+; Test that a map that was passed to a function that is run at runtime is also
+; modified at runtime.
+define internal void @main.testExternal() {
+entry:
+  %key = alloca i8
+  %value = alloca i8
+  %map = call %runtime.hashmap* @runtime.hashmapMake(i8 1, i8 1, i32 0, i8* undef, i8* null)
+  store %runtime.hashmap* %map, %runtime.hashmap** @main.externalMap
+  store i8 1, i8* %key
+  store i8 10, i8* %value
+  call void @runtime.hashmapBinarySet(%runtime.hashmap* %map, i8* %key, i8* %value, i8* undef, i8* null)
+  call void @main.useMap(%runtime.hashmap* %map)
+  call void @runtime.hashmapBinaryDelete(%runtime.hashmap* %map, i8* %key, i8* undef, i8* null)
+  store i8 2, i8* %key
+  call void @runtime.hashmapBinarySet(%runtime.hashmap* %map, i8* %key, i8* %value, i8* undef, i8* null)
+  ret void
+}
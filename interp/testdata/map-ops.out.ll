@@ -0,0 +1,38 @@
+target datalayout = "e-m:e-p:32:32-Fi8-i64:64-v128:64:128-a:0:32-n32-S64"
+target triple = "armv6m-none-eabi"
+
+%runtime.hashmap = type { %runtime.hashmap*, i8*, i32, i8, i8, i8 }
+
+@main.binaryMap = local_unnamed_addr global %runtime.hashmap* @"main$map.2"
+@main.stringMap = local_unnamed_addr global %runtime.hashmap* @"main$map.4"
+@main.externalMap = local_unnamed_addr global %runtime.hashmap* @"main$map"
+@main.got = local_unnamed_addr global i8 10
+@main.gotOk = local_unnamed_addr global i1 true
+@main.missingOk = local_unnamed_addr global i1 false
+@main.gotString = local_unnamed_addr global i8 2
+@main.len = local_unnamed_addr global i32 2
+@main.sum = local_unnamed_addr global i8 40
+@main.string.b = internal unnamed_addr constant [1 x i8] c"b"
+@"main$map" = internal global %runtime.hashmap { %runtime.hashmap* null, i8* getelementptr inbounds ({ [8 x i8], i8*, { i8, [7 x i8] }, { i8, [7 x i8] } }, { [8 x i8], i8*, { i8, [7 x i8] }, { i8, [7 x i8] } }* @"main$mapbucket", i32 0, i32 0, i32 0), i32 1, i8 1, i8 1, i8 0 }
+@"main$mapbucket" = internal unnamed_addr global { [8 x i8], i8*, { i8, [7 x i8] }, { i8, [7 x i8] } } { [8 x i8] c"\04\00\00\00\00\00\00\00", i8* null, { i8, [7 x i8] } { i8 1, [7 x i8] zeroinitializer }, { i8, [7 x i8] } { i8 10, [7 x i8] zeroinitializer } }
+@"main$alloca" = internal global i8 1
+@"main$alloca.1" = internal global i8 10
+@"main$map.2" = internal global %runtime.hashmap { %runtime.hashmap* null, i8* getelementptr inbounds ({ [8 x i8], i8*, { i8, i8, [6 x i8] }, { i8, i8, [6 x i8] } }, { [8 x i8], i8*, { i8, i8, [6 x i8] }, { i8, i8, [6 x i8] } }* @"main$mapbucket.3", i32 0, i32 0, i32 0), i32 2, i8 1, i8 1, i8 0 }
+@"main$mapbucket.3" = internal unnamed_addr global { [8 x i8], i8*, { i8, i8, [6 x i8] }, { i8, i8, [6 x i8] } } { [8 x i8] c"\04\06\00\00\00\00\00\00", i8* null, { i8, i8, [6 x i8] } { i8 1, i8 3, [6 x i8] zeroinitializer }, { i8, i8, [6 x i8] } { i8 10, i8 30, [6 x i8] zeroinitializer } }
+@"main$map.4" = internal global %runtime.hashmap { %runtime.hashmap* null, i8* getelementptr inbounds ({ [8 x i8], i8*, { { [1 x i8]*, [4 x i8] }, [56 x i8] }, { i8, [7 x i8] } }, { [8 x i8], i8*, { { [1 x i8]*, [4 x i8] }, [56 x i8] }, { i8, [7 x i8] } }* @"main$mapbucket.5", i32 0, i32 0, i32 0), i32 1, i8 8, i8 1, i8 0 }
+@"main$mapbucket.5" = internal unnamed_addr global { [8 x i8], i8*, { { [1 x i8]*, [4 x i8] }, [56 x i8] }, { i8, [7 x i8] } } { [8 x i8] c"\E7\00\00\00\00\00\00\00", i8* null, { { [1 x i8]*, [4 x i8] }, [56 x i8] } { { [1 x i8]*, [4 x i8] } { [1 x i8]* @main.string.b, [4 x i8] c"\01\00\00\00" }, [56 x i8] zeroinitializer }, { i8, [7 x i8] } { i8 2, [7 x i8] zeroinitializer } }
+
+declare void @runtime.hashmapBinarySet(%runtime.hashmap*, i8*, i8*, i8*, i8*) local_unnamed_addr
+
+declare void @runtime.hashmapBinaryDelete(%runtime.hashmap*, i8*, i8*, i8*) local_unnamed_addr
+
+declare void @main.useMap(%runtime.hashmap*) local_unnamed_addr
+
+define void @runtime.initAll() unnamed_addr {
+entry:
+  call void @main.useMap(%runtime.hashmap* @"main$map")
+  call void @runtime.hashmapBinaryDelete(%runtime.hashmap* @"main$map", i8* @"main$alloca", i8* undef, i8* null)
+  store i8 2, i8* @"main$alloca", align 1
+  call void @runtime.hashmapBinarySet(%runtime.hashmap* @"main$map", i8* @"main$alloca", i8* @"main$alloca.1", i8* undef, i8* null)
+  ret void
+}
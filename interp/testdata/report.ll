@@ -0,0 +1,40 @@
+target datalayout = "e-m:e-i64:64-f80:128-n8:16:32:64-S128"
+target triple = "x86_64--linux"
+
+@main.v1 = internal global i64 0
+@main.v2 = internal global i32 0
+@foo.v = internal global i64 0
+
+define void @runtime.initAll() unnamed_addr {
+entry:
+  call void @foo.init()
+  call void @main.init()
+  ret void
+}
+
+define internal void @foo.init() unnamed_addr {
+entry:
+  store i64 3, i64* @foo.v
+  ret void
+}
+
+define internal void @main.init() unnamed_addr !dbg !5 {
+entry:
+  store i64 5, i64* @main.v1
+  ; Read from a memory-mapped I/O register: this can only be done at runtime.
+  %value = load volatile i32, i32* inttoptr (i64 16 to i32*), !dbg !8
+  store i32 %value, i32* @main.v2
+  ret void
+}
+
+!llvm.dbg.cu = !{!0}
+!llvm.module.flags = !{!3, !4}
+
+!0 = distinct !DICompileUnit(language: DW_LANG_C99, file: !1, producer: "TinyGo", isOptimized: true, runtimeVersion: 0, emissionKind: FullDebug, enums: !2)
+!1 = !DIFile(filename: "main.go", directory: "/src")
+!2 = !{}
+!3 = !{i32 2, !"Debug Info Version", i32 3}
+!4 = !{i32 7, !"Dwarf Version", i32 4}
+!5 = distinct !DISubprogram(name: "main.init", scope: !1, file: !1, line: 10, type: !6, scopeLine: 10, spFlags: DISPFlagDefinition | DISPFlagOptimized, unit: !0, retainedNodes: !2)
+!6 = !DISubroutineType(types: !2)
+!8 = !DILocation(line: 12, column: 7, scope: !5)
@@ -14,8 +14,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 
 	"github.com/tinygo-org/tinygo/cgo"
@@ -46,6 +48,9 @@ type PackageJSON struct {
 	Name       string
 	ForTest    string
 
+	// Module information, only set in module mode.
+	Module *Module
+
 	// Source files
 	GoFiles  []string
 	CgoFiles []string
@@ -63,6 +68,15 @@ type PackageJSON struct {
 	}
 }
 
+// Module is a subset of the module information returned from `go list`.
+type Module struct {
+	Path    string
+	Version string
+	Replace *Module
+	Main    bool
+	Dir     string
+}
+
 // Package holds a loaded package, its imports, and its parsed files.
 type Package struct {
 	PackageJSON
@@ -71,6 +85,11 @@ type Package struct {
 	Files   []*ast.File
 	Pkg     *types.Package
 	info    types.Info
+
+	// Information obtained during CGo processing.
+	CFlags       []string // flags from #cgo CFLAGS lines
+	ExportHeader string   // contents of _cgo_export.h, empty if nothing is exported
+	ldflags      []string // flags from #cgo LDFLAGS lines, see Program.LDFlags
 }
 
 // Load loads the given package with all dependencies (including the runtime
@@ -242,6 +261,45 @@ func (p *Program) getOriginalPath(path string) string {
 	return originalPath
 }
 
+// TrimPath returns the given (absolute) source path with the machine-specific
+// prefix removed, like the -trimpath flag of the go command. Files in the
+// standard library become relative to GOROOT/src (for example
+// "runtime/scheduler.go"), files in a module are prefixed with the module path
+// and version instead of the module directory, and other files are prefixed
+// with their import path. Paths not part of the program are returned unchanged.
+func (p *Program) TrimPath(path string) string {
+	for _, root := range []string{goenv.Get("GOROOT"), goenv.Get("TINYGOROOT"), p.goroot} {
+		if relpath, ok := relativePath(filepath.Join(root, "src"), path); ok {
+			return relpath
+		}
+	}
+	dir := filepath.Dir(path)
+	for _, pkg := range p.sorted {
+		if pkg.Module != nil && pkg.Module.Dir != "" {
+			if relpath, ok := relativePath(pkg.Module.Dir, path); ok {
+				prefix := pkg.Module.Path
+				if pkg.Module.Version != "" {
+					prefix += "@" + pkg.Module.Version
+				}
+				return prefix + "/" + relpath
+			}
+		}
+		if pkg.Dir == dir {
+			return pkg.ImportPath + "/" + filepath.Base(path)
+		}
+	}
+	return path
+}
+
+// relativePath returns path relative to dir, using forward slashes, if path is
+// inside dir.
+func relativePath(dir, path string) (string, bool) {
+	if !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(path[len(dir)+1:]), true
+}
+
 // Sorted returns a list of all packages, sorted in a way that no packages come
 // before the packages they depend upon.
 func (p *Program) Sorted() []*Package {
@@ -260,18 +318,60 @@ func (p *Program) MainPkg() *Package {
 //
 // Idempotent.
 func (p *Program) Parse() error {
-	// Parse all packages.
-	// TODO: do this in parallel.
-	for _, pkg := range p.sorted {
-		err := pkg.Parse()
+	// Parse all packages. Parsing (including CGo processing) doesn't depend on
+	// other packages, so all packages are parsed in parallel.
+	errs := make([]error, len(p.sorted))
+	limiter := make(chan struct{}, runtime.NumCPU())
+	var wg sync.WaitGroup
+	for i, pkg := range p.sorted {
+		wg.Add(1)
+		go func(i int, pkg *Package) {
+			defer wg.Done()
+			limiter <- struct{}{}
+			errs[i] = pkg.Parse()
+			<-limiter
+		}(i, pkg)
+	}
+	wg.Wait()
+	for _, err := range errs {
 		if err != nil {
 			return err
 		}
 	}
 
-	// Typecheck all packages.
+	// Collect the linker flags in a deterministic order.
+	p.LDFlags = nil
+	for _, pkg := range p.sorted {
+		p.LDFlags = append(p.LDFlags, pkg.ldflags...)
+	}
+
+	// Typecheck all packages. A package is typechecked as soon as all the
+	// packages it imports have been typechecked, so that independent parts of
+	// the import graph are typechecked in parallel.
+	done := make(map[*Package]chan struct{}, len(p.sorted))
 	for _, pkg := range p.sorted {
-		err := pkg.Check()
+		done[pkg] = make(chan struct{})
+	}
+	for i, pkg := range p.sorted {
+		wg.Add(1)
+		go func(i int, pkg *Package) {
+			defer wg.Done()
+			defer close(done[pkg])
+			for _, imported := range pkg.imports() {
+				<-done[imported]
+				if imported.Pkg == nil {
+					// The imported package failed to typecheck. That error is
+					// reported instead, as it comes earlier in p.sorted.
+					return
+				}
+			}
+			limiter <- struct{}{}
+			errs[i] = pkg.Check()
+			<-limiter
+		}(i, pkg)
+	}
+	wg.Wait()
+	for _, err := range errs {
 		if err != nil {
 			return err
 		}
@@ -389,12 +489,14 @@ func (p *Package) parseFiles() ([]*ast.File, error) {
 		if p.program.clangHeaders != "" {
 			cflags = append(cflags, "-Xclang", "-internal-isystem", "-Xclang", p.program.clangHeaders)
 		}
-		generated, ldflags, errs := cgo.Process(files, p.program.workingDir, p.program.fset, cflags)
+		generated, exportHeader, pkgCFlags, ldflags, errs := cgo.Process(files, p.program.workingDir, p.program.fset, cflags)
 		if errs != nil {
 			fileErrs = append(fileErrs, errs...)
 		}
 		files = append(files, generated)
-		p.program.LDFlags = append(p.program.LDFlags, ldflags...)
+		p.CFlags = pkgCFlags
+		p.ExportHeader = exportHeader
+		p.ldflags = ldflags
 	}
 
 	// Only return an error after CGo processing, so that errors in parsing and
@@ -406,6 +508,21 @@ func (p *Package) parseFiles() ([]*ast.File, error) {
 	return files, nil
 }
 
+// imports returns the packages directly imported by this package that are part
+// of the program.
+func (p *Package) imports() []*Package {
+	var imports []*Package
+	for _, to := range p.Imports {
+		if newTo, ok := p.ImportMap[to]; ok && !strings.HasSuffix(newTo, ".test]") {
+			to = newTo
+		}
+		if imported, ok := p.program.Packages[to]; ok {
+			imports = append(imports, imported)
+		}
+	}
+	return imports
+}
+
 // Import implements types.Importer. It loads and parses packages it encounters
 // along the way, if needed.
 func (p *Package) Import(to string) (*types.Package, error) {
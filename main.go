@@ -19,9 +19,11 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/google/shlex"
 	"github.com/mattn/go-colorable"
 	"github.com/tinygo-org/tinygo/builder"
 	"github.com/tinygo-org/tinygo/compileopts"
+	"github.com/tinygo-org/tinygo/flash"
 	"github.com/tinygo-org/tinygo/goenv"
 	"github.com/tinygo-org/tinygo/interp"
 	"github.com/tinygo-org/tinygo/loader"
@@ -235,6 +237,10 @@ func Flash(pkgName, port string, options *compileopts.Options) error {
 		fileExt = filepath.Ext(config.Target.FlashFilename)
 	case "openocd":
 		fileExt = ".hex"
+	case "bossa", "stk500v1":
+		// Built-in implementations of bootloader protocols that otherwise
+		// require bossac or avrdude.
+		fileExt = ".hex"
 	case "native":
 		return errors.New("unknown flash method \"native\" - did you miss a -target flag?")
 	default:
@@ -330,6 +336,19 @@ func Flash(pkgName, port string, options *compileopts.Options) error {
 				return &commandError{"failed to flash", result.Binary, err}
 			}
 			return nil
+		case "bossa", "stk500v1":
+			if port == "" {
+				var err error
+				port, err = getDefaultPort()
+				if err != nil {
+					return err
+				}
+			}
+			err := flashUsingSerialBootloader(flashMethod, port, result.Binary, config)
+			if err != nil {
+				return &commandError{"failed to flash", result.Binary, err}
+			}
+			return nil
 		default:
 			return fmt.Errorf("unknown flash method: %s", flashMethod)
 		}
@@ -575,6 +594,42 @@ func touchSerialPortAt1200bps(port string) (err error) {
 	return fmt.Errorf("opening port: %s", err)
 }
 
+// flashUsingSerialBootloader flashes the given .hex file to a bootloader that
+// is reachable over a serial port, using one of the built-in protocols.
+func flashUsingSerialBootloader(method, port, tmppath string, config *compileopts.Config) error {
+	image, err := flash.LoadHex(tmppath)
+	if err != nil {
+		return err
+	}
+
+	baudRate := int(config.Target.FlashBaudRate)
+	if baudRate == 0 {
+		baudRate = 115200
+	}
+	p, err := serial.Open(port, &serial.Mode{BaudRate: baudRate})
+	if err != nil {
+		return fmt.Errorf("opening port: %s", err)
+	}
+	defer p.Close()
+
+	switch method {
+	case "bossa":
+		return flash.SAMBA(p, image)
+	case "stk500v1":
+		// Reset the chip by toggling DTR (like avrdude does), so that the
+		// bootloader starts running.
+		p.SetDTR(false)
+		p.SetRTS(false)
+		time.Sleep(250 * time.Millisecond)
+		p.SetDTR(true)
+		p.SetRTS(true)
+		time.Sleep(50 * time.Millisecond)
+		return flash.STK500v1(p, image, config.CPU())
+	default:
+		panic("unreachable")
+	}
+}
+
 const maxMSDRetries = 10
 
 func flashUF2UsingMSD(volume, tmppath string, options *compileopts.Options) error {
@@ -815,6 +870,52 @@ func handleCompilerError(err error) {
 	}
 }
 
+// parseLDFlags parses the -ldflags flag. The -X flag (to set a string global)
+// is handled by the compiler, all other flags are passed to the linker. Like
+// the go command, the flags are split using shell quoting rules so that values
+// may contain spaces.
+func parseLDFlags(s string) (ldflags []string, globalValues map[string]map[string]string, err error) {
+	fields, err := shlex.Split(s)
+	if err != nil {
+		return nil, nil, fmt.Errorf("-ldflags: %w", err)
+	}
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		var definition string
+		switch {
+		case field == "-X" || field == "--X":
+			if i+1 == len(fields) {
+				return nil, nil, errors.New("-ldflags: missing argument to -X")
+			}
+			i++
+			definition = fields[i]
+		case strings.HasPrefix(field, "-X="):
+			definition = field[len("-X="):]
+		case strings.HasPrefix(field, "--X="):
+			definition = field[len("--X="):]
+		default:
+			ldflags = append(ldflags, field)
+			continue
+		}
+
+		// The definition has the form importpath.name=value.
+		eq := strings.IndexByte(definition, '=')
+		dot := strings.LastIndexByte(definition[:eq+1], '.')
+		if eq <= 0 || dot <= 0 || dot+1 == eq {
+			return nil, nil, fmt.Errorf("-ldflags: -X flag requires argument of the form importpath.name=value, got %#v", definition)
+		}
+		pkgPath, name, value := definition[:dot], definition[dot+1:eq], definition[eq+1:]
+		if globalValues == nil {
+			globalValues = make(map[string]map[string]string)
+		}
+		if globalValues[pkgPath] == nil {
+			globalValues[pkgPath] = make(map[string]string)
+		}
+		globalValues[pkgPath][name] = value
+	}
+	return ldflags, globalValues, nil
+}
+
 func main() {
 	if len(os.Args) < 2 {
 		fmt.Fprintln(os.Stderr, "No command-line arguments supplied.")
@@ -834,6 +935,9 @@ func main() {
 	target := flag.String("target", "", "LLVM target | .json file with TargetSpec")
 	printSize := flag.String("size", "", "print sizes (none, short, full)")
 	printStacks := flag.Bool("print-stacks", false, "print stack sizes of goroutines")
+	printInterp := flag.Bool("print-interp", false, "print which package initializers run at compile time")
+	printReach := flag.String("print-reachability", "", "print why the given function, global or type is included in the program")
+	lto := flag.Bool("lto", false, "compile C files to LLVM bitcode and optimize them together with Go code")
 	printCommands := flag.Bool("x", false, "Print commands")
 	nodebug := flag.Bool("no-debug", false, "disable DWARF debug symbol generation")
 	ocdOutput := flag.Bool("ocd-output", false, "print OCD daemon output during debug")
@@ -841,7 +945,12 @@ func main() {
 	programmer := flag.String("programmer", "", "which hardware programmer to use")
 	cFlags := flag.String("cflags", "", "additional cflags for compiler")
 	ldFlags := flag.String("ldflags", "", "additional ldflags for linker")
-	wasmAbi := flag.String("wasm-abi", "", "WebAssembly ABI conventions: js (no i64 params) or generic")
+	wasmAbi := flag.String("wasm-abi", "", "WebAssembly ABI conventions: js (no i64 params), generic, or canonical (strings, slices and structs lowered to ptr/len)")
+	jsLoader := flag.Bool("js-loader", false, "write an ES module loader and TypeScript declarations next to the .wasm file (GOOS=js only)")
+	signingKey := flag.String("signing-key", "", "private key (PEM file) to sign firmware images with")
+	imageVersion := flag.String("image-version", "", "firmware image version (major.minor.revision+build)")
+	trimpath := flag.Bool("trimpath", false, "remove file system paths from the resulting executable")
+	buildID := flag.Bool("build-id", false, "embed a build ID derived from the output file in a note section")
 
 	var flagJSON, flagDeps *bool
 	if command == "help" || command == "list" {
@@ -852,6 +961,10 @@ func main() {
 	if command == "help" || command == "build" || command == "build-library" || command == "test" {
 		flag.StringVar(&outpath, "o", "", "output filename")
 	}
+	var binaryFormat string
+	if command == "help" || command == "build" {
+		flag.StringVar(&binaryFormat, "format", "", "output file format, instead of deriving it from the -o file extension ("+strings.Join(compileopts.BinaryFormats(), ", ")+")")
+	}
 	var testCompileOnlyFlag *bool
 	if command == "help" || command == "test" {
 		testCompileOnlyFlag = flag.Bool("c", false, "compile the test binary but do not run it")
@@ -882,10 +995,19 @@ func main() {
 		Debug:         !*nodebug,
 		PrintSizes:    *printSize,
 		PrintStacks:   *printStacks,
+		PrintInterp:   *printInterp,
+		PrintReach:    *printReach,
+		LTO:           *lto,
 		PrintCommands: *printCommands,
 		Tags:          *tags,
 		WasmAbi:       *wasmAbi,
+		JSLoader:      *jsLoader,
 		Programmer:    *programmer,
+		BinaryFormat:  binaryFormat,
+		SigningKey:    *signingKey,
+		ImageVersion:  *imageVersion,
+		TrimPath:      *trimpath,
+		BuildID:       *buildID,
 	}
 
 	if *cFlags != "" {
@@ -893,7 +1015,13 @@ func main() {
 	}
 
 	if *ldFlags != "" {
-		options.LDFlags = strings.Split(*ldFlags, " ")
+		var err error
+		options.LDFlags, options.GlobalValues, err = parseLDFlags(*ldFlags)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			usage()
+			os.Exit(1)
+		}
 	}
 
 	os.Setenv("CC", "clang -target="+*target)
@@ -6,6 +6,7 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"debug/elf"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -58,9 +59,41 @@ func TestCompiler(t *testing.T) {
 	if runtime.GOOS != "windows" {
 		t.Run("Host", func(t *testing.T) {
 			runPlatTests("", matches, t)
-			if runtime.GOOS == "darwin" {
-				runTest("testdata/libc/env.go", "", t, []string{"ENV1=VALUE1", "ENV2=VALUE2"}...)
+			runTest("testdata/libc/env.go", "", t, []string{"first", "second"}, []string{"ENV1=VALUE1", "ENV2=VALUE2"})
+			runTest("testdata/machine/simulator.go", "", t, nil, nil)
+			runTest("testdata/machine/devices.go", "", t, nil, nil)
+			if runtime.GOOS == "linux" {
+				runTest("testdata/machine/pty.go", "", t, nil, nil)
 			}
+			runTest("testdata/filesystem/filesystem.go", "", t, nil, nil)
+			t.Run("ldflags", func(t *testing.T) {
+				t.Parallel()
+				ldflags, globalValues, err := parseLDFlags("-X main.someGlobal=foobar -X=main.otherGlobal=changed -X 'main.quotedGlobal=a b'")
+				if err != nil {
+					t.Fatal("could not parse ldflags:", err)
+				}
+				config := &compileopts.Options{
+					Opt:          "z",
+					VerifyIR:     true,
+					Debug:        true,
+					LDFlags:      ldflags,
+					GlobalValues: globalValues,
+				}
+				runTestWithConfig(filepath.Join(TESTDATA, "ldflags")+string(filepath.Separator), "", t, config, nil, nil)
+			})
+			t.Run("cgo-lto", func(t *testing.T) {
+				t.Parallel()
+				// Build the C files to bitcode and link them with the Go code,
+				// with debug information so that the module flags of Clang
+				// and the compiler must be compatible.
+				config := &compileopts.Options{
+					Opt:      "z",
+					VerifyIR: true,
+					Debug:    true,
+					LTO:      true,
+				}
+				runTestWithConfig(filepath.Join(TESTDATA, "cgo")+string(filepath.Separator), "", t, config, nil, nil)
+			})
 		})
 	}
 
@@ -107,7 +140,44 @@ func TestCompiler(t *testing.T) {
 
 		t.Run("WASI", func(t *testing.T) {
 			runPlatTests("wasi", matches, t)
-			runTest("testdata/libc/env.go", "wasi", t, []string{"ENV1=VALUE1", "ENV2=VALUE2"}...)
+			runTest("testdata/libc/env.go", "wasi", t, []string{"first", "second"}, []string{"ENV1=VALUE1", "ENV2=VALUE2"})
+			runTest("testdata/wasi/io.go", "wasi", t, nil, nil)
+			runTest("testdata/filesystem/filesystem.go", "wasi", t, nil, nil)
+			t.Run("io.go-tasks", func(t *testing.T) {
+				t.Parallel()
+				requireWasmOpt(t)
+				config := &compileopts.Options{
+					Target:    "wasi",
+					Opt:       "z",
+					VerifyIR:  true,
+					Debug:     true,
+					Scheduler: "tasks",
+				}
+				runTestWithConfig("testdata/wasi/io.go", "wasi", t, config, nil, nil)
+			})
+			t.Run("stdin.go-tasks", func(t *testing.T) {
+				t.Parallel()
+				requireWasmOpt(t)
+				runWasiStdinTest(t)
+			})
+			// Goroutines (started directly and through a function value)
+			// must pause instead of return when they exit with the tasks
+			// scheduler on WebAssembly.
+			for _, name := range []string{"channel.go", "coroutines.go"} {
+				name := name
+				t.Run(name+"-tasks", func(t *testing.T) {
+					t.Parallel()
+					requireWasmOpt(t)
+					config := &compileopts.Options{
+						Target:    "wasi",
+						Opt:       "z",
+						VerifyIR:  true,
+						Debug:     true,
+						Scheduler: "tasks",
+					}
+					runTestWithConfig(filepath.Join(TESTDATA, name), "wasi", t, config, nil, nil)
+				})
+			}
 		})
 	}
 }
@@ -119,7 +189,7 @@ func runPlatTests(target string, matches []string, t *testing.T) {
 		path := path // redefine to avoid race condition
 		t.Run(filepath.Base(path), func(t *testing.T) {
 			t.Parallel()
-			runTest(path, target, t)
+			runTest(path, target, t, nil, nil)
 		})
 	}
 }
@@ -136,7 +206,21 @@ func runBuild(src, out string, opts *compileopts.Options) error {
 	return Build(src, out, opts)
 }
 
-func runTest(path, target string, t *testing.T, environmentVars ...string) {
+func runTest(path, target string, t *testing.T, cmdArgs, environmentVars []string) {
+	config := &compileopts.Options{
+		Target:     target,
+		Opt:        "z",
+		PrintIR:    false,
+		DumpSSA:    false,
+		VerifyIR:   true,
+		Debug:      true,
+		PrintSizes: "",
+		WasmAbi:    "",
+	}
+	runTestWithConfig(path, target, t, config, cmdArgs, environmentVars)
+}
+
+func runTestWithConfig(path, target string, t *testing.T, config *compileopts.Options, cmdArgs, environmentVars []string) {
 	// Get the expected output for this test.
 	txtpath := path[:len(path)-3] + ".txt"
 	if path[len(path)-1] == os.PathSeparator {
@@ -160,17 +244,6 @@ func runTest(path, target string, t *testing.T, environmentVars ...string) {
 	}()
 
 	// Build the test binary.
-	config := &compileopts.Options{
-		Target:     target,
-		Opt:        "z",
-		PrintIR:    false,
-		DumpSSA:    false,
-		VerifyIR:   true,
-		Debug:      true,
-		PrintSizes: "",
-		WasmAbi:    "",
-	}
-
 	binary := filepath.Join(tmpdir, "test")
 	err = runBuild("./"+path, binary, config)
 	if err != nil {
@@ -179,12 +252,12 @@ func runTest(path, target string, t *testing.T, environmentVars ...string) {
 		return
 	}
 
-	// Run the test.
+	// Run the test, in the temporary directory so that it can create files.
 	runComplete := make(chan struct{})
 	var cmd *exec.Cmd
 	ranTooLong := false
 	if target == "" {
-		cmd = exec.Command(binary)
+		cmd = exec.Command(binary, cmdArgs...)
 		cmd.Env = append(cmd.Env, environmentVars...)
 	} else {
 		spec, err := compileopts.LoadTarget(target)
@@ -192,20 +265,25 @@ func runTest(path, target string, t *testing.T, environmentVars ...string) {
 			t.Fatal("failed to load target spec:", err)
 		}
 		if len(spec.Emulator) == 0 {
-			cmd = exec.Command(binary)
-		} else {
-			args := append(spec.Emulator[1:], binary)
-			cmd = exec.Command(spec.Emulator[0], args...)
-		}
-
-		if len(spec.Emulator) != 0 && spec.Emulator[0] == "wasmtime" {
+			cmd = exec.Command(binary, cmdArgs...)
+			cmd.Env = append(cmd.Env, environmentVars...)
+		} else if spec.Emulator[0] == "wasmtime" {
+			// Environment variables and the preopened current directory are
+			// passed to wasmtime as options, which must come before the
+			// binary.
+			args := append(spec.Emulator[1:], "--dir=.")
 			for _, v := range environmentVars {
-				cmd.Args = append(cmd.Args, "--env", v)
+				args = append(args, "--env", v)
 			}
+			args = append(append(args, binary), cmdArgs...)
+			cmd = exec.Command(spec.Emulator[0], args...)
 		} else {
+			args := append(append(spec.Emulator[1:], binary), cmdArgs...)
+			cmd = exec.Command(spec.Emulator[0], args...)
 			cmd.Env = append(cmd.Env, environmentVars...)
 		}
 	}
+	cmd.Dir = tmpdir
 	stdout := &bytes.Buffer{}
 	cmd.Stdout = stdout
 	cmd.Stderr = os.Stderr
@@ -265,6 +343,298 @@ func runTest(path, target string, t *testing.T, environmentVars ...string) {
 	}
 }
 
+// requireWasmOpt skips the test if wasm-opt (from Binaryen) is not installed.
+// It is needed for the tasks scheduler on WebAssembly, which uses asyncify.
+func requireWasmOpt(t *testing.T) {
+	if _, err := exec.LookPath("wasm-opt"); err != nil {
+		t.Skip("wasm-opt not found:", err)
+	}
+}
+
+// runWasiStdinTest runs testdata/wasi/stdin.go with the tasks scheduler. It
+// writes to standard input of the program through a pipe after a delay, so
+// that the program has to wait for input while another goroutine is running.
+func runWasiStdinTest(t *testing.T) {
+	expected, err := ioutil.ReadFile("testdata/wasi/stdin.txt")
+	if err != nil {
+		t.Fatal("could not read expected output file:", err)
+	}
+	tmpdir, err := ioutil.TempDir("", "tinygo-test")
+	if err != nil {
+		t.Fatal("could not create temporary directory:", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	binary := filepath.Join(tmpdir, "test.wasm")
+	err = runBuild("./testdata/wasi/stdin.go", binary, &compileopts.Options{
+		Target:    "wasi",
+		Opt:       "z",
+		VerifyIR:  true,
+		Debug:     true,
+		Scheduler: "tasks",
+	})
+	if err != nil {
+		printCompilerError(t.Log, err)
+		t.FailNow()
+	}
+
+	cmd := exec.Command("wasmtime", binary)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal("could not create stdin pipe:", err)
+	}
+	stdout := &bytes.Buffer{}
+	cmd.Stdout = stdout
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	err = cmd.Start()
+	if err != nil {
+		t.Fatal("failed to start:", err)
+	}
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		stdin.Write([]byte("hello\n"))
+		stdin.Close()
+	}()
+	timer := time.AfterFunc(10*time.Second, func() {
+		cmd.Process.Kill()
+	})
+	err = cmd.Wait()
+	timer.Stop()
+	if err != nil {
+		t.Error("failed to run:", err)
+	}
+	if !bytes.Equal(stdout.Bytes(), expected) {
+		t.Errorf("output did not match:\n%s", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "tick") {
+		t.Error("no goroutine ran while waiting for standard input")
+	}
+}
+
+// TestBuildID checks that -build-id results in a GNU build ID note in the
+// output file, which means that the linker script of the target places the
+// note section in the firmware.
+func TestBuildID(t *testing.T) {
+	targets := []string{"cortex-m-qemu", "riscv-qemu", "hifive1b", "pca10040-s132v6", "gameboy-advance"}
+	if runtime.GOOS == "linux" {
+		targets = append(targets, "")
+	}
+	if _, err := exec.LookPath("avr-gcc"); err == nil {
+		targets = append(targets, "arduino")
+	}
+	for _, target := range targets {
+		target := target
+		name := target
+		if name == "" {
+			name = "Host"
+		}
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			tmpdir, err := ioutil.TempDir("", "tinygo-test")
+			if err != nil {
+				t.Fatal("could not create temporary directory:", err)
+			}
+			defer os.RemoveAll(tmpdir)
+
+			binary := filepath.Join(tmpdir, "test.elf")
+			err = runBuild("./testdata/alias.go", binary, &compileopts.Options{
+				Target:  target,
+				Opt:     "z",
+				BuildID: true,
+			})
+			if err != nil {
+				printCompilerError(t.Log, err)
+				t.FailNow()
+			}
+
+			f, err := elf.Open(binary)
+			if err != nil {
+				t.Fatal("could not open output file:", err)
+			}
+			defer f.Close()
+			section := f.Section(".note.gnu.build-id")
+			if section == nil {
+				t.Fatal("no .note.gnu.build-id section in output file")
+			}
+			if section.Flags&elf.SHF_ALLOC == 0 {
+				t.Error("build ID note is not part of the program image")
+			}
+			data, err := section.Data()
+			if err != nil {
+				t.Fatal("could not read build ID note:", err)
+			}
+
+			// The note consists of a header with the name size, descriptor
+			// size and type, followed by the name ("GNU") and the descriptor
+			// (the SHA1 hash).
+			order := f.ByteOrder
+			if len(data) < 16 {
+				t.Fatalf("build ID note is too short: %d bytes", len(data))
+			}
+			namesz, descsz, noteType := order.Uint32(data[0:]), order.Uint32(data[4:]), order.Uint32(data[8:])
+			if namesz != 4 || string(data[12:16]) != "GNU\x00" || noteType != 3 {
+				t.Errorf("unexpected note header: namesz=%d type=%d name=%q", namesz, noteType, data[12:16])
+			}
+			if descsz != 20 || len(data) < 16+20 {
+				t.Errorf("expected a 20-byte SHA1 build ID, got %d bytes", descsz)
+			} else if bytes.Equal(data[16:36], make([]byte, 20)) {
+				t.Error("build ID is all zeroes")
+			}
+		})
+	}
+}
+
+// TestTrimPath checks that -trimpath results in reproducible output: building
+// the same package from two different directories must result in the same
+// binary, without any absolute source paths in it.
+func TestTrimPath(t *testing.T) {
+	targets := []string{"cortex-m-qemu"}
+	if runtime.GOOS == "linux" {
+		targets = append(targets, "")
+	}
+
+	// The package is built from a different directory each time, to check
+	// that neither the source directory nor the working directory ends up in
+	// the binary.
+	source, err := ioutil.ReadFile("testdata/alias.go")
+	if err != nil {
+		t.Fatal("could not read test program:", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal("could not get working directory:", err)
+	}
+	defer os.Chdir(wd)
+
+	for _, target := range targets {
+		name := target
+		if name == "" {
+			name = "Host"
+		}
+		t.Run(name, func(t *testing.T) {
+			var binaries [][]byte
+			var dirs []string
+			for i := 0; i < 2; i++ {
+				tmpdir, err := ioutil.TempDir("", "tinygo-trimpath")
+				if err != nil {
+					t.Fatal("could not create temporary directory:", err)
+				}
+				defer os.RemoveAll(tmpdir)
+				dirs = append(dirs, tmpdir)
+				pkgdir := filepath.Join(tmpdir, "src")
+				err = os.Mkdir(pkgdir, 0777)
+				if err == nil {
+					err = ioutil.WriteFile(filepath.Join(pkgdir, "go.mod"), []byte("module example.com/trimpath\n"), 0666)
+				}
+				if err == nil {
+					err = ioutil.WriteFile(filepath.Join(pkgdir, "main.go"), source, 0666)
+				}
+				if err != nil {
+					t.Fatal("could not write test package:", err)
+				}
+
+				err = os.Chdir(pkgdir)
+				if err != nil {
+					t.Fatal("could not change working directory:", err)
+				}
+				binary := filepath.Join(tmpdir, "test.elf")
+				err = runBuild(".", binary, &compileopts.Options{
+					Target:   target,
+					Opt:      "z",
+					Debug:    true,
+					TrimPath: true,
+				})
+				os.Chdir(wd)
+				if err != nil {
+					printCompilerError(t.Log, err)
+					t.FailNow()
+				}
+				data, err := ioutil.ReadFile(binary)
+				if err != nil {
+					t.Fatal("could not read output file:", err)
+				}
+				binaries = append(binaries, data)
+			}
+
+			if !bytes.Equal(binaries[0], binaries[1]) {
+				t.Error("output differs when built from a different directory")
+			}
+			paths := append(dirs, goenv.Get("TINYGOROOT"), goenv.Get("GOROOT"), wd)
+			for _, path := range paths {
+				if bytes.Contains(binaries[0], []byte(path)) {
+					t.Errorf("output contains absolute path %s", path)
+				}
+			}
+		})
+	}
+}
+
+// TestParseLDFlags checks that -X flags are split off from the linker flags,
+// using shell quoting rules.
+func TestParseLDFlags(t *testing.T) {
+	for _, tc := range []struct {
+		input        string
+		ldflags      []string
+		globalValues map[string]map[string]string
+		valid        bool
+	}{
+		{"-s -w", []string{"-s", "-w"}, nil, true},
+		{"-X main.version=1.0", nil, map[string]map[string]string{"main": {"version": "1.0"}}, true},
+		{"-X 'main.version=a b' --gc-sections", []string{"--gc-sections"}, map[string]map[string]string{"main": {"version": "a b"}}, true},
+		{`-X="example.com/pkg.name=x y" -X=main.empty=`, nil, map[string]map[string]string{"example.com/pkg": {"name": "x y"}, "main": {"empty": ""}}, true},
+		{"-X", nil, nil, false},
+		{"-X main.version", nil, nil, false},
+		{"-X 'main.version=unterminated", nil, nil, false},
+	} {
+		ldflags, globalValues, err := parseLDFlags(tc.input)
+		if (err == nil) != tc.valid {
+			t.Errorf("%#v: unexpected error state: %v", tc.input, err)
+			continue
+		}
+		if !tc.valid {
+			continue
+		}
+		if fmt.Sprint(ldflags) != fmt.Sprint(tc.ldflags) {
+			t.Errorf("%#v: expected linker flags %q, got %q", tc.input, tc.ldflags, ldflags)
+		}
+		if fmt.Sprint(globalValues) != fmt.Sprint(tc.globalValues) {
+			t.Errorf("%#v: expected globals %v, got %v", tc.input, tc.globalValues, globalValues)
+		}
+	}
+}
+
+// TestWasmUnknownImports checks that a program built for wasm-unknown may only
+// import what it declared with //go:wasm-module.
+func TestWasmUnknownImports(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "tinygo-test")
+	if err != nil {
+		t.Fatal("could not create temporary directory:", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	options := &compileopts.Options{
+		Target: "wasm-unknown",
+		Opt:    "z",
+	}
+	t.Run("declared", func(t *testing.T) {
+		err := runBuild("examples/wasm/unknown", filepath.Join(tmpdir, "declared.wasm"), options)
+		if err != nil {
+			printCompilerError(t.Log, err)
+			t.Fail()
+		}
+	})
+	t.Run("undeclared", func(t *testing.T) {
+		err := runBuild("./testdata/wasmunknown", filepath.Join(tmpdir, "undeclared.wasm"), options)
+		if err == nil {
+			t.Fatal("expected an error for the undeclared import")
+		}
+		if !strings.Contains(err.Error(), "imports env.undeclared, which was not declared with //go:wasm-module") {
+			t.Error("unexpected error:", err)
+		}
+	})
+}
+
 // This TestMain is necessary because TinyGo may also be invoked to run certain
 // LLVM tools in a separate process. Not capturing these invocations would lead
 // to recursive tests.
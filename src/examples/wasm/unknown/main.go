@@ -0,0 +1,38 @@
+// This example is built for the wasm-unknown target, for embedding in a host
+// that provides neither WASI nor the JavaScript imports. The module only
+// imports the functions declared below, from the "host" module:
+//
+//     tinygo build -o unknown.wasm -target=wasm-unknown ./unknown
+package main
+
+// Functions provided by the host.
+
+//go:wasm-module host
+//export host_write
+func hostWrite(c byte)
+
+//go:wasm-module host
+//export host_time
+func hostTime() int64
+
+// Replace the default runtime hooks, so that println writes to the host and
+// the time is the time of the host.
+
+//export tinygo_putchar
+func putchar(c byte) {
+	hostWrite(c)
+}
+
+//export tinygo_ticks
+func ticks() int64 {
+	return hostTime()
+}
+
+func main() {
+	println("hello from wasm-unknown")
+}
+
+//export add
+func add(a, b int32) int32 {
+	return a + b
+}
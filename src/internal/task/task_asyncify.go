@@ -0,0 +1,156 @@
+// +build scheduler.tasks,wasm
+
+package task
+
+import "unsafe"
+
+// This is the tasks scheduler for WebAssembly. WebAssembly does not allow
+// direct access to the call stack, so it is not possible to switch stacks like
+// on other architectures. Instead, the program is post-processed with the
+// asyncify pass of Binaryen (wasm-opt --asyncify). This instruments every
+// function that may pause so that it can unwind the call stack into a buffer
+// and later rewind it from that buffer. Unlike the coroutines scheduler, this
+// works for all Go code including blocking calls through function pointers and
+// interfaces.
+
+//go:linkname runtimePanic runtime.runtimePanic
+func runtimePanic(str string)
+
+// Stack canary, to detect a stack overflow. The number is a random number
+// generated by random.org. The bit fiddling dance is necessary because
+// otherwise Go wouldn't allow the cast to a smaller integer size.
+const stackCanary = uintptr(uint64(0x670c1333b83bf575) & uint64(^uintptr(0)))
+
+// state is a structure which holds a reference to the state of the task.
+// Also see task_asyncify_wasm.S that relies on the exact layout of this
+// struct.
+type state struct {
+	// entry is the goroutine start wrapper to call on the first resume.
+	entry uintptr
+
+	// args is the argument bundle passed to entry.
+	args unsafe.Pointer
+
+	// stackState is the asyncify data structure, and is updated on every
+	// stack switch.
+	stackState
+
+	// launched is set once the goroutine has been started.
+	launched bool
+
+	// stackChain is the chain of GC stack objects of this goroutine while it
+	// is paused. See swapStackChain.
+	stackChain unsafe.Pointer
+}
+
+// stackState is the data structure used by asyncify. The first two fields are
+// read and written by asyncify itself.
+type stackState struct {
+	// asyncifysp is the stack pointer of the asyncify stack. This grows
+	// upwards, starting right after the stack canary.
+	asyncifysp uintptr
+
+	// csp is the C (shadow) stack pointer of the goroutine. This grows
+	// downwards, starting at the end of the stack. It is also the end of the
+	// asyncify stack, which means asyncify will trap before the two overlap.
+	csp uintptr
+
+	// canaryPtr points to the top word of the stack (the lowest address).
+	// This is used to detect stack overflows.
+	// When initializing the goroutine, the stackCanary constant is stored there.
+	// If the stack overflowed, the word will likely no longer equal stackCanary.
+	canaryPtr *uintptr
+}
+
+// currentTask is the current running task, or nil if currently in the scheduler.
+var currentTask *Task
+
+// Current returns the current active task.
+func Current() *Task {
+	return currentTask
+}
+
+// Pause suspends the current task and returns to the scheduler.
+// This function may only be called when running on a goroutine stack, not when running on the system stack.
+func Pause() {
+	// Check whether the canary (the lowest address of the stack) is still
+	// valid. If it is not, a stack overflow has occured.
+	if *currentTask.state.canaryPtr != stackCanary {
+		runtimePanic("goroutine stack overflow")
+	}
+	currentTask.state.unwind()
+}
+
+//export tinygo_unwind
+func (*stackState) unwind()
+
+// Resume the task until it pauses or completes.
+// This may only be called from the scheduler.
+func (t *Task) Resume() {
+	// The current task must be saved and restored, because on WebAssembly
+	// with JavaScript the scheduler may be entered again from an exported
+	// function while a goroutine is running.
+	prevTask := currentTask
+	currentTask = t
+	t.state.swapStackChain()
+	if !t.state.launched {
+		t.state.launched = true
+		t.state.launch()
+	} else {
+		t.state.rewind()
+	}
+	t.state.swapStackChain()
+	currentTask = prevTask
+	if t.state.asyncifysp > t.state.csp {
+		runtimePanic("goroutine stack overflow")
+	}
+}
+
+//export tinygo_launch
+func (*state) launch()
+
+//export tinygo_rewind
+func (*state) rewind()
+
+// initialize the state and prepare to call the specified function with the specified argument bundle.
+func (s *state) initialize(fn uintptr, args unsafe.Pointer, stackSize uintptr) {
+	s.entry = fn
+	s.args = args
+
+	// Create a stack. The asyncify stack grows upwards from the start and the
+	// C stack grows downwards from the end.
+	stack := make([]uintptr, stackSize/unsafe.Sizeof(uintptr(0)))
+
+	// Set up the stack canary, a random number that should be checked when
+	// switching from the task back to the scheduler. The stack canary pointer
+	// points to the first word of the stack. If it has changed between now and
+	// the next stack switch, there was a stack overflow.
+	s.canaryPtr = &stack[0]
+	*s.canaryPtr = stackCanary
+
+	s.asyncifysp = uintptr(unsafe.Pointer(&stack[1]))
+	s.csp = uintptr(unsafe.Pointer(&stack[0])) + uintptr(len(stack))*unsafe.Sizeof(uintptr(0))
+}
+
+//go:linkname runqueuePushBack runtime.runqueuePushBack
+func runqueuePushBack(*Task)
+
+// start creates and starts a new goroutine with the given function and arguments.
+// The new goroutine is scheduled to run later.
+func start(fn uintptr, args unsafe.Pointer, stackSize uintptr) {
+	t := &Task{}
+	t.state.initialize(fn, args, stackSize)
+	runqueuePushBack(t)
+}
+
+// OnSystemStack returns whether the caller is running on the system stack.
+func OnSystemStack() bool {
+	// If there is not an active goroutine, then this must be running on the system stack.
+	return Current() == nil
+}
+
+// SystemStack returns the system stack pointer. It is not known on
+// WebAssembly, so 0 is returned.
+func SystemStack() uintptr {
+	return 0
+}
@@ -0,0 +1,19 @@
+// +build scheduler.tasks,wasm
+// +build gc.conservative gc.extalloc
+
+package task
+
+import "unsafe"
+
+// The garbage collector finds pointers on the stack by following the chain of
+// stack objects that starts at runtime.stackChainStart. Every goroutine has
+// its own chain, which is stored in the task while it is paused.
+
+//go:extern runtime.stackChainStart
+var stackChainStart unsafe.Pointer
+
+// swapStackChain swaps the stack chain of the goroutine with the one that is
+// currently active. It is called when entering and leaving a goroutine.
+func (s *state) swapStackChain() {
+	stackChainStart, s.stackChain = s.stackChain, stackChainStart
+}
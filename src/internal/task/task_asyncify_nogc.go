@@ -0,0 +1,7 @@
+// +build scheduler.tasks,wasm,!gc.conservative,!gc.extalloc
+
+package task
+
+// swapStackChain does nothing: stack objects are only tracked by the
+// conservative and extalloc garbage collectors.
+func (s *state) swapStackChain() {}
@@ -1,4 +1,4 @@
-// +build scheduler.tasks
+// +build scheduler.tasks,!wasm
 
 package task
 
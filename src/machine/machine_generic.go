@@ -122,14 +122,22 @@ func (i2c I2C) Configure(config I2CConfig) error {
 
 // Tx does a single I2C transaction at the specified address.
 func (i2c I2C) Tx(addr uint16, w, r []byte) error {
-	i2cTransfer(i2c.Bus, &w[0], len(w), &r[0], len(r))
-	// TODO: do something with the returned error code.
+	i2cSetAddress(i2c.Bus, addr)
+	if i2cTransfer(i2c.Bus, bufPtr(w), len(w), bufPtr(r), len(r)) != 0 {
+		// The device didn't acknowledge the transfer.
+		return errI2CAckExpected
+	}
 	return nil
 }
 
 //export __tinygo_i2c_configure
 func i2cConfigure(bus uint8, scl Pin, sda Pin)
 
+// Optional: the default implementation (in targets/machine-generic.c) ignores
+// the address, so hosts that only implement __tinygo_i2c_transfer keep working.
+//export __tinygo_i2c_set_address
+func i2cSetAddress(bus uint8, addr uint16)
+
 //export __tinygo_i2c_transfer
 func i2cTransfer(bus uint8, w *byte, wlen int, r *byte, rlen int) int
 
@@ -150,12 +158,12 @@ func (uart UART) Configure(config UARTConfig) {
 
 // Read from the UART.
 func (uart UART) Read(data []byte) (n int, err error) {
-	return uartRead(uart.Bus, &data[0], len(data)), nil
+	return uartRead(uart.Bus, bufPtr(data), len(data)), nil
 }
 
 // Write to the UART.
 func (uart UART) Write(data []byte) (n int, err error) {
-	return uartWrite(uart.Bus, &data[0], len(data)), nil
+	return uartWrite(uart.Bus, bufPtr(data), len(data)), nil
 }
 
 // Buffered returns the number of bytes currently stored in the RX buffer.
@@ -184,3 +192,11 @@ func uartRead(bus uint8, buf *byte, bufLen int) int
 
 //export __tinygo_uart_write
 func uartWrite(bus uint8, buf *byte, bufLen int) int
+
+// bufPtr returns a pointer to the first byte of buf, or nil if buf is empty.
+func bufPtr(buf []byte) *byte {
+	if len(buf) == 0 {
+		return nil
+	}
+	return &buf[0]
+}
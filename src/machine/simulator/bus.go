@@ -0,0 +1,162 @@
+// +build !baremetal
+
+package simulator
+
+import (
+	"errors"
+	"machine"
+)
+
+var errI2CNack = errors.New("simulator: I2C device did not acknowledge")
+
+// I2CBus is a virtual I2C bus with devices attached to it. It has the same
+// methods as machine.I2C, so it can be passed directly to a driver that
+// accepts an I2C bus interface. The I2C buses of the machine package (such as
+// machine.I2C0) are backed by an I2CBus, see AttachI2C.
+type I2CBus struct {
+	devices map[uint16]I2CDevice
+	address uint16 // set through the __tinygo_i2c_set_address hook
+}
+
+// NewI2CBus returns a new I2C bus without any devices. It is not connected to
+// the machine package.
+func NewI2CBus() *I2CBus {
+	return &I2CBus{devices: map[uint16]I2CDevice{}}
+}
+
+// Attach connects a device to the bus at the given address, replacing any
+// device that was connected at this address before.
+func (b *I2CBus) Attach(addr uint16, dev I2CDevice) {
+	b.devices[addr] = dev
+}
+
+// Tx does a single I2C transaction at the given address. It returns an error
+// if there is no device at this address or if the device returns an error.
+func (b *I2CBus) Tx(addr uint16, w, r []byte) error {
+	dev := b.devices[addr]
+	if dev == nil {
+		return errI2CNack
+	}
+	return dev.I2CTx(w, r)
+}
+
+// WriteRegister writes the register and then the data to the device at the
+// given address, like machine.I2C.WriteRegister.
+func (b *I2CBus) WriteRegister(addr uint8, register uint8, data []byte) error {
+	buf := make([]uint8, len(data)+1)
+	buf[0] = register
+	copy(buf[1:], data)
+	return b.Tx(uint16(addr), buf, nil)
+}
+
+// ReadRegister writes the register and then reads the response from the
+// device at the given address, like machine.I2C.ReadRegister.
+func (b *I2CBus) ReadRegister(addr uint8, register uint8, data []byte) error {
+	return b.Tx(uint16(addr), []byte{register}, data)
+}
+
+type spiDevice struct {
+	cs       machine.Pin
+	device   SPIDevice
+	selected bool
+}
+
+// SPIBus is a virtual SPI bus with devices attached to it. It has the same
+// methods as machine.SPI, so it can be passed directly to a driver that
+// accepts an SPI bus interface. The SPI buses of the machine package (such as
+// machine.SPI0) are backed by an SPIBus, see AttachSPI.
+//
+// A device is selected while its chip select pin is configured as an output
+// and driven low, through the machine package.
+type SPIBus struct {
+	devices []spiDevice
+}
+
+// NewSPIBus returns a new SPI bus without any devices. It is not connected to
+// the machine package.
+func NewSPIBus() *SPIBus {
+	return &SPIBus{}
+}
+
+// Attach connects a device to the bus. The device is selected when the chip
+// select pin cs is driven low.
+func (b *SPIBus) Attach(cs machine.Pin, dev SPIDevice) {
+	b.devices = append(b.devices, spiDevice{cs: cs, device: dev})
+	state := getPin(cs)
+	found := false
+	for _, bus := range state.spiBuses {
+		if bus == b {
+			found = true
+		}
+	}
+	if !found {
+		state.spiBuses = append(state.spiBuses, b)
+	}
+	b.chipSelect(cs)
+}
+
+// chipSelect selects or deselects the devices with the given chip select pin,
+// after the mode or level of the pin changed. Devices are only notified when
+// their select state actually changes.
+func (b *SPIBus) chipSelect(pin machine.Pin) {
+	state := getPin(pin)
+	selected := state.mode == machine.PinOutput && !state.level
+	for i := range b.devices {
+		dev := &b.devices[i]
+		if dev.cs == pin && dev.selected != selected {
+			dev.selected = selected
+			dev.device.SPISelect(selected)
+		}
+	}
+}
+
+// detach deselects and removes all devices with the given chip select pin.
+func (b *SPIBus) detach(pin machine.Pin) {
+	devices := b.devices[:0]
+	for _, dev := range b.devices {
+		if dev.cs != pin {
+			devices = append(devices, dev)
+			continue
+		}
+		if dev.selected {
+			dev.device.SPISelect(false)
+		}
+	}
+	b.devices = devices
+}
+
+// Transfer writes a single byte and returns the byte read at the same time.
+// If no device is selected, it returns 0xff.
+func (b *SPIBus) Transfer(w byte) (byte, error) {
+	// SDI is pulled high, and all selected devices drive it (open drain).
+	r := uint8(0xff)
+	for _, dev := range b.devices {
+		if dev.selected {
+			r &= dev.device.SPITransfer(w)
+		}
+	}
+	return r, nil
+}
+
+// Tx writes w and reads r at the same time, like machine.SPI.Tx. Either w or r
+// may be nil, in which case only data is read or written.
+func (b *SPIBus) Tx(w, r []byte) error {
+	switch {
+	case w == nil:
+		for i := range r {
+			r[i], _ = b.Transfer(0)
+		}
+	case r == nil:
+		for _, c := range w {
+			b.Transfer(c)
+		}
+	default:
+		if len(w) != len(r) {
+			return machine.ErrTxInvalidSliceSize
+		}
+		for i, c := range w {
+			r[i], _ = b.Transfer(c)
+		}
+	}
+	return nil
+}
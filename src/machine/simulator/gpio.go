@@ -0,0 +1,108 @@
+// +build !baremetal
+
+package simulator
+
+import (
+	"machine"
+)
+
+// LED is a virtual LED connected to an output pin. It is on when the pin is
+// driven high.
+type LED struct {
+	on         bool
+	changes    int
+	brightness uint16
+}
+
+// AttachLED connects a new LED to the given pin.
+func AttachLED(pin machine.Pin) *LED {
+	led := &LED{}
+	AttachPin(pin, led)
+	return led
+}
+
+// PinSet implements PinDevice.
+func (led *LED) PinSet(level bool) {
+	if level != led.on {
+		led.changes++
+	}
+	led.on = level
+	if level {
+		led.brightness = 0xffff
+	} else {
+		led.brightness = 0
+	}
+}
+
+// PinLevel implements PinDevice. An LED doesn't drive the pin.
+func (led *LED) PinLevel() (level, driven bool) {
+	return false, false
+}
+
+// On returns whether the LED is currently on.
+func (led *LED) On() bool {
+	return led.on
+}
+
+// Changes returns how often the LED was switched on or off, which is useful to
+// check that an LED is blinking.
+func (led *LED) Changes() int {
+	return led.changes
+}
+
+// Brightness returns the brightness of the LED, as last set through PWM or by
+// switching the LED on or off.
+func (led *LED) Brightness() uint16 {
+	return led.brightness
+}
+
+// Button is a virtual push button connected to an input pin. When pressed,
+// the button connects the pin to ground (or to the supply voltage, if the
+// button is active high).
+type Button struct {
+	activeHigh bool
+	pressed    bool
+}
+
+// AttachButton connects a new button to the given pin, which connects the pin
+// to ground when pressed. Such a button is normally used with
+// machine.PinInputPullup.
+func AttachButton(pin machine.Pin) *Button {
+	button := &Button{}
+	AttachPin(pin, button)
+	return button
+}
+
+// AttachButtonActiveHigh connects a new button to the given pin, which
+// connects the pin to the supply voltage when pressed. Such a button is
+// normally used with machine.PinInputPulldown.
+func AttachButtonActiveHigh(pin machine.Pin) *Button {
+	button := &Button{activeHigh: true}
+	AttachPin(pin, button)
+	return button
+}
+
+// Press presses the button, until it is released with Release.
+func (b *Button) Press() {
+	b.pressed = true
+}
+
+// Release releases the button.
+func (b *Button) Release() {
+	b.pressed = false
+}
+
+// Pressed returns whether the button is currently pressed.
+func (b *Button) Pressed() bool {
+	return b.pressed
+}
+
+// PinSet implements PinDevice. A button ignores the level of the pin.
+func (b *Button) PinSet(level bool) {
+}
+
+// PinLevel implements PinDevice. A button only drives the pin while it is
+// pressed.
+func (b *Button) PinLevel() (level, driven bool) {
+	return b.activeHigh, b.pressed
+}
@@ -0,0 +1,129 @@
+// +build !baremetal
+
+package simulator
+
+import (
+	"errors"
+	"fmt"
+)
+
+// I2CTransaction is a single expected I2C transaction of a ScriptedI2C
+// device.
+type I2CTransaction struct {
+	// Write is the data the controller is expected to write. If it is nil,
+	// any data is accepted.
+	Write []byte
+
+	// Read is the data returned to the controller. Only the first bytes are
+	// used if the controller reads less, and the rest of the read buffer is
+	// filled with 0xff if it reads more.
+	Read []byte
+
+	// Nack makes the device not acknowledge the transaction.
+	Nack bool
+}
+
+// ScriptedI2C is an I2C device that replays a list of transactions, for
+// example to simulate a sensor in a test. Every transaction made by the
+// controller is checked against the next transaction in the script.
+type ScriptedI2C struct {
+	script []I2CTransaction
+	index  int
+	err    error
+}
+
+// NewScriptedI2C returns a new I2C device that replays the given script.
+func NewScriptedI2C(script []I2CTransaction) *ScriptedI2C {
+	return &ScriptedI2C{script: script}
+}
+
+// NewScriptedI2CFromJSON returns a new I2C device that replays a script in
+// JSON format. The script is a list of transactions, where each transaction
+// is an object with optional "write", "read" and "nack" keys:
+//
+//     [
+//         {"write": [117], "read": [104]},
+//         {"write": [107, 0]},
+//         {"write": [59], "read": [1, 2, 3, 4, 5, 6]}
+//     ]
+func NewScriptedI2CFromJSON(data []byte) (*ScriptedI2C, error) {
+	value, err := parseJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New("simulator: I2C script is not a list")
+	}
+	script := make([]I2CTransaction, len(list))
+	for i, item := range list {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("simulator: I2C transaction %d is not an object", i)
+		}
+		for key, value := range object {
+			switch key {
+			case "write":
+				script[i].Write, err = jsonBytes(value)
+				if script[i].Write == nil && err == nil {
+					script[i].Write = []byte{}
+				}
+			case "read":
+				script[i].Read, err = jsonBytes(value)
+			case "nack":
+				script[i].Nack, ok = value.(bool)
+				if !ok {
+					err = errors.New("not a boolean")
+				}
+			default:
+				err = errors.New("unknown key")
+			}
+			if err != nil {
+				return nil, fmt.Errorf("simulator: I2C transaction %d, key %#v: %v", i, key, err)
+			}
+		}
+	}
+	return NewScriptedI2C(script), nil
+}
+
+// I2CTx implements I2CDevice.
+func (s *ScriptedI2C) I2CTx(w, r []byte) error {
+	if s.index >= len(s.script) {
+		s.fail(fmt.Errorf("simulator: unexpected I2C transaction %d (write % x), script has only %d transactions", s.index, w, len(s.script)))
+		return s.err
+	}
+	tx := s.script[s.index]
+	s.index++
+	if tx.Write != nil && string(tx.Write) != string(w) {
+		s.fail(fmt.Errorf("simulator: I2C transaction %d: expected write % x, got % x", s.index-1, tx.Write, w))
+		return s.err
+	}
+	if tx.Nack {
+		return errI2CNack
+	}
+	n := copy(r, tx.Read)
+	for i := n; i < len(r); i++ {
+		r[i] = 0xff
+	}
+	return nil
+}
+
+// fail stores the first error found while replaying the script.
+func (s *ScriptedI2C) fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// Err returns the first mismatch between the script and the transactions
+// made by the controller, or an error if not all transactions in the script
+// were made.
+func (s *ScriptedI2C) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.index < len(s.script) {
+		return fmt.Errorf("simulator: only %d of %d I2C transactions were made", s.index, len(s.script))
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+// +build !baremetal
+
+package simulator
+
+// A small JSON parser for device scripts. It is used instead of
+// encoding/json, which relies on reflection that is not fully supported by
+// TinyGo.
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// parseJSON parses a JSON document into nil, bool, float64, string,
+// []interface{} and map[string]interface{} values.
+func parseJSON(data []byte) (interface{}, error) {
+	p := &jsonParser{data: data}
+	value := p.value()
+	p.skipSpace()
+	if p.err == nil && p.pos != len(p.data) {
+		p.fail("unexpected data after value")
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return value, nil
+}
+
+// jsonBytes converts a parsed JSON list of numbers to a byte slice.
+func jsonBytes(value interface{}) ([]byte, error) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New("not a list of bytes")
+	}
+	var buf []byte
+	for _, item := range list {
+		n, ok := item.(float64)
+		if !ok || n < 0 || n > 255 || n != float64(int(n)) {
+			return nil, fmt.Errorf("not a byte: %v", item)
+		}
+		buf = append(buf, byte(n))
+	}
+	return buf, nil
+}
+
+type jsonParser struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (p *jsonParser) fail(msg string) {
+	if p.err == nil {
+		p.err = fmt.Errorf("simulator: invalid JSON at offset %d: %s", p.pos, msg)
+	}
+}
+
+func (p *jsonParser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\r', '\n':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// consume skips the given byte (after whitespace) and returns true if it is
+// the next byte in the input.
+func (p *jsonParser) consume(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *jsonParser) value() interface{} {
+	p.skipSpace()
+	if p.err != nil || p.pos >= len(p.data) {
+		p.fail("unexpected end of input")
+		return nil
+	}
+	switch c := p.data[p.pos]; {
+	case c == '[':
+		p.pos++
+		list := []interface{}{}
+		if p.consume(']') {
+			return list
+		}
+		for p.err == nil {
+			list = append(list, p.value())
+			if p.consume(']') {
+				break
+			}
+			if !p.consume(',') {
+				p.fail("expected ',' or ']'")
+			}
+		}
+		return list
+	case c == '{':
+		p.pos++
+		object := map[string]interface{}{}
+		if p.consume('}') {
+			return object
+		}
+		for p.err == nil {
+			key, ok := p.value().(string)
+			if !ok {
+				p.fail("expected string key")
+				break
+			}
+			if !p.consume(':') {
+				p.fail("expected ':'")
+				break
+			}
+			object[key] = p.value()
+			if p.consume('}') {
+				break
+			}
+			if !p.consume(',') {
+				p.fail("expected ',' or '}'")
+			}
+		}
+		return object
+	case c == '"':
+		start := p.pos
+		for p.pos++; p.pos < len(p.data) && p.data[p.pos] != '"'; p.pos++ {
+			if p.data[p.pos] == '\\' {
+				p.pos++
+			}
+		}
+		if p.pos >= len(p.data) {
+			p.fail("unterminated string")
+			return nil
+		}
+		p.pos++
+		s, err := strconv.Unquote(string(p.data[start:p.pos]))
+		if err != nil {
+			p.fail("invalid string")
+		}
+		return s
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		for p.pos < len(p.data) && isNumberByte(p.data[p.pos]) {
+			p.pos++
+		}
+		n, err := strconv.ParseFloat(string(p.data[start:p.pos]), 64)
+		if err != nil {
+			p.fail("invalid number")
+		}
+		return n
+	default:
+		for _, literal := range []struct {
+			text  string
+			value interface{}
+		}{{"true", true}, {"false", false}, {"null", nil}} {
+			end := p.pos + len(literal.text)
+			if end <= len(p.data) && string(p.data[p.pos:end]) == literal.text {
+				p.pos = end
+				return literal.value
+			}
+		}
+		p.fail("unexpected character")
+		return nil
+	}
+}
+
+func isNumberByte(c byte) bool {
+	return (c >= '0' && c <= '9') || c == '-' || c == '+' || c == '.' || c == 'e' || c == 'E'
+}
@@ -0,0 +1,74 @@
+// +build linux,!baremetal,!wasm
+
+package simulator
+
+import (
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+// PTY is a pseudo terminal, which can be attached to a UART to talk to the
+// program with a terminal program such as screen or minicom, or with a
+// serial port library on the host.
+type PTY struct {
+	fd   int
+	name string
+}
+
+// OpenPTY opens a new pseudo terminal. Reads from the returned PTY don't
+// block.
+func OpenPTY() (*PTY, error) {
+	fd, err := syscall.Open("/dev/ptmx", syscall.O_RDWR|syscall.O_NOCTTY|syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	// Unlock the slave side, like unlockpt does.
+	var unlock int32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); errno != 0 {
+		syscall.Close(fd)
+		return nil, errno
+	}
+	// Get the slave number, like ptsname does.
+	var n uint32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); errno != 0 {
+		syscall.Close(fd)
+		return nil, errno
+	}
+	return &PTY{fd: fd, name: "/dev/pts/" + strconv.Itoa(int(n))}, nil
+}
+
+// AttachPTY opens a new pseudo terminal and connects it to the given UART
+// bus. Use Name to find the device to open on the host.
+func AttachPTY(bus uint8) (*PTY, error) {
+	pty, err := OpenPTY()
+	if err != nil {
+		return nil, err
+	}
+	AttachUART(bus, pty)
+	return pty, nil
+}
+
+// Name returns the path of the terminal device, such as /dev/pts/3.
+func (pty *PTY) Name() string {
+	return pty.name
+}
+
+// Read implements io.Reader. It returns 0 bytes if no data is available.
+func (pty *PTY) Read(buf []byte) (int, error) {
+	n, err := syscall.Read(pty.fd, buf)
+	if err == syscall.EAGAIN || n < 0 {
+		return 0, nil
+	}
+	return n, err
+}
+
+// Write implements io.Writer.
+func (pty *PTY) Write(buf []byte) (int, error) {
+	return syscall.Write(pty.fd, buf)
+}
+
+// Close closes the pseudo terminal.
+func (pty *PTY) Close() error {
+	return syscall.Close(pty.fd)
+}
@@ -0,0 +1,130 @@
+// +build !baremetal
+
+package simulator
+
+// RegisterFile is a generic device model with 256 8-bit registers, which is
+// how most I2C and SPI sensors are organized. It can be used as a starting
+// point to simulate such a device: set the initial register values (such as
+// an ID register), and use OnWrite to react to writes (such as starting a
+// measurement).
+//
+// On an I2C bus, the first byte written in a transaction selects the register.
+// The following bytes are written to consecutive registers, and reads start at
+// the selected register. This is compatible with machine.I2C.ReadRegister and
+// machine.I2C.WriteRegister.
+//
+// On an SPI bus, the first byte after the chip is selected contains the
+// register in the lower 7 bits. If the highest bit is set the following bytes
+// are read from consecutive registers, otherwise they are written.
+type RegisterFile struct {
+	// Registers holds the current value of all registers.
+	Registers [256]byte
+
+	// OnWrite is called after a register was written by the controller, if
+	// it is set. It can be used to update other registers in response.
+	OnWrite func(reg, value byte)
+
+	// OnRead is called before a register is read by the controller, if it is
+	// set. It can be used to update the register.
+	OnRead func(reg byte)
+
+	readOnly [256]bool
+	pointer  byte
+	spiState byte
+}
+
+// States of the SPI protocol of a RegisterFile.
+const (
+	registersSPIIdle byte = iota
+	registersSPIRead
+	registersSPIWrite
+)
+
+// NewRegisterFile returns a new register file with all registers set to zero.
+func NewRegisterFile() *RegisterFile {
+	return &RegisterFile{}
+}
+
+// Set sets the value of consecutive registers, starting at reg. This doesn't
+// call OnWrite.
+func (rf *RegisterFile) Set(reg byte, values ...byte) {
+	for _, value := range values {
+		rf.Registers[reg] = value
+		reg++
+	}
+}
+
+// Get returns the value of a single register.
+func (rf *RegisterFile) Get(reg byte) byte {
+	return rf.Registers[reg]
+}
+
+// SetReadOnly marks the given registers as read-only: writes from the
+// controller to these registers are ignored.
+func (rf *RegisterFile) SetReadOnly(regs ...byte) {
+	for _, reg := range regs {
+		rf.readOnly[reg] = true
+	}
+}
+
+// I2CTx implements I2CDevice.
+func (rf *RegisterFile) I2CTx(w, r []byte) error {
+	if len(w) != 0 {
+		rf.pointer = w[0]
+		for _, value := range w[1:] {
+			rf.write(value)
+		}
+	}
+	for i := range r {
+		r[i] = rf.read()
+	}
+	return nil
+}
+
+// SPISelect implements SPIDevice.
+func (rf *RegisterFile) SPISelect(selected bool) {
+	rf.spiState = registersSPIIdle
+}
+
+// SPITransfer implements SPIDevice.
+func (rf *RegisterFile) SPITransfer(w byte) byte {
+	switch rf.spiState {
+	case registersSPIIdle:
+		rf.pointer = w & 0x7f
+		if w&0x80 != 0 {
+			rf.spiState = registersSPIRead
+		} else {
+			rf.spiState = registersSPIWrite
+		}
+	case registersSPIRead:
+		return rf.read()
+	case registersSPIWrite:
+		rf.write(w)
+	}
+	return 0xff
+}
+
+// read returns the register at the register pointer and increments the
+// pointer.
+func (rf *RegisterFile) read() byte {
+	reg := rf.pointer
+	rf.pointer++
+	if rf.OnRead != nil {
+		rf.OnRead(reg)
+	}
+	return rf.Registers[reg]
+}
+
+// write writes to the register at the register pointer and increments the
+// pointer.
+func (rf *RegisterFile) write(value byte) {
+	reg := rf.pointer
+	rf.pointer++
+	if rf.readOnly[reg] {
+		return
+	}
+	rf.Registers[reg] = value
+	if rf.OnWrite != nil {
+		rf.OnWrite(reg, value)
+	}
+}
@@ -0,0 +1,251 @@
+// +build !baremetal
+
+// Package simulator implements the hooks of the generic machine package (used
+// when building for the host or for WebAssembly) with virtual devices, so that
+// code using the machine package can run without any hardware.
+//
+// Importing this package is enough to link the simulator into a program:
+//
+//     import "machine/simulator"
+//
+//     func main() {
+//         led := simulator.AttachLED(machine.Pin(13))
+//         machine.Pin(13).Configure(machine.PinConfig{Mode: machine.PinOutput})
+//         machine.Pin(13).High()
+//         println(led.On()) // true
+//     }
+//
+// All devices are attached to a pin or bus number. Pins and buses without a
+// device behave like unconnected hardware: an output pin reads back what was
+// last written, an input pin reads its pull-up or pull-down level, an SPI bus
+// reads 0xff and an I2C transaction is not acknowledged.
+//
+// Devices can also be attached to an I2CBus or SPIBus that is not connected to
+// the machine package. These have the same methods as machine.I2C and
+// machine.SPI, so they can be passed to drivers that accept a bus interface.
+//
+// Besides simple devices such as LEDs and buttons, this package contains
+// models of common peripherals for testing drivers: a generic register file
+// (RegisterFile), an SPI NOR flash (Flash) and an SSD1306 display controller
+// (SSD1306).
+package simulator
+
+import (
+	"machine"
+	"unsafe"
+)
+
+// PinDevice is a device connected to a GPIO pin.
+type PinDevice interface {
+	// PinSet is called when the program drives the pin high or low.
+	PinSet(level bool)
+
+	// PinLevel returns the level the device drives the pin to. If driven is
+	// false, the device doesn't drive the pin and the level is determined by
+	// the pin configuration.
+	PinLevel() (level, driven bool)
+}
+
+// I2CDevice is a device connected to an I2C bus.
+type I2CDevice interface {
+	// I2CTx is called for each transaction addressed to the device. The
+	// device reads the bytes in w, which were written by the controller, and
+	// fills r with the bytes to be read by the controller. Returning an error
+	// signals a NACK to the controller.
+	I2CTx(w, r []byte) error
+}
+
+// SPIDevice is a device connected to an SPI bus.
+type SPIDevice interface {
+	// SPISelect is called when the chip select pin of the device changes.
+	// The chip select pin is active low, so selected is true when the pin is
+	// driven low.
+	SPISelect(selected bool)
+
+	// SPITransfer is called for each byte sent on the bus while the device
+	// is selected. It returns the byte sent back by the device.
+	SPITransfer(w byte) byte
+}
+
+type pinState struct {
+	mode   machine.PinMode
+	level  bool   // last level set by the program
+	analog uint16 // value returned by the ADC
+	pwm    uint16 // last value set through PWM
+	device PinDevice
+
+	// SPI buses with a device that uses this pin as chip select.
+	spiBuses []*SPIBus
+}
+
+var (
+	pins     = map[machine.Pin]*pinState{}
+	i2cBuses = map[uint8]*I2CBus{}
+	spiBuses = map[uint8]*SPIBus{}
+	uarts    = map[uint8]*uart{}
+)
+
+// Reset detaches all devices from the pins and buses of the machine package
+// and resets all pins to their initial state. Buses created with NewI2CBus
+// are not affected, but devices on buses created with NewSPIBus are detached
+// as well because their chip select pins are reset.
+func Reset() {
+	for pin, state := range pins {
+		for _, bus := range state.spiBuses {
+			bus.detach(pin)
+		}
+	}
+	pins = map[machine.Pin]*pinState{}
+	i2cBuses = map[uint8]*I2CBus{}
+	spiBuses = map[uint8]*SPIBus{}
+	uarts = map[uint8]*uart{}
+}
+
+// getPin returns the state of the given pin, creating it if needed.
+func getPin(pin machine.Pin) *pinState {
+	state := pins[pin]
+	if state == nil {
+		state = &pinState{}
+		pins[pin] = state
+	}
+	return state
+}
+
+// AttachPin connects a device to the given pin, replacing any device that
+// was connected before.
+func AttachPin(pin machine.Pin, dev PinDevice) {
+	getPin(pin).device = dev
+}
+
+// AttachI2C connects a device to the given I2C bus of the machine package, at
+// the given address.
+func AttachI2C(bus uint8, addr uint16, dev I2CDevice) {
+	getI2CBus(bus).Attach(addr, dev)
+}
+
+// AttachSPI connects a device to the given SPI bus of the machine package. The
+// device is selected when the chip select pin cs is driven low.
+func AttachSPI(bus uint8, cs machine.Pin, dev SPIDevice) {
+	getSPIBus(bus).Attach(cs, dev)
+}
+
+// getI2CBus returns the I2C bus with the given number in the machine package,
+// creating it if needed.
+func getI2CBus(bus uint8) *I2CBus {
+	b := i2cBuses[bus]
+	if b == nil {
+		b = NewI2CBus()
+		i2cBuses[bus] = b
+	}
+	return b
+}
+
+// getSPIBus returns the SPI bus with the given number in the machine package,
+// creating it if needed.
+func getSPIBus(bus uint8) *SPIBus {
+	b := spiBuses[bus]
+	if b == nil {
+		b = &SPIBus{}
+		spiBuses[bus] = b
+	}
+	return b
+}
+
+// SetAnalog sets the value that is read by the ADC on the given pin.
+func SetAnalog(pin machine.Pin, value uint16) {
+	getPin(pin).analog = value
+}
+
+// PWMValue returns the last value that was set through PWM on the given pin.
+func PWMValue(pin machine.Pin) uint16 {
+	return getPin(pin).pwm
+}
+
+//export __tinygo_gpio_configure
+func gpioConfigure(pin machine.Pin, config machine.PinConfig) {
+	state := getPin(pin)
+	state.mode = config.Mode
+	for _, bus := range state.spiBuses {
+		bus.chipSelect(pin)
+	}
+}
+
+//export __tinygo_gpio_set
+func gpioSet(pin machine.Pin, value bool) {
+	state := getPin(pin)
+	state.level = value
+	if state.device != nil {
+		state.device.PinSet(value)
+	}
+	for _, bus := range state.spiBuses {
+		bus.chipSelect(pin)
+	}
+}
+
+//export __tinygo_gpio_get
+func gpioGet(pin machine.Pin) bool {
+	state := getPin(pin)
+	if state.device != nil {
+		if level, driven := state.device.PinLevel(); driven {
+			return level
+		}
+	}
+	switch state.mode {
+	case machine.PinOutput:
+		return state.level
+	case machine.PinInputPullup:
+		return true
+	default:
+		return false
+	}
+}
+
+//export __tinygo_spi_configure
+func spiConfigure(bus uint8, sck machine.Pin, sdo machine.Pin, sdi machine.Pin) {
+}
+
+//export __tinygo_spi_transfer
+func spiTransfer(bus uint8, w uint8) uint8 {
+	r, _ := getSPIBus(bus).Transfer(w)
+	return r
+}
+
+//export __tinygo_i2c_configure
+func i2cConfigure(bus uint8, scl machine.Pin, sda machine.Pin) {
+}
+
+//export __tinygo_i2c_set_address
+func i2cSetAddress(bus uint8, addr uint16) {
+	getI2CBus(bus).address = addr
+}
+
+//export __tinygo_i2c_transfer
+func i2cTransfer(bus uint8, w *byte, wlen int, r *byte, rlen int) int {
+	b := getI2CBus(bus)
+	if b.Tx(b.address, bytes(w, wlen), bytes(r, rlen)) != nil {
+		return 1
+	}
+	return 0
+}
+
+//export __tinygo_adc_read
+func adcRead(pin machine.Pin) uint16 {
+	return getPin(pin).analog
+}
+
+//export __tinygo_pwm_set
+func pwmSet(pin machine.Pin, value uint16) {
+	state := getPin(pin)
+	state.pwm = value
+	if led, ok := state.device.(*LED); ok {
+		led.brightness = value
+	}
+}
+
+// bytes returns a slice for the given buffer passed to a hook.
+func bytes(buf *byte, length int) []byte {
+	if length == 0 {
+		return nil
+	}
+	return (*[1 << 30]byte)(unsafe.Pointer(buf))[:length:length]
+}
@@ -0,0 +1,180 @@
+// +build !baremetal
+
+package simulator
+
+import (
+	"machine"
+)
+
+// SPI NOR flash commands, as used by most serial flash chips.
+const (
+	flashCmdWriteStatus  = 0x01
+	flashCmdPageProgram  = 0x02
+	flashCmdRead         = 0x03
+	flashCmdWriteDisable = 0x04
+	flashCmdReadStatus   = 0x05
+	flashCmdWriteEnable  = 0x06
+	flashCmdFastRead     = 0x0b
+	flashCmdSectorErase  = 0x20
+	flashCmdBlockErase   = 0xd8
+	flashCmdChipErase    = 0xc7
+	flashCmdReadJEDECID  = 0x9f
+)
+
+// Bits in the status register of an SPI flash.
+const (
+	flashStatusBusy         = 1 << 0
+	flashStatusWriteEnabled = 1 << 1
+)
+
+const (
+	flashPageSize   = 256
+	flashSectorSize = 4096
+	flashBlockSize  = 64 * 1024
+)
+
+// Flash is a virtual SPI NOR flash chip, with 3-byte addresses. It implements
+// the common commands: JEDEC ID, read (normal and fast), write enable and
+// disable, read status, page program and sector, block and chip erase.
+//
+// Erasing sets all bytes to 0xff and programming can only clear bits, like a
+// real flash chip. Operations complete immediately, so the chip is never busy.
+type Flash struct {
+	// JEDECID is the manufacturer ID followed by the two device ID bytes, as
+	// returned by the JEDEC ID command.
+	JEDECID [3]byte
+
+	data     []byte
+	status   byte
+	selected bool
+	command  []byte // bytes received since the chip was selected
+	address  uint32 // current address of a read or page program
+}
+
+// NewFlash returns a new, erased flash chip of the given size in bytes, which
+// must be a multiple of the sector size (4kB). The JEDEC ID is that of a
+// Winbond W25Q series chip of the same size.
+func NewFlash(size int) *Flash {
+	if size <= 0 || size%flashSectorSize != 0 {
+		panic("simulator: flash size must be a positive multiple of 4kB")
+	}
+	f := &Flash{
+		data: make([]byte, size),
+	}
+	// Winbond, W25Q series, followed by log2 of the size.
+	f.JEDECID = [3]byte{0xef, 0x40, 0}
+	for s := size; s > 1; s >>= 1 {
+		f.JEDECID[2]++
+	}
+	for i := range f.data {
+		f.data[i] = 0xff
+	}
+	return f
+}
+
+// AttachFlash connects a new flash chip of the given size to an SPI bus, see
+// NewFlash and AttachSPI.
+func AttachFlash(bus uint8, cs machine.Pin, size int) *Flash {
+	f := NewFlash(size)
+	AttachSPI(bus, cs, f)
+	return f
+}
+
+// Data returns the contents of the flash chip. It can be modified, for
+// example to load a file system image before running the program.
+func (f *Flash) Data() []byte {
+	return f.data
+}
+
+// SPISelect implements SPIDevice.
+func (f *Flash) SPISelect(selected bool) {
+	if f.selected && !selected && len(f.command) != 0 {
+		f.finish()
+	}
+	f.selected = selected
+	f.command = f.command[:0]
+}
+
+// SPITransfer implements SPIDevice.
+func (f *Flash) SPITransfer(w byte) byte {
+	f.command = append(f.command, w)
+	cmd := f.command[0]
+	n := len(f.command) - 1 // number of bytes after the command byte
+	switch cmd {
+	case flashCmdReadJEDECID:
+		if n >= 1 && n <= 3 {
+			return f.JEDECID[n-1]
+		}
+	case flashCmdReadStatus:
+		if n >= 1 {
+			return f.status
+		}
+	case flashCmdRead, flashCmdFastRead:
+		dummy := 0
+		if cmd == flashCmdFastRead {
+			dummy = 1
+		}
+		if n == 3 {
+			f.address = f.commandAddress()
+		}
+		if n > 3+dummy {
+			b := f.data[f.address%uint32(len(f.data))]
+			f.address++
+			return b
+		}
+	case flashCmdPageProgram:
+		if n == 3 {
+			f.address = f.commandAddress()
+		}
+		if n > 3 && f.status&flashStatusWriteEnabled != 0 {
+			// Programming can only change bits from 1 to 0, and wraps
+			// around within the page.
+			f.data[f.address%uint32(len(f.data))] &= w
+			f.address = f.address&^(flashPageSize-1) | (f.address+1)&(flashPageSize-1)
+		}
+	}
+	return 0xff
+}
+
+// finish executes a command that takes effect when the chip is deselected.
+func (f *Flash) finish() {
+	switch f.command[0] {
+	case flashCmdWriteEnable:
+		f.status |= flashStatusWriteEnabled
+	case flashCmdWriteDisable, flashCmdPageProgram:
+		f.status &^= flashStatusWriteEnabled
+	case flashCmdWriteStatus:
+		// Only the write enable bit is implemented, which is cleared.
+		f.status &^= flashStatusWriteEnabled
+	case flashCmdSectorErase:
+		f.erase(flashSectorSize)
+	case flashCmdBlockErase:
+		f.erase(flashBlockSize)
+	case flashCmdChipErase:
+		f.erase(len(f.data))
+	}
+}
+
+// erase erases the region of the given size that contains the address sent
+// with the current command, if writing is enabled.
+func (f *Flash) erase(size int) {
+	if f.status&flashStatusWriteEnabled == 0 {
+		return
+	}
+	f.status &^= flashStatusWriteEnabled
+	start := 0
+	if size < len(f.data) {
+		if len(f.command) < 4 {
+			return
+		}
+		start = int(f.commandAddress()) % len(f.data) / size * size
+	}
+	for i := start; i < start+size && i < len(f.data); i++ {
+		f.data[i] = 0xff
+	}
+}
+
+// commandAddress returns the 24-bit address sent after the command byte.
+func (f *Flash) commandAddress() uint32 {
+	return uint32(f.command[1])<<16 | uint32(f.command[2])<<8 | uint32(f.command[3])
+}
@@ -0,0 +1,268 @@
+// +build !baremetal
+
+package simulator
+
+import (
+	"machine"
+)
+
+// Addressing modes of the SSD1306.
+const (
+	ssd1306HorizontalAddressing = 0
+	ssd1306VerticalAddressing   = 1
+	ssd1306PageAddressing       = 2
+)
+
+// SSD1306 is a model of an SSD1306 monochrome OLED display controller, which
+// captures everything written to the display memory in a framebuffer. It can
+// be connected to an I2C bus (usually at address 0x3C) or to an SPI bus, in
+// which case a data/command pin selects whether the controller receives
+// commands or data.
+//
+// The display memory is organized as in the controller: the display is split
+// in pages of 8 rows, and each byte holds a column of 8 pixels of a page with
+// the top pixel in the lowest bit. All addressing modes are implemented, other
+// commands (such as contrast and display on/off) are recorded or ignored.
+type SSD1306 struct {
+	width  int
+	height int
+	buffer []byte
+
+	on       bool
+	inverted bool
+	contrast byte
+
+	mode      byte
+	column    int
+	page      int
+	colStart  int
+	colEnd    int
+	pageStart int
+	pageEnd   int
+
+	command []byte      // command bytes received so far
+	dc      machine.Pin // data/command pin, when connected over SPI
+}
+
+// NewSSD1306 returns a new SSD1306 display controller for a display of the
+// given size, such as 128x64 or 128x32. The height must be a multiple of 8.
+// The display is initially off and empty, like after a reset.
+func NewSSD1306(width, height int) *SSD1306 {
+	return &SSD1306{
+		width:    width,
+		height:   height,
+		buffer:   make([]byte, width*height/8),
+		contrast: 0x7f,
+		mode:     ssd1306PageAddressing,
+		colEnd:   width - 1,
+		pageEnd:  height/8 - 1,
+		dc:       machine.NoPin,
+	}
+}
+
+// NewSSD1306SPI returns a new SSD1306 display controller connected to an
+// SPI bus, see NewSSD1306. The controller receives data when the dc pin is
+// high and commands when it is low.
+func NewSSD1306SPI(width, height int, dc machine.Pin) *SSD1306 {
+	d := NewSSD1306(width, height)
+	d.dc = dc
+	return d
+}
+
+// Width returns the width of the display in pixels.
+func (d *SSD1306) Width() int {
+	return d.width
+}
+
+// Height returns the height of the display in pixels.
+func (d *SSD1306) Height() int {
+	return d.height
+}
+
+// Buffer returns the display memory. See SSD1306 for its layout.
+func (d *SSD1306) Buffer() []byte {
+	return d.buffer
+}
+
+// Pixel returns whether the pixel at the given position is set in the display
+// memory.
+func (d *SSD1306) Pixel(x, y int) bool {
+	if x < 0 || y < 0 || x >= d.width || y >= d.height {
+		return false
+	}
+	return d.buffer[y/8*d.width+x]&(1<<uint(y%8)) != 0
+}
+
+// On returns whether the display was switched on.
+func (d *SSD1306) On() bool {
+	return d.on
+}
+
+// Inverted returns whether the display was set to inverse mode.
+func (d *SSD1306) Inverted() bool {
+	return d.inverted
+}
+
+// Contrast returns the contrast set for the display.
+func (d *SSD1306) Contrast() byte {
+	return d.contrast
+}
+
+// String returns the display memory as text, with a '#' for each pixel that
+// is set and a '.' for each pixel that is not.
+func (d *SSD1306) String() string {
+	buf := make([]byte, 0, (d.width+1)*d.height)
+	for y := 0; y < d.height; y++ {
+		for x := 0; x < d.width; x++ {
+			if d.Pixel(x, y) {
+				buf = append(buf, '#')
+			} else {
+				buf = append(buf, '.')
+			}
+		}
+		buf = append(buf, '\n')
+	}
+	return string(buf)
+}
+
+// I2CTx implements I2CDevice. Each write starts with a control byte, which
+// indicates whether the following bytes are commands or data. If the
+// continuation bit of the control byte is set, only one byte follows before
+// the next control byte.
+func (d *SSD1306) I2CTx(w, r []byte) error {
+	for len(w) >= 2 {
+		control := w[0]
+		n := len(w)
+		if control&0x80 != 0 {
+			n = 2
+		}
+		for _, b := range w[1:n] {
+			if control&0x40 != 0 {
+				d.writeData(b)
+			} else {
+				d.writeCommand(b)
+			}
+		}
+		w = w[n:]
+	}
+	for i := range r {
+		// Reading returns the status byte: bit 6 is set when the display is
+		// off.
+		r[i] = 0
+		if !d.on {
+			r[i] = 0x40
+		}
+	}
+	return nil
+}
+
+// SPISelect implements SPIDevice.
+func (d *SSD1306) SPISelect(selected bool) {
+}
+
+// SPITransfer implements SPIDevice.
+func (d *SSD1306) SPITransfer(w byte) byte {
+	if gpioGet(d.dc) {
+		d.writeData(w)
+	} else {
+		d.writeCommand(w)
+	}
+	return 0xff
+}
+
+// writeData writes a byte to the display memory and advances the address
+// according to the addressing mode.
+func (d *SSD1306) writeData(b byte) {
+	d.buffer[d.page*d.width+d.column] = b
+	switch d.mode {
+	case ssd1306HorizontalAddressing:
+		d.column++
+		if d.column > d.colEnd {
+			d.column = d.colStart
+			d.page++
+			if d.page > d.pageEnd {
+				d.page = d.pageStart
+			}
+		}
+	case ssd1306VerticalAddressing:
+		d.page++
+		if d.page > d.pageEnd {
+			d.page = d.pageStart
+			d.column++
+			if d.column > d.colEnd {
+				d.column = d.colStart
+			}
+		}
+	default:
+		d.column++
+		if d.column >= d.width {
+			d.column = 0
+		}
+	}
+}
+
+// ssd1306CommandLength returns the number of bytes of the command that starts
+// with the given byte, including that byte.
+func ssd1306CommandLength(cmd byte) int {
+	switch cmd {
+	case 0x20, 0x81, 0x8d, 0xa8, 0xd3, 0xd5, 0xd9, 0xda, 0xdb:
+		return 2
+	case 0x21, 0x22, 0xa3:
+		return 3
+	case 0x29, 0x2a:
+		return 6
+	case 0x26, 0x27:
+		return 7
+	default:
+		return 1
+	}
+}
+
+// writeCommand receives a command byte, and executes the command once all its
+// bytes were received.
+func (d *SSD1306) writeCommand(b byte) {
+	d.command = append(d.command, b)
+	if len(d.command) < ssd1306CommandLength(d.command[0]) {
+		return
+	}
+	cmd := d.command
+	d.command = d.command[:0]
+
+	switch {
+	case cmd[0] == 0xae || cmd[0] == 0xaf:
+		d.on = cmd[0] == 0xaf
+	case cmd[0] == 0xa6 || cmd[0] == 0xa7:
+		d.inverted = cmd[0] == 0xa7
+	case cmd[0] == 0x81:
+		d.contrast = cmd[1]
+	case cmd[0] == 0x20:
+		d.mode = cmd[1] & 0x03
+	case cmd[0] == 0x21:
+		d.colStart = clamp(int(cmd[1]&0x7f), d.width-1)
+		d.colEnd = clamp(int(cmd[2]&0x7f), d.width-1)
+		d.column = d.colStart
+	case cmd[0] == 0x22:
+		d.pageStart = clamp(int(cmd[1]&0x07), d.height/8-1)
+		d.pageEnd = clamp(int(cmd[2]&0x07), d.height/8-1)
+		d.page = d.pageStart
+	case cmd[0] >= 0xb0 && cmd[0] <= 0xb7:
+		// Page start address, for page addressing mode.
+		d.page = clamp(int(cmd[0]&0x07), d.height/8-1)
+	case cmd[0] <= 0x0f:
+		// Lower nibble of the column start address, for page addressing
+		// mode.
+		d.column = clamp(d.column&0xf0|int(cmd[0]&0x0f), d.width-1)
+	case cmd[0] >= 0x10 && cmd[0] <= 0x1f:
+		// Higher nibble of the column start address, for page addressing
+		// mode.
+		d.column = clamp(d.column&0x0f|int(cmd[0]&0x0f)<<4, d.width-1)
+	}
+}
+
+// clamp limits v to the range 0 to max.
+func clamp(v, max int) int {
+	if v > max {
+		return max
+	}
+	return v
+}
@@ -0,0 +1,87 @@
+// +build !baremetal
+
+package simulator
+
+import (
+	"io"
+	"machine"
+)
+
+type uart struct {
+	rw io.ReadWriter
+}
+
+// AttachUART connects a UART bus to the given reader and writer. Bytes read
+// from rw are received by the program, and bytes sent by the program are
+// written to rw. A read should not block when no data is available, as
+// reading from a UART doesn't block either.
+//
+// A UART without anything attached discards all data sent to it and never
+// receives any data.
+func AttachUART(bus uint8, rw io.ReadWriter) {
+	uarts[bus] = &uart{rw}
+}
+
+// Terminal is a virtual serial terminal connected to a UART, for use in
+// tests. The program receives the input typed with Type, and all data sent
+// by the program is collected in the output.
+type Terminal struct {
+	input  []byte
+	output []byte
+}
+
+// AttachTerminal connects a new terminal to the given UART bus.
+func AttachTerminal(bus uint8) *Terminal {
+	t := &Terminal{}
+	AttachUART(bus, t)
+	return t
+}
+
+// Type queues the given input, to be received by the program.
+func (t *Terminal) Type(input string) {
+	t.input = append(t.input, input...)
+}
+
+// Output returns all data sent by the program since the last call to Output.
+func (t *Terminal) Output() string {
+	output := string(t.output)
+	t.output = t.output[:0]
+	return output
+}
+
+// Read implements io.Reader, by returning the queued input.
+func (t *Terminal) Read(buf []byte) (int, error) {
+	n := copy(buf, t.input)
+	t.input = t.input[n:]
+	return n, nil
+}
+
+// Write implements io.Writer, by appending to the output.
+func (t *Terminal) Write(buf []byte) (int, error) {
+	t.output = append(t.output, buf...)
+	return len(buf), nil
+}
+
+//export __tinygo_uart_configure
+func uartConfigure(bus uint8, tx machine.Pin, rx machine.Pin) {
+}
+
+//export __tinygo_uart_read
+func uartRead(bus uint8, buf *byte, bufLen int) int {
+	u := uarts[bus]
+	if u == nil {
+		return 0
+	}
+	n, _ := u.rw.Read(bytes(buf, bufLen))
+	return n
+}
+
+//export __tinygo_uart_write
+func uartWrite(bus uint8, buf *byte, bufLen int) int {
+	u := uarts[bus]
+	if u == nil {
+		return bufLen
+	}
+	n, _ := u.rw.Write(bytes(buf, bufLen))
+	return n
+}
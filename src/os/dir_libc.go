@@ -0,0 +1,13 @@
+// +build darwin nintendoswitch wasi
+
+package os
+
+import (
+	_ "unsafe"
+)
+
+// readDirNames returns the names of all entries in the given directory except
+// for "." and "..", in directory order. It is implemented in the syscall
+// package using opendir and readdir from libc.
+//go:linkname readDirNames syscall.readDirNames
+func readDirNames(path string) ([]string, error)
@@ -0,0 +1,31 @@
+// +build linux,!baremetal,!wasi,!nintendoswitch,!wasm_unknown freebsd,!baremetal
+
+package os
+
+import (
+	"syscall"
+)
+
+// readDirNames returns the names of all entries in the given directory except
+// for "." and "..", in directory order.
+func readDirNames(path string) ([]string, error) {
+	fd, err := syscall.Open(path, syscall.O_RDONLY|syscall.O_DIRECTORY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.Close(fd)
+
+	var names []string
+	buf := make([]byte, 4096)
+	for {
+		n, err := syscall.ReadDirent(fd, buf)
+		if err != nil {
+			return nil, err
+		}
+		if n <= 0 {
+			break
+		}
+		_, _, names = syscall.ParseDirent(buf[:n], -1, names)
+	}
+	return names, nil
+}
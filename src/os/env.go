@@ -12,3 +12,9 @@ func Getenv(key string) string {
 func LookupEnv(key string) (string, bool) {
 	return syscall.Getenv(key)
 }
+
+// Environ returns a copy of strings representing the environment, in the form
+// "key=value".
+func Environ() []string {
+	return syscall.Environ()
+}
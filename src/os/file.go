@@ -6,6 +6,8 @@
 package os
 
 import (
+	"io"
+	"sort"
 	"syscall"
 )
 
@@ -37,10 +39,164 @@ func Remove(path string) error {
 	return nil
 }
 
+// Rename renames (moves) oldpath to newpath. If newpath already exists and is
+// not a directory, Rename replaces it. Both paths must be in the same mounted
+// filesystem. If the operation fails, it will return an error of type
+// *LinkError.
+func Rename(oldpath, newpath string) error {
+	fs, oldsuffix := findMount(oldpath)
+	newfs, newsuffix := findMount(newpath)
+	if fs == nil {
+		return &LinkError{"rename", oldpath, newpath, ErrNotExist}
+	}
+	renameFS, ok := fs.(RenameFS)
+	if !ok || fs != newfs {
+		return &LinkError{"rename", oldpath, newpath, ErrUnsupported}
+	}
+	err := renameFS.Rename(oldsuffix, newsuffix)
+	if err != nil {
+		return &LinkError{"rename", oldpath, newpath, err}
+	}
+	return nil
+}
+
+// Truncate changes the size of the named file. If the operation fails, it will
+// return an error of type *PathError.
+func Truncate(name string, size int64) error {
+	fs, suffix := findMount(name)
+	if fs == nil {
+		return &PathError{"truncate", name, ErrNotExist}
+	}
+	truncateFS, ok := fs.(TruncateFS)
+	if !ok {
+		return &PathError{"truncate", name, ErrUnsupported}
+	}
+	err := truncateFS.Truncate(suffix, size)
+	if err != nil {
+		return &PathError{"truncate", name, err}
+	}
+	return nil
+}
+
+// Symlink creates newname as a symbolic link to oldname. If the operation
+// fails, it will return an error of type *LinkError.
+func Symlink(oldname, newname string) error {
+	fs, suffix := findMount(newname)
+	if fs == nil {
+		return &LinkError{"symlink", oldname, newname, ErrNotExist}
+	}
+	symlinkFS, ok := fs.(SymlinkFS)
+	if !ok {
+		return &LinkError{"symlink", oldname, newname, ErrUnsupported}
+	}
+	err := symlinkFS.Symlink(oldname, suffix)
+	if err != nil {
+		return &LinkError{"symlink", oldname, newname, err}
+	}
+	return nil
+}
+
+// Readlink returns the destination of the named symbolic link. If the
+// operation fails, it will return an error of type *PathError.
+func Readlink(name string) (string, error) {
+	fs, suffix := findMount(name)
+	if fs == nil {
+		return "", &PathError{"readlink", name, ErrNotExist}
+	}
+	symlinkFS, ok := fs.(SymlinkFS)
+	if !ok {
+		return "", &PathError{"readlink", name, ErrUnsupported}
+	}
+	dest, err := symlinkFS.Readlink(suffix)
+	if err != nil {
+		return "", &PathError{"readlink", name, err}
+	}
+	return dest, nil
+}
+
+// Stat returns a FileInfo describing the named file. If the operation fails,
+// it will return an error of type *PathError.
+func Stat(name string) (FileInfo, error) {
+	fs, suffix := findMount(name)
+	if fs == nil {
+		return nil, &PathError{"stat", name, ErrNotExist}
+	}
+	statFS, ok := fs.(StatFS)
+	if !ok {
+		return nil, &PathError{"stat", name, ErrUnsupported}
+	}
+	info, err := statFS.Stat(suffix)
+	if err != nil {
+		return nil, &PathError{"stat", name, err}
+	}
+	return info, nil
+}
+
+// Lstat returns a FileInfo describing the named file. If the file is a symbolic
+// link, the returned FileInfo describes the symbolic link. If the operation
+// fails, it will return an error of type *PathError.
+func Lstat(name string) (FileInfo, error) {
+	fs, suffix := findMount(name)
+	if fs == nil {
+		return nil, &PathError{"lstat", name, ErrNotExist}
+	}
+	var info FileInfo
+	var err error
+	if lstatFS, ok := fs.(LstatFS); ok {
+		info, err = lstatFS.Lstat(suffix)
+	} else if statFS, ok := fs.(StatFS); ok {
+		// There are no symbolic links in this filesystem.
+		info, err = statFS.Stat(suffix)
+	} else {
+		err = ErrUnsupported
+	}
+	if err != nil {
+		return nil, &PathError{"lstat", name, err}
+	}
+	return info, nil
+}
+
+// ReadDir reads the named directory, returning all its directory entries sorted
+// by filename. If the operation fails, it will return an error of type
+// *PathError.
+func ReadDir(name string) ([]DirEntry, error) {
+	infos, err := readDir(name)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]DirEntry, len(infos))
+	for i, info := range infos {
+		entries[i] = dirEntry{info}
+	}
+	sort.Sort(dirEntrySlice(entries))
+	return entries, nil
+}
+
+// readDir returns a FileInfo for all entries in the named directory.
+func readDir(name string) ([]FileInfo, error) {
+	fs, suffix := findMount(name)
+	if fs == nil {
+		return nil, &PathError{"readdir", name, ErrNotExist}
+	}
+	readDirFS, ok := fs.(ReadDirFS)
+	if !ok {
+		return nil, &PathError{"readdir", name, ErrUnsupported}
+	}
+	infos, err := readDirFS.ReadDir(suffix)
+	if err != nil {
+		return nil, &PathError{"readdir", name, err}
+	}
+	return infos, nil
+}
+
 // File represents an open file descriptor.
 type File struct {
 	handle FileHandle
 	name   string
+
+	// dirEntries contains the directory entries that have not yet been
+	// returned by Readdir, after the first call to Readdir.
+	dirEntries *[]FileInfo
 }
 
 // Name returns the name of the file with which it was opened.
@@ -105,19 +261,76 @@ func (f *File) Close() (err error) {
 	return
 }
 
-// Readdir is a stub, not yet implemented
+// Readdir reads the contents of the directory associated with the file and
+// returns a slice of up to n FileInfo values, in directory order. Subsequent
+// calls on the same file will yield further FileInfos.
+//
+// If n > 0, Readdir returns at most n FileInfo structures. In this case, if
+// Readdir returns an empty slice, it will return io.EOF.
+//
+// If n <= 0, Readdir returns all the remaining FileInfo values from the
+// directory in a single slice.
 func (f *File) Readdir(n int) ([]FileInfo, error) {
-	return nil, &PathError{"readdir", f.name, ErrNotImplemented}
+	if f.dirEntries == nil {
+		// The directory is read in one go when Readdir is first called.
+		infos, err := readDir(f.name)
+		if err != nil {
+			return nil, err
+		}
+		f.dirEntries = &infos
+	}
+	remaining := *f.dirEntries
+	if n <= 0 {
+		*f.dirEntries = nil
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	*f.dirEntries = remaining[n:]
+	return remaining[:n], nil
 }
 
-// Readdirnames is a stub, not yet implemented
+// Readdirnames reads the contents of the directory associated with the file and
+// returns a slice of up to n names of files in the directory, in directory
+// order. It behaves like Readdir.
 func (f *File) Readdirnames(n int) (names []string, err error) {
-	return nil, &PathError{"readdirnames", f.name, ErrNotImplemented}
+	infos, err := f.Readdir(n)
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	return names, err
+}
+
+// ReadDir reads the contents of the directory associated with the file and
+// returns a slice of up to n DirEntry values, in directory order. It behaves
+// like Readdir.
+func (f *File) ReadDir(n int) ([]DirEntry, error) {
+	infos, err := f.Readdir(n)
+	entries := make([]DirEntry, len(infos))
+	for i, info := range infos {
+		entries[i] = dirEntry{info}
+	}
+	return entries, err
 }
 
-// Stat is a stub, not yet implemented
+// Stat returns the FileInfo structure describing the file. If the operation
+// fails, it will return an error of type *PathError.
 func (f *File) Stat() (FileInfo, error) {
-	return nil, &PathError{"stat", f.name, ErrNotImplemented}
+	handle, ok := f.handle.(StatFileHandle)
+	if !ok {
+		// Not all filesystems support stat on an open file handle, so look up
+		// the file by name instead.
+		return Stat(f.name)
+	}
+	info, err := handle.Stat(f.name)
+	if err != nil {
+		return nil, &PathError{"stat", f.name, err}
+	}
+	return info, nil
 }
 
 // Sync is a stub, not yet implemented
@@ -155,6 +368,23 @@ func (e *PathError) Error() string {
 	return e.Op + " " + e.Path + ": " + e.Err.Error()
 }
 
+func (e *PathError) Unwrap() error { return e.Err }
+
+// LinkError records an error during a link or symlink or rename system call and
+// the paths that caused it.
+type LinkError struct {
+	Op  string
+	Old string
+	New string
+	Err error
+}
+
+func (e *LinkError) Error() string {
+	return e.Op + " " + e.Old + " " + e.New + ": " + e.Err.Error()
+}
+
+func (e *LinkError) Unwrap() error { return e.Err }
+
 type FileMode uint32
 
 // Mode constants, copied from the mainline Go source
@@ -181,9 +411,24 @@ const (
 	ModePerm FileMode = 0777 // Unix permission bits
 )
 
-// IsDir is a stub, always returning false
+// IsDir reports whether m describes a directory.
 func (m FileMode) IsDir() bool {
-	return false
+	return m&ModeDir != 0
+}
+
+// IsRegular reports whether m describes a regular file.
+func (m FileMode) IsRegular() bool {
+	return m&ModeType == 0
+}
+
+// Perm returns the Unix permission bits in m.
+func (m FileMode) Perm() FileMode {
+	return m & ModePerm
+}
+
+// Type returns type bits in m (m & ModeType).
+func (m FileMode) Type() FileMode {
+	return m & ModeType
 }
 
 // Stub constants
@@ -208,39 +453,61 @@ type FileInfo interface {
 	Sys() interface{} // underlying data source (can return nil)
 }
 
-// Stat is a stub, not yet implemented
-func Stat(name string) (FileInfo, error) {
-	return nil, &PathError{"stat", name, ErrNotImplemented}
+// A DirEntry is an entry read from a directory (using the ReadDir function or a
+// File's ReadDir method).
+type DirEntry interface {
+	Name() string            // base name of the file
+	IsDir() bool             // whether the entry describes a directory
+	Type() FileMode          // type bits of the entry (Mode().Type())
+	Info() (FileInfo, error) // FileInfo of the entry, as returned by Lstat
 }
 
-// Lstat is a stub, not yet implemented
-func Lstat(name string) (FileInfo, error) {
-	return nil, &PathError{"lstat", name, ErrNotImplemented}
+// dirEntry implements DirEntry on top of the FileInfo returned by the
+// Filesystem.
+type dirEntry struct {
+	info FileInfo
 }
 
-// Getwd is a stub (for now), always returning an empty string
-func Getwd() (string, error) {
-	return "", nil
-}
+func (d dirEntry) Name() string            { return d.info.Name() }
+func (d dirEntry) IsDir() bool             { return d.info.IsDir() }
+func (d dirEntry) Type() FileMode          { return d.info.Mode().Type() }
+func (d dirEntry) Info() (FileInfo, error) { return d.info, nil }
 
-// Readlink is a stub (for now), always returning the string it was given
-func Readlink(name string) (string, error) {
-	return name, nil
-}
+// dirEntrySlice sorts directory entries by name.
+type dirEntrySlice []DirEntry
+
+func (s dirEntrySlice) Len() int           { return len(s) }
+func (s dirEntrySlice) Less(i, j int) bool { return s[i].Name() < s[j].Name() }
+func (s dirEntrySlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 
 // TempDir is a stub (for now), always returning the string "/tmp"
 func TempDir() string {
 	return "/tmp"
 }
 
-// IsExist is a stub (for now), always returning false
+// IsExist returns a boolean indicating whether the error is known to report
+// that a file or directory already exists.
 func IsExist(err error) bool {
-	return false
+	return underlyingError(err) == ErrExist
 }
 
-// IsNotExist is a stub (for now), always returning false
+// IsNotExist returns a boolean indicating whether the error is known to report
+// that a file or directory does not exist.
 func IsNotExist(err error) bool {
-	return false
+	return underlyingError(err) == ErrNotExist
+}
+
+// underlyingError returns the underlying error for known os error types.
+func underlyingError(err error) error {
+	switch err := err.(type) {
+	case *PathError:
+		return err.Err
+	case *LinkError:
+		return err.Err
+	case *SyscallError:
+		return err.Err
+	}
+	return err
 }
 
 // Getpid is a stub (for now), always returning 1
@@ -1,4 +1,4 @@
-// +build darwin linux,!baremetal,!wasi freebsd,!baremetal
+// +build darwin linux,!baremetal,!wasm_unknown freebsd,!baremetal
 
 package os
 
@@ -15,15 +15,34 @@ func init() {
 // Stdin, Stdout, and Stderr are open Files pointing to the standard input,
 // standard output, and standard error file descriptors.
 var (
-	Stdin  = &File{unixFileHandle(0), "/dev/stdin"}
-	Stdout = &File{unixFileHandle(1), "/dev/stdout"}
-	Stderr = &File{unixFileHandle(2), "/dev/stderr"}
+	Stdin  = &File{handle: unixFileHandle(0), name: "/dev/stdin"}
+	Stdout = &File{handle: unixFileHandle(1), name: "/dev/stdout"}
+	Stderr = &File{handle: unixFileHandle(2), name: "/dev/stderr"}
 )
 
 // isOS indicates whether we're running on a real operating system with
 // filesystem support.
 const isOS = true
 
+// Chdir changes the current working directory to the named directory. If there
+// is an error, it will be of type *PathError.
+func Chdir(dir string) error {
+	err := handleSyscallError(syscall.Chdir(dir))
+	if err != nil {
+		return &PathError{"chdir", dir, err}
+	}
+	return nil
+}
+
+// Getwd returns a rooted path name corresponding to the current directory.
+func Getwd() (string, error) {
+	wd, err := syscall.Getwd()
+	if err != nil {
+		return "", NewSyscallError("getwd", err)
+	}
+	return wd, nil
+}
+
 // unixFilesystem is an empty handle for a Unix/Linux filesystem. All operations
 // are relative to the current working directory.
 type unixFilesystem struct {
@@ -34,7 +53,80 @@ func (fs unixFilesystem) Mkdir(path string, perm FileMode) error {
 }
 
 func (fs unixFilesystem) Remove(path string) error {
-	return handleSyscallError(syscall.Unlink(path))
+	err := syscall.Unlink(path)
+	if err != nil {
+		// The path may be a directory, which must be removed with rmdir.
+		if syscall.Rmdir(path) == nil {
+			return nil
+		}
+	}
+	return handleSyscallError(err)
+}
+
+func (fs unixFilesystem) Rename(oldpath, newpath string) error {
+	return handleSyscallError(syscall.Rename(oldpath, newpath))
+}
+
+func (fs unixFilesystem) Truncate(path string, size int64) error {
+	return handleSyscallError(syscall.Truncate(path, size))
+}
+
+func (fs unixFilesystem) Stat(path string) (FileInfo, error) {
+	st := &fileStat{}
+	err := syscall.Stat(path, &st.sys)
+	if err != nil {
+		return nil, handleSyscallError(err)
+	}
+	fillFileStatFromSys(st, path)
+	return st, nil
+}
+
+func (fs unixFilesystem) Lstat(path string) (FileInfo, error) {
+	st := &fileStat{}
+	err := syscall.Lstat(path, &st.sys)
+	if err != nil {
+		return nil, handleSyscallError(err)
+	}
+	fillFileStatFromSys(st, path)
+	return st, nil
+}
+
+func (fs unixFilesystem) ReadDir(path string) ([]FileInfo, error) {
+	names, err := readDirNames(path)
+	if err != nil {
+		return nil, handleSyscallError(err)
+	}
+	infos := make([]FileInfo, 0, len(names))
+	for _, name := range names {
+		info, err := fs.Lstat(path + "/" + name)
+		if err == ErrNotExist {
+			// The file was removed after reading the directory.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (fs unixFilesystem) Symlink(oldpath, newpath string) error {
+	return handleSyscallError(syscall.Symlink(oldpath, newpath))
+}
+
+func (fs unixFilesystem) Readlink(path string) (string, error) {
+	for size := 128; ; size *= 2 {
+		buf := make([]byte, size)
+		n, err := syscall.Readlink(path, buf)
+		if err != nil {
+			return "", handleSyscallError(err)
+		}
+		if n < size {
+			return string(buf[:n]), nil
+		}
+		// The buffer may have been too small, try again with a bigger one.
+	}
 }
 
 func (fs unixFilesystem) OpenFile(path string, flag int, perm FileMode) (FileHandle, error) {
@@ -93,6 +185,18 @@ func (f unixFileHandle) Close() error {
 	return handleSyscallError(syscall.Close(int(f)))
 }
 
+// Stat returns a FileInfo describing the open file, using fstat. The name is
+// only used for the Name method of the FileInfo.
+func (f unixFileHandle) Stat(name string) (FileInfo, error) {
+	st := &fileStat{}
+	err := syscall.Fstat(int(f), &st.sys)
+	if err != nil {
+		return nil, handleSyscallError(err)
+	}
+	fillFileStatFromSys(st, name)
+	return st, nil
+}
+
 // handleSyscallError converts syscall errors into regular os package errors.
 // The err parameter must be either nil or of type syscall.Errno.
 func handleSyscallError(err error) error {
@@ -53,6 +53,81 @@ type FileHandle interface {
 	Close() (err error)
 }
 
+// The following interfaces can be implemented by a Filesystem to support more
+// operations. The os package returns ErrUnsupported for operations that the
+// filesystem doesn't implement.
+//
+// WARNING: these interfaces are not finalized and may change in a future
+// version.
+
+// RenameFS is a Filesystem that can rename files.
+type RenameFS interface {
+	Filesystem
+
+	// Rename renames (moves) a file. Both names are relative to this
+	// filesystem.
+	Rename(oldname, newname string) error
+}
+
+// TruncateFS is a Filesystem that can change the size of files.
+type TruncateFS interface {
+	Filesystem
+
+	// Truncate changes the size of the named file.
+	Truncate(name string, size int64) error
+}
+
+// StatFS is a Filesystem that can describe files.
+type StatFS interface {
+	Filesystem
+
+	// Stat returns a FileInfo describing the named file, following symbolic
+	// links.
+	Stat(name string) (FileInfo, error)
+}
+
+// LstatFS is a Filesystem with symbolic links that can describe files. If a
+// filesystem doesn't implement it, Lstat is the same as Stat.
+type LstatFS interface {
+	Filesystem
+
+	// Lstat returns a FileInfo describing the named file. If the file is a
+	// symbolic link, the FileInfo describes the link itself.
+	Lstat(name string) (FileInfo, error)
+}
+
+// ReadDirFS is a Filesystem that can list directories.
+type ReadDirFS interface {
+	Filesystem
+
+	// ReadDir returns a FileInfo for every entry in the named directory
+	// (except for "." and ".."), in directory order.
+	ReadDir(name string) ([]FileInfo, error)
+}
+
+// SymlinkFS is a Filesystem that supports symbolic links.
+type SymlinkFS interface {
+	Filesystem
+
+	// Symlink creates newname as a symbolic link to oldname. The oldname is
+	// stored as-is in the link.
+	Symlink(oldname, newname string) error
+
+	// Readlink returns the destination of the named symbolic link.
+	Readlink(name string) (string, error)
+}
+
+// StatFileHandle is a FileHandle that can describe the open file, which is
+// used by File.Stat. If a file handle doesn't implement it, File.Stat looks up
+// the file by name instead.
+type StatFileHandle interface {
+	FileHandle
+
+	// Stat returns a FileInfo describing the open file. The name is the name
+	// with which the file was opened.
+	Stat(name string) (FileInfo, error)
+}
+
 // findMount returns the appropriate (mounted) filesystem to use for a given
 // filename plus the path relative to that filesystem.
 func findMount(path string) (Filesystem, string) {
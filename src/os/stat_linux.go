@@ -0,0 +1,16 @@
+// +build !baremetal,!wasm_unknown
+
+package os
+
+import (
+	"time"
+)
+
+// fillFileStatFromSys fills in the FileInfo fields from the stat structure
+// returned by the system, which is already stored in fs.sys.
+func fillFileStatFromSys(fs *fileStat, name string) {
+	fs.name = basename(name)
+	fs.size = fs.sys.Size
+	fs.modTime = time.Unix(int64(fs.sys.Mtim.Sec), int64(fs.sys.Mtim.Nsec))
+	fillFileStatMode(fs, uint32(fs.sys.Mode))
+}
@@ -27,6 +27,20 @@ var (
 
 const wasmPageSize = 64 * 1024
 
+// __wasm_call_ctors runs the constructors of the C library (for example, the
+// one that registers the preopened directories in wasi-libc). It is created by
+// the linker.
+//export __wasm_call_ctors
+func __wasm_call_ctors()
+
+func init() {
+	// The constructors may call malloc, so they can only run once the heap is
+	// initialized. Because the runtime calls them itself, wasm-ld doesn't call
+	// them at the start of every exported function (including _start, before
+	// the heap is initialized).
+	__wasm_call_ctors()
+}
+
 // Align on word boundary.
 func align(ptr uintptr) uintptr {
 	return (ptr + 3) &^ 3
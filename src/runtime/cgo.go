@@ -0,0 +1,81 @@
+package runtime
+
+// This file implements the helper functions of the C pseudo-package that is
+// available with CGo (C.CString, C.GoString, etc). They are declared in every
+// package that uses them by the cgo package, with a //go:linkname to the
+// functions below.
+
+import (
+	"unsafe"
+)
+
+// The malloc implementation of the libc (picolibc, wasi-libc, the system libc),
+// or the one in the runtime on baremetal systems without a libc.
+//
+//export malloc
+func cgo_malloc(size uintptr) unsafe.Pointer
+
+// cgo_CString implements C.CString. It copies the Go string into a
+// NUL-terminated C string, allocated with malloc. The caller is responsible for
+// freeing it, usually with C.free.
+func cgo_CString(s _string) unsafe.Pointer {
+	buf := cgo_malloc(s.length + 1)
+	if buf == nil {
+		runtimePanic("C malloc failed")
+	}
+	memcpy(buf, unsafe.Pointer(s.ptr), s.length)
+	*(*byte)(unsafe.Pointer(uintptr(buf) + s.length)) = 0 // trailing NUL byte
+	return buf
+}
+
+// cgo_CBytes implements C.CBytes. It copies the byte slice into a new C array,
+// allocated with malloc. The caller is responsible for freeing it.
+func cgo_CBytes(b []byte) unsafe.Pointer {
+	size := uintptr(len(b))
+	if size == 0 {
+		// Like gc, always return a non-nil pointer.
+		size = 1
+	}
+	buf := cgo_malloc(size)
+	if buf == nil {
+		runtimePanic("C malloc failed")
+	}
+	if len(b) != 0 {
+		memcpy(buf, unsafe.Pointer(&b[0]), uintptr(len(b)))
+	}
+	return buf
+}
+
+// cgo_GoString implements C.GoString. It copies the NUL-terminated C string
+// into a new Go string. A nil pointer results in an empty string.
+func cgo_GoString(cstr unsafe.Pointer) _string {
+	if cstr == nil {
+		return _string{}
+	}
+	length := uintptr(0)
+	for *(*byte)(unsafe.Pointer(uintptr(cstr) + length)) != 0 {
+		length++
+	}
+	return cgo_GoStringN(cstr, length)
+}
+
+// cgo_GoStringN implements C.GoStringN. It copies length bytes of the C string
+// into a new Go string.
+func cgo_GoStringN(cstr unsafe.Pointer, length uintptr) _string {
+	if length == 0 {
+		return _string{}
+	}
+	buf := alloc(length)
+	memcpy(buf, cstr, length)
+	return _string{ptr: (*byte)(buf), length: length}
+}
+
+// cgo_GoBytes implements C.GoBytes. It copies length bytes of the C array into
+// a new byte slice.
+func cgo_GoBytes(ptr unsafe.Pointer, length uintptr) []byte {
+	buf := make([]byte, length)
+	if length != 0 {
+		memcpy(unsafe.Pointer(&buf[0]), ptr, length)
+	}
+	return buf
+}
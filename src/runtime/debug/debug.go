@@ -0,0 +1,144 @@
+// Package debug is a very partially implemented package to allow compilation.
+// It mostly provides information about the build, which is embedded by the
+// compiler.
+package debug
+
+import (
+	"runtime"
+	"strings"
+)
+
+// modinfo is set by the compiler (just like a -ldflags="-X ..." flag) and
+// contains the module and build information of the program in the same text
+// format as used by the go command.
+var modinfo string
+
+// BuildInfo represents the build information read from the running binary.
+type BuildInfo struct {
+	Path     string         // The main package path
+	Main     Module         // The module containing the main package
+	Deps     []*Module      // Module dependencies
+	Settings []BuildSetting // Other information about the build.
+}
+
+// Module represents a module.
+type Module struct {
+	Path    string  // module path
+	Version string  // module version
+	Sum     string  // checksum
+	Replace *Module // replaced by this module
+}
+
+// BuildSetting is a key-value pair describing one setting that influenced a
+// build, such as the build tags, the target and version control information
+// (vcs.revision, vcs.time and vcs.modified).
+type BuildSetting struct {
+	Key, Value string
+}
+
+// ReadBuildInfo returns the build information embedded in the running binary.
+// The information is only available when the program was built with module
+// support.
+func ReadBuildInfo() (info *BuildInfo, ok bool) {
+	if modinfo == "" {
+		return nil, false
+	}
+	info = &BuildInfo{}
+	var last *Module
+	for _, line := range strings.Split(modinfo, "\n") {
+		fields := strings.Split(line, "\t")
+		switch fields[0] {
+		case "path":
+			if len(fields) >= 2 {
+				info.Path = fields[1]
+			}
+		case "mod":
+			info.Main = readModule(fields)
+			last = &info.Main
+		case "dep":
+			last = new(Module)
+			*last = readModule(fields)
+			info.Deps = append(info.Deps, last)
+		case "=>":
+			if last != nil {
+				replace := readModule(fields)
+				last.Replace = &replace
+				last = nil
+			}
+		case "build":
+			if len(fields) >= 2 {
+				if index := strings.IndexByte(fields[1], '='); index >= 0 {
+					info.Settings = append(info.Settings, BuildSetting{
+						Key:   fields[1][:index],
+						Value: fields[1][index+1:],
+					})
+				}
+			}
+		}
+	}
+	return info, true
+}
+
+// readModule reads a module from a single mod, dep or => line.
+func readModule(fields []string) Module {
+	var m Module
+	if len(fields) >= 2 {
+		m.Path = fields[1]
+	}
+	if len(fields) >= 3 {
+		m.Version = fields[2]
+	}
+	if len(fields) >= 4 {
+		m.Sum = fields[3]
+	}
+	return m
+}
+
+// String returns the build information in the same format as the go command
+// (and `go version -m`) uses.
+func (bi *BuildInfo) String() string {
+	buf := &strings.Builder{}
+	buf.WriteString("path\t" + bi.Path + "\n")
+	var writeModule func(word string, m *Module)
+	writeModule = func(word string, m *Module) {
+		buf.WriteString(word + "\t" + m.Path + "\t" + m.Version)
+		if m.Replace == nil && m.Sum != "" {
+			buf.WriteString("\t" + m.Sum)
+		}
+		buf.WriteString("\n")
+		if m.Replace != nil {
+			writeModule("=>", m.Replace)
+		}
+	}
+	if bi.Main.Path != "" {
+		writeModule("mod", &bi.Main)
+	}
+	for _, dep := range bi.Deps {
+		writeModule("dep", dep)
+	}
+	for _, s := range bi.Settings {
+		buf.WriteString("build\t" + s.Key + "=" + s.Value + "\n")
+	}
+	return buf.String()
+}
+
+// SetGCPercent is not supported: the garbage collector is not tunable. It
+// returns the previous setting, which is always 100.
+func SetGCPercent(percent int) int {
+	return 100
+}
+
+// FreeOSMemory forces a garbage collection.
+func FreeOSMemory() {
+	runtime.GC()
+}
+
+// Stack returns a formatted stack trace of the goroutine that calls it. Stack
+// traces are not supported, so it always returns nil.
+func Stack() []byte {
+	return nil
+}
+
+// PrintStack prints to standard error the stack trace returned by Stack.
+func PrintStack() {
+}
@@ -0,0 +1,8 @@
+// +build !wasi
+
+package runtime
+
+// pollIO wakes up goroutines that wait for I/O. There is no such I/O on this
+// platform, so it does nothing.
+func pollIO() {
+}
@@ -23,14 +23,56 @@ func GOROOT() string {
 	return "/usr/local/go"
 }
 
-// TODO: fill with real args.
-var args = []string{"/proc/self/exe"}
+// Command line arguments and environment variables (in "key=value" form) of
+// the program. They are read from the system on first use (see loadArgs).
+var (
+	args       []string
+	env        []string
+	argsLoaded bool
+)
+
+// loadArgs reads the command line arguments and environment variables from the
+// system, if that hasn't been done yet. This can't be done during startup,
+// because they are stored on the heap, which may not be initialized yet.
+func loadArgs() {
+	if !argsLoaded {
+		argsLoaded = true
+		initArgs()
+	}
+}
 
 //go:linkname os_runtime_args os.runtime_args
 func os_runtime_args() []string {
+	loadArgs()
+	if args == nil {
+		// There are no arguments on this system, so use a placeholder for
+		// the program name.
+		args = []string{"/proc/self/exe"}
+	}
 	return args
 }
 
+// goStrings converts a list of NUL-terminated C strings, such as argv, to Go
+// strings. A negative count means the list ends with a nil pointer.
+func goStrings(list *unsafe.Pointer, count int) []string {
+	if list == nil {
+		return nil
+	}
+	if count < 0 {
+		count = 0
+		for *(*unsafe.Pointer)(unsafe.Pointer(uintptr(unsafe.Pointer(list)) + uintptr(count)*unsafe.Sizeof(list))) != nil {
+			count++
+		}
+	}
+	strs := make([]string, count)
+	for i := range strs {
+		cstr := *(*unsafe.Pointer)(unsafe.Pointer(uintptr(unsafe.Pointer(list)) + uintptr(i)*unsafe.Sizeof(list)))
+		s := cgo_GoString(cstr)
+		strs[i] = *(*string)(unsafe.Pointer(&s))
+	}
+	return strs
+}
+
 // Copy size bytes from src to dst. The memory areas must not overlap.
 // Calls to this function are converted to LLVM intrinsic calls such as
 // llvm.memcpy.p0i8.p0i8.i32(dst, src, size, false).
@@ -92,5 +134,6 @@ func os_sigpipe() {
 
 //go:linkname syscall_runtime_envs syscall.runtime_envs
 func syscall_runtime_envs() []string {
-	return nil
+	loadArgs()
+	return env
 }
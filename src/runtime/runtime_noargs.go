@@ -0,0 +1,7 @@
+// +build baremetal js nintendoswitch wasm_unknown
+
+package runtime
+
+// initArgs does nothing: there are no command line arguments or environment
+// variables on these systems.
+func initArgs() {}
@@ -1,4 +1,4 @@
-// +build darwin linux,!baremetal,!wasi freebsd,!baremetal
+// +build darwin linux,!baremetal,!wasi,!wasm_unknown freebsd,!baremetal
 // +build !nintendoswitch
 
 package runtime
@@ -39,23 +39,46 @@ const CLOCK_MONOTONIC_RAW = 4
 
 var stackTop uintptr
 
+// The environment of the process, as provided by the libc.
+//go:extern environ
+var libc_environ *unsafe.Pointer
+
+// The command line arguments, as passed to main.
+var (
+	main_argc int32
+	main_argv *unsafe.Pointer
+)
+
 func postinit() {}
 
 // Entry point for Go. Initialize all packages and call main.main().
 //export main
-func main() int {
+func main(argc int32, argv *unsafe.Pointer) int {
 	preinit()
 
 	// Obtain the initial stack pointer right before calling the run() function.
 	// The run function has been moved to a separate (non-inlined) function so
 	// that the correct stack pointer is read.
 	stackTop = getCurrentStackPointer()
+
+	// Store the command line arguments, to be converted to Go strings once
+	// they are needed (see initArgs).
+	main_argc = argc
+	main_argv = argv
+
 	runMain()
 
 	// For libc compatibility.
 	return 0
 }
 
+// initArgs converts the command line arguments and environment variables
+// provided by the libc to Go strings.
+func initArgs() {
+	args = goStrings(main_argv, int(main_argc))
+	env = goStrings(libc_environ, -1)
+}
+
 // Must be a separate function to get the correct stack pointer.
 //go:noinline
 func runMain() {
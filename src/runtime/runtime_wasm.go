@@ -1,4 +1,4 @@
-// +build wasm
+// +build wasm,!wasm_unknown
 
 package runtime
 
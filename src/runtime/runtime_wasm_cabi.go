@@ -0,0 +1,62 @@
+// +build wasm
+
+package runtime
+
+// This file implements runtime support for the canonical WebAssembly ABI
+// (-wasm-abi=canonical), in which strings, slices and structs are passed
+// between the host and exported or imported functions through linear memory.
+// The host allocates this memory with cabi_realloc, which is exported by the
+// compiler and calls cabiRealloc.
+
+import (
+	"unsafe"
+)
+
+// The number of canonical ABI exports that are currently running.
+var cabiDepth int
+
+// cabiRealloc allocates (or reallocates) memory on behalf of the host, for
+// example to store a string that is passed to an exported function. The memory
+// is allocated on the Go heap and is kept alive until the outermost exported
+// function returns, or, for results of imported functions, until the results
+// have been converted to Go values.
+func cabiRealloc(oldPtr unsafe.Pointer, oldSize, align, newSize uintptr) unsafe.Pointer {
+	// The heap always uses the maximum alignment, so align can be ignored.
+	ptr := hostAllocTemporary(newSize)
+	if oldPtr != nil {
+		size := oldSize
+		if newSize < size {
+			size = newSize
+		}
+		memcpy(ptr, oldPtr, size)
+	}
+	return ptr
+}
+
+// cabiEnter is called by the compiler when an exported function is called by
+// the host.
+func cabiEnter() {
+	cabiDepth++
+}
+
+// cabiExit is called by the compiler when an exported function returns to the
+// host. Memory allocated by the host is released when the outermost exported
+// function returns.
+func cabiExit() {
+	cabiDepth--
+	if cabiDepth == 0 {
+		cabiUnpin(0)
+	}
+}
+
+// cabiPinCount returns the current pin mark, for use with cabiUnpin.
+func cabiPinCount() int {
+	return pinMark
+}
+
+// cabiUnpin releases all memory allocated by the host after the given pin
+// mark was taken. It does nothing if that memory was already released (by an
+// exported function called from within an imported function).
+func cabiUnpin(count int) {
+	hostUnpin(count)
+}
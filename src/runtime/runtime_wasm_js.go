@@ -1,4 +1,4 @@
-// +build wasm,!wasi
+// +build wasm,!wasi,!wasm_unknown
 
 package runtime
 
@@ -11,6 +11,7 @@ func _start() {
 	// These need to be initialized early so that the heap can be initialized.
 	heapStart = uintptr(unsafe.Pointer(&heapStartSymbol))
 	heapEnd = uintptr(wasm_memory_size(0) * wasmPageSize)
+	wasmStarted = true
 
 	run()
 }
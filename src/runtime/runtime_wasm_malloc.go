@@ -0,0 +1,107 @@
+// +build wasm,!gc.none
+
+package runtime
+
+// This file replaces the allocator of the C library (wasi-libc) with one that
+// allocates from the Go heap. The functions are exported from the WebAssembly
+// module, so that the host can allocate memory in the module, for example to
+// pass a string to an exported function:
+//
+//     ptr = malloc(len)
+//     ... write the string to memory at ptr ...
+//     call the exported function with ptr and len
+//     free(ptr)
+//
+// Every allocation is pinned until it is freed, see runtime_wasm_pin.go.
+//
+// All entry points of the malloc implementation of wasi-libc are defined
+// here. If C code referenced one that is missing, the linker would add the
+// wasi-libc malloc as well, with a second heap that the garbage collector
+// doesn't know about.
+
+import (
+	"unsafe"
+)
+
+//export malloc
+func libc_malloc(size uintptr) unsafe.Pointer {
+	if size == 0 {
+		return nil
+	}
+	return hostAlloc(size)
+}
+
+//export calloc
+func libc_calloc(nmemb, size uintptr) unsafe.Pointer {
+	if size != 0 && nmemb > ^uintptr(0)/size {
+		// Overflow.
+		return nil
+	}
+	// Memory returned by alloc is already zeroed.
+	return libc_malloc(nmemb * size)
+}
+
+//export realloc
+func libc_realloc(oldPtr unsafe.Pointer, size uintptr) unsafe.Pointer {
+	if oldPtr == nil {
+		return libc_malloc(size)
+	}
+	oldSize, ok := hostSize(oldPtr)
+	if !ok {
+		runtimePanic("realloc: invalid pointer")
+	}
+	if size == 0 {
+		libc_free(oldPtr)
+		return nil
+	}
+	ptr := libc_malloc(size)
+	if oldSize < size {
+		size = oldSize
+	}
+	memcpy(ptr, oldPtr, size)
+	hostFree(oldPtr)
+	return ptr
+}
+
+//export posix_memalign
+func libc_posix_memalign(memptr *unsafe.Pointer, alignment, size uintptr) int32 {
+	if alignment < unsafe.Sizeof(uintptr(0)) || alignment&(alignment-1) != 0 {
+		return 28 // EINVAL in WASI
+	}
+	if size == 0 {
+		*memptr = nil
+		return 0
+	}
+	*memptr = hostAllocAligned(size, alignment)
+	return 0
+}
+
+//export aligned_alloc
+func libc_aligned_alloc(alignment, size uintptr) unsafe.Pointer {
+	if alignment == 0 || alignment&(alignment-1) != 0 || size == 0 {
+		return nil
+	}
+	return hostAllocAligned(size, alignment)
+}
+
+//export malloc_usable_size
+func libc_malloc_usable_size(ptr unsafe.Pointer) uintptr {
+	if ptr == nil {
+		return 0
+	}
+	size, ok := hostSize(ptr)
+	if !ok {
+		runtimePanic("malloc_usable_size: invalid pointer")
+	}
+	return size
+}
+
+//export free
+func libc_free(ptr unsafe.Pointer) {
+	if ptr == nil {
+		return
+	}
+	if !hostFree(ptr) {
+		runtimePanic("free: invalid pointer")
+	}
+}
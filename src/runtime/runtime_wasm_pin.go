@@ -0,0 +1,107 @@
+// +build wasm
+
+package runtime
+
+// This file keeps track of memory that is allocated on behalf of the host,
+// with malloc (see runtime_wasm_malloc.go) or with cabi_realloc (see
+// runtime_wasm_cabi.go). The garbage collector can't see pointers held by the
+// host, so this memory is pinned until it is released.
+
+import (
+	"unsafe"
+)
+
+// pinnedObject describes a single allocation made on behalf of the host.
+type pinnedObject struct {
+	size uintptr
+
+	// The pin mark of a temporary allocation (see hostAllocTemporary), or 0 for
+	// allocations that are pinned until they are unpinned explicitly.
+	mark int
+}
+
+var (
+	// All memory that was allocated by the host and not yet released. This map
+	// is reachable from a global, so the garbage collector treats the
+	// allocations as roots.
+	pinned map[unsafe.Pointer]pinnedObject
+
+	// The mark of the most recent temporary allocation.
+	pinMark int
+
+	// Set by _start. The heap is not initialized before that, so the host must
+	// not allocate memory in the module (or call any other export) before
+	// running _start. The C constructors, which may also allocate memory, are
+	// run by the runtime after the heap is initialized (see arch_wasm.go).
+	wasmStarted bool
+)
+
+// hostAlloc allocates memory for the host and pins it until it is released
+// with hostFree.
+func hostAlloc(size uintptr) unsafe.Pointer {
+	return hostPin(size, 1, 0)
+}
+
+// hostAllocAligned is like hostAlloc, but the returned pointer is a multiple
+// of the given alignment, which must be a power of two.
+func hostAllocAligned(size, alignment uintptr) unsafe.Pointer {
+	return hostPin(size, alignment, 0)
+}
+
+// hostAllocTemporary allocates memory for the host and pins it until
+// hostUnpin is called with a mark from before this allocation.
+func hostAllocTemporary(size uintptr) unsafe.Pointer {
+	pinMark++
+	return hostPin(size, 1, pinMark)
+}
+
+func hostPin(size, alignment uintptr, mark int) unsafe.Pointer {
+	if !wasmStarted {
+		runtimePanic("memory allocated before _start")
+	}
+	if pinned == nil {
+		pinned = make(map[unsafe.Pointer]pinnedObject)
+	}
+	ptr := alloc(size)
+	if uintptr(ptr)&(alignment-1) != 0 {
+		// Allocate enough memory to find an aligned pointer inside it. The
+		// garbage collector keeps the whole object alive through this
+		// pointer, and collects the first allocation.
+		base := alloc(size + alignment - 1)
+		ptr = unsafe.Pointer((uintptr(base) + alignment - 1) &^ (alignment - 1))
+	}
+	pinned[ptr] = pinnedObject{size: size, mark: mark}
+	return ptr
+}
+
+// hostSize returns the size of the given pinned allocation.
+func hostSize(ptr unsafe.Pointer) (uintptr, bool) {
+	obj, ok := pinned[ptr]
+	return obj.size, ok
+}
+
+// hostFree releases a single pinned allocation. It returns false if the
+// pointer was not allocated by the host.
+func hostFree(ptr unsafe.Pointer) bool {
+	if _, ok := pinned[ptr]; !ok {
+		return false
+	}
+	// The memory is collected by the next GC cycle, if nothing else refers to
+	// it.
+	delete(pinned, ptr)
+	return true
+}
+
+// hostUnpin releases all temporary allocations made after the given mark.
+func hostUnpin(mark int) {
+	if mark >= pinMark {
+		// Nothing to release.
+		return
+	}
+	for ptr, obj := range pinned {
+		if obj.mark > mark {
+			delete(pinned, ptr)
+		}
+	}
+	pinMark = mark
+}
@@ -0,0 +1,89 @@
+// +build wasm,wasm_unknown
+
+package runtime
+
+import (
+	"unsafe"
+)
+
+// This is the runtime for WebAssembly modules that are embedded in a custom
+// host, which provides neither WASI nor the JavaScript imports. The runtime
+// itself doesn't import anything: output, time, exiting and aborting go
+// through the hooks below. They have a default implementation (see
+// targets/wasm-unknown.c) that can be replaced by the program.
+
+type timeUnit int64
+
+//export tinygo_putchar
+func hookPutchar(c byte)
+
+//export tinygo_ticks
+func hookTicks() int64
+
+//export tinygo_sleep
+func hookSleep(ns int64)
+
+//export tinygo_exit
+func hookExit(code int32)
+
+//export tinygo_abort
+func hookAbort()
+
+//export _start
+func _start() {
+	// These need to be initialized early so that the heap can be initialized.
+	heapStart = uintptr(unsafe.Pointer(&heapStartSymbol))
+	heapEnd = uintptr(wasm_memory_size(0) * wasmPageSize)
+	wasmStarted = true
+	run()
+}
+
+func postinit() {}
+
+func putchar(c byte) {
+	hookPutchar(c)
+}
+
+// abort calls the abort hook, and traps if it returns.
+func abort() {
+	hookAbort()
+	trap()
+}
+
+func ticksToNanoseconds(ticks timeUnit) int64 {
+	return int64(ticks)
+}
+
+func nanosecondsToTicks(ns int64) timeUnit {
+	return timeUnit(ns)
+}
+
+const asyncScheduler = false
+
+func sleepTicks(d timeUnit) {
+	hookSleep(int64(d))
+}
+
+func ticks() timeUnit {
+	return timeUnit(hookTicks())
+}
+
+//go:linkname syscall_Exit syscall.Exit
+func syscall_Exit(code int) {
+	hookExit(int32(code))
+	// The hook returned, which the default hook does for code 0. There is no
+	// way to unwind the stack back to the host, so stop here without
+	// reporting a fatal error.
+	trap()
+}
+
+// TinyGo does not yet support any form of parallelism on WebAssembly, so these
+// can be left empty.
+
+//go:linkname procPin sync/atomic.runtime_procPin
+func procPin() {
+}
+
+//go:linkname procUnpin sync/atomic.runtime_procUnpin
+func procUnpin() {
+}
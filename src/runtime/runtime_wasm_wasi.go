@@ -3,6 +3,7 @@
 package runtime
 
 import (
+	"internal/task"
 	"unsafe"
 )
 
@@ -13,9 +14,30 @@ func _start() {
 	// These need to be initialized early so that the heap can be initialized.
 	heapStart = uintptr(unsafe.Pointer(&heapStartSymbol))
 	heapEnd = uintptr(wasm_memory_size(0) * wasmPageSize)
+	wasmStarted = true
 	run()
 }
 
+// initArgs reads the command line arguments and environment variables from
+// the host.
+func initArgs() {
+	var count, bufSize uint32
+	if args_sizes_get(&count, &bufSize) == 0 && count != 0 {
+		list := make([]unsafe.Pointer, count)
+		buf := make([]byte, bufSize)
+		if args_get(&list[0], &buf[0]) == 0 {
+			args = goStrings(&list[0], int(count))
+		}
+	}
+	if environ_sizes_get(&count, &bufSize) == 0 && count != 0 {
+		list := make([]unsafe.Pointer, count)
+		buf := make([]byte, bufSize)
+		if environ_get(&list[0], &buf[0]) == 0 {
+			env = goStrings(&list[0], int(count))
+		}
+	}
+}
+
 func ticksToNanoseconds(ticks timeUnit) int64 {
 	return int64(ticks)
 }
@@ -29,27 +51,168 @@ const (
 	timePrecisionNanoseconds = 1000 // TODO: how can we determine the appropriate `precision`?
 )
 
+// Goroutines that wait for a file descriptor to become ready, linked through
+// their Next field. The Data field contains the file descriptor and whether the
+// goroutine waits for reading or writing (see fdWaitData).
+var fdWaiters *task.Task
+
+// Buffers passed to poll_oneoff. They are reused between calls to avoid heap
+// allocations in the scheduler.
 var (
-	sleepTicksSubscription = __wasi_subscription_t{
-		userData: 0,
+	pollSubscriptions []__wasi_subscription_t
+	pollEvents        []__wasi_event_t
+	pollNEvents       uint32
+)
+
+func sleepTicks(d timeUnit) {
+	pollFDs(d)
+}
+
+// waitForEvents is called by the scheduler when there are no runnable or
+// sleeping goroutines. It waits until one of the file descriptors that
+// goroutines are waiting on becomes ready.
+func waitForEvents() {
+	if fdWaiters == nil {
+		runtimePanic("deadlocked: no event source")
+	}
+	pollFDs(-1)
+}
+
+// pollIO wakes up goroutines waiting for a file descriptor that is ready now,
+// without blocking.
+func pollIO() {
+	if fdWaiters != nil {
+		pollFDs(0)
+	}
+}
+
+// fdWaitData returns the value stored in the Data field of a goroutine waiting
+// for a file descriptor.
+func fdWaitData(fd int32, write bool) uint {
+	data := uint(fd) << 1
+	if write {
+		data |= 1
+	}
+	return data
+}
+
+// fdSubscription returns a poll_oneoff subscription for reading from or writing
+// to the given file descriptor.
+func fdSubscription(userData uint64, fd int32, write bool) __wasi_subscription_t {
+	sub := __wasi_subscription_t{userData: userData}
+	sub.u.tag = __wasi_eventtype_t_fd_read
+	if write {
+		sub.u.tag = __wasi_eventtype_t_fd_write
+	}
+	(*__wasi_subscription_fd_readwrite_t)(unsafe.Pointer(&sub.u.u)).fd = uint32(fd)
+	return sub
+}
+
+// clockSubscription returns a poll_oneoff subscription that expires after the
+// given timeout.
+func clockSubscription(timeout timeUnit) __wasi_subscription_t {
+	return __wasi_subscription_t{
 		u: __wasi_subscription_u_t{
 			tag: __wasi_eventtype_t_clock,
 			u: __wasi_subscription_clock_t{
-				userData:  0,
-				id:        0,
-				timeout:   0,
+				timeout:   int64(timeout),
 				precision: timePrecisionNanoseconds,
-				flags:     0,
 			},
 		},
 	}
-	sleepTicksResult  = __wasi_event_t{}
-	sleepTicksNEvents uint32
+}
+
+// pollFDs calls poll_oneoff with a subscription for every goroutine waiting on
+// a file descriptor, combined with a clock subscription that expires after the
+// given timeout. A negative timeout means there is no clock subscription so
+// that it waits until a file descriptor is ready. Goroutines whose file
+// descriptor is ready are added to the runqueue.
+func pollFDs(timeout timeUnit) {
+	subs := pollSubscriptions[:0]
+	if timeout >= 0 {
+		subs = append(subs, clockSubscription(timeout))
+	}
+	for t := fdWaiters; t != nil; t = t.Next {
+		userData := uint64(uintptr(unsafe.Pointer(t)))
+		subs = append(subs, fdSubscription(userData, int32(t.Data>>1), t.Data&1 != 0))
+	}
+	pollSubscriptions = subs
+	if len(subs) == 0 {
+		return
+	}
+	if len(pollEvents) < len(subs) {
+		pollEvents = make([]__wasi_event_t, len(subs))
+	}
+	if poll_oneoff(&subs[0], &pollEvents[0], uint32(len(subs)), &pollNEvents) != 0 {
+		// The call failed, so the events are not valid. Wake up all waiting
+		// goroutines, so that the following read or write reports the error
+		// instead of waiting forever.
+		for fdWaiters != nil {
+			t := fdWaiters
+			fdWaiters = t.Next
+			t.Next = nil
+			runqueue.Push(t)
+		}
+		return
+	}
+	for _, event := range pollEvents[:pollNEvents] {
+		if event.eventType == __wasi_eventtype_t_clock {
+			continue
+		}
+		// The file descriptor is ready, or there was an error (such as an
+		// invalid file descriptor) that will be reported by the following
+		// read or write. Either way, the goroutine can continue.
+		t := (*task.Task)(unsafe.Pointer(uintptr(event.userData)))
+		for q := &fdWaiters; *q != nil; q = &(*q).Next {
+			if *q == t {
+				*q = t.Next
+				break
+			}
+		}
+		t.Next = nil
+		runqueue.Push(t)
+	}
+}
+
+// Buffers for fdReady, separate from the ones used by the scheduler.
+var (
+	fdReadySubscriptions [2]__wasi_subscription_t
+	fdReadyEvents        [2]__wasi_event_t
+	fdReadyNEvents       uint32
 )
 
-func sleepTicks(d timeUnit) {
-	sleepTicksSubscription.u.u.timeout = int64(d)
-	poll_oneoff(&sleepTicksSubscription, &sleepTicksResult, 1, &sleepTicksNEvents)
+// fdReady returns whether a read from or write to the file descriptor would not
+// block, without blocking itself.
+func fdReady(fd int32, write bool) bool {
+	fdReadySubscriptions[0] = clockSubscription(0)
+	fdReadySubscriptions[1] = fdSubscription(1, fd, write)
+	if poll_oneoff(&fdReadySubscriptions[0], &fdReadyEvents[0], 2, &fdReadyNEvents) != 0 {
+		// Let the read or write report the error.
+		return true
+	}
+	for _, event := range fdReadyEvents[:fdReadyNEvents] {
+		if event.userData == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// fdCanWait returns whether it is useful to wait for the file descriptor to
+// become ready. Regular files and directories are excluded because they are
+// always ready.
+func fdCanWait(fd int32) bool {
+	var stat __wasi_fdstat_t
+	if fd_fdstat_get(fd, &stat) != 0 {
+		// Probably an invalid file descriptor. The read or write will report
+		// the error.
+		return false
+	}
+	switch stat.filetype {
+	case __wasi_filetype_t_directory, __wasi_filetype_t_regular_file:
+		return false
+	}
+	return true
 }
 
 func ticks() timeUnit {
@@ -64,6 +227,26 @@ func ticks() timeUnit {
 //export clock_time_get
 func clock_time_get(clockid uint32, precision uint64, time *int64) (errno uint16)
 
+//go:wasm-module wasi_unstable
+//export args_sizes_get
+func args_sizes_get(argc, argvBufSize *uint32) (errno uint16)
+
+//go:wasm-module wasi_unstable
+//export args_get
+func args_get(argv *unsafe.Pointer, argvBuf *byte) (errno uint16)
+
+//go:wasm-module wasi_unstable
+//export environ_sizes_get
+func environ_sizes_get(environc, environBufSize *uint32) (errno uint16)
+
+//go:wasm-module wasi_unstable
+//export environ_get
+func environ_get(environ *unsafe.Pointer, environBuf *byte) (errno uint16)
+
+//go:wasm-module wasi_unstable
+//export fd_fdstat_get
+func fd_fdstat_get(fd int32, stat *__wasi_fdstat_t) (errno uint16)
+
 //go:wasm-module wasi_unstable
 //export poll_oneoff
 func poll_oneoff(in *__wasi_subscription_t, out *__wasi_event_t, nsubscriptions uint32, nevents *uint32) (errno uint16)
@@ -71,9 +254,9 @@ func poll_oneoff(in *__wasi_subscription_t, out *__wasi_event_t, nsubscriptions
 type __wasi_eventtype_t = uint8
 
 const (
-	__wasi_eventtype_t_clock __wasi_eventtype_t = 0
-	// TODO: __wasi_eventtype_t_fd_read  __wasi_eventtype_t = 1
-	// TODO: __wasi_eventtype_t_fd_write __wasi_eventtype_t = 2
+	__wasi_eventtype_t_clock    __wasi_eventtype_t = 0
+	__wasi_eventtype_t_fd_read  __wasi_eventtype_t = 1
+	__wasi_eventtype_t_fd_write __wasi_eventtype_t = 2
 )
 
 type (
@@ -86,7 +269,8 @@ type (
 	__wasi_subscription_u_t struct {
 		tag __wasi_eventtype_t
 
-		// TODO: support fd_read/fd_write event
+		// This is a union: for fd_read and fd_write subscriptions, it contains
+		// a __wasi_subscription_fd_readwrite_t instead.
 		u __wasi_subscription_clock_t
 	}
 
@@ -98,8 +282,27 @@ type (
 		precision int64
 		flags     uint16
 	}
+
+	__wasi_subscription_fd_readwrite_t struct {
+		fd uint32
+	}
+)
+
+type __wasi_filetype_t = uint8
+
+const (
+	__wasi_filetype_t_directory    __wasi_filetype_t = 3
+	__wasi_filetype_t_regular_file __wasi_filetype_t = 4
 )
 
+// https://github.com/WebAssembly/WASI/blob/main/phases/old/snapshot_0/docs.md#-fdstat-struct
+type __wasi_fdstat_t struct {
+	filetype         __wasi_filetype_t
+	flags            uint16
+	rightsBase       uint64
+	rightsInheriting uint64
+}
+
 type (
 	// https://github.com/wasmerio/wasmer/blob/1.0.0-alpha3/lib/wasi/src/syscalls/types.rs#L191-L198
 	__wasi_event_t struct {
@@ -108,8 +311,7 @@ type (
 		eventType __wasi_eventtype_t
 
 		// only used for fd_read or fd_write events
-		// TODO: support fd_read/fd_write event
-		_ struct {
+		fdReadWrite struct {
 			nBytes uint64
 			flags  uint16
 		}
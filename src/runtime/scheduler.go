@@ -134,6 +134,10 @@ func scheduler() {
 			runqueue.Push(t)
 		}
 
+		// Wake up goroutines waiting for I/O that is ready now, without
+		// blocking. This is a no-op on most platforms.
+		pollIO()
+
 		t := runqueue.Pop()
 		if t == nil {
 			if sleepQueue == nil {
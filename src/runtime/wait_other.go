@@ -1,5 +1,6 @@
 // +build !tinygo.riscv
 // +build !cortexm
+// +build !wasi
 
 package runtime
 
@@ -0,0 +1,12 @@
+// +build wasi,!scheduler.tasks
+
+package runtime
+
+// syscall_runtime_waitFD returns immediately, so that the following read or
+// write blocks the whole program (or fails with EAGAIN for a non-blocking file
+// descriptor). Pausing here would make every read and write a blocking
+// operation for the coroutines scheduler, which isn't allowed in exported
+// functions and when called through an interface such as io.Writer.
+//go:linkname syscall_runtime_waitFD syscall.runtime_waitFD
+func syscall_runtime_waitFD(fd int32, write bool) {
+}
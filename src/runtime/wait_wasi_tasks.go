@@ -0,0 +1,23 @@
+// +build wasi,scheduler.tasks
+
+package runtime
+
+import "internal/task"
+
+// syscall_runtime_waitFD parks the current goroutine until the file descriptor
+// is ready for reading or writing, so that other goroutines can run in the
+// meantime. This is only possible with the tasks scheduler, which can pause a
+// goroutine anywhere. Outside of a goroutine (for example in an exported
+// function called by the host) it returns immediately, so that the following
+// read or write blocks.
+//go:linkname syscall_runtime_waitFD syscall.runtime_waitFD
+func syscall_runtime_waitFD(fd int32, write bool) {
+	if task.OnSystemStack() || !fdCanWait(fd) || fdReady(fd, write) {
+		return
+	}
+	t := task.Current()
+	t.Data = fdWaitData(fd, write)
+	t.Next = fdWaiters
+	fdWaiters = t
+	task.Pause()
+}
@@ -0,0 +1,21 @@
+package syscall
+
+// The environment variables of the program, in "key=value" form. They are
+// provided by the runtime on systems that have them.
+func runtime_envs() []string // in package runtime
+
+// Getenv retrieves the value of the environment variable named by the key.
+func Getenv(key string) (value string, found bool) {
+	for _, s := range runtime_envs() {
+		if len(s) > len(key) && s[len(key)] == '=' && s[:len(key)] == key {
+			return s[len(key)+1:], true
+		}
+	}
+	return "", false
+}
+
+// Environ returns a copy of the environment variables, in "key=value" form.
+func Environ() []string {
+	env := runtime_envs()
+	return append(make([]string, 0, len(env)), env...)
+}
@@ -1,4 +1,4 @@
-// +build baremetal
+// +build baremetal wasm_unknown
 
 package syscall
 
@@ -47,10 +47,6 @@ const (
 	O_CLOEXEC = 0
 )
 
-func Getenv(key string) (value string, found bool) {
-	return "", false // stub
-}
-
 func Open(path string, mode int, perm uint32) (fd int, err error) {
 	return 0, ENOSYS
 }
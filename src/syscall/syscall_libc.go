@@ -12,11 +12,8 @@ type sliceHeader struct {
 	cap uintptr
 }
 
-func Close(fd int) (err error) {
-	return ENOSYS // TODO
-}
-
 func Write(fd int, p []byte) (n int, err error) {
+	waitFD(fd, true)
 	buf, count := splitSlice(p)
 	n = libc_write(int32(fd), buf, uint(count))
 	if n < 0 {
@@ -25,26 +22,6 @@ func Write(fd int, p []byte) (n int, err error) {
 	return
 }
 
-func Read(fd int, p []byte) (n int, err error) {
-	return 0, ENOSYS // TODO
-}
-
-func Seek(fd int, offset int64, whence int) (off int64, err error) {
-	return 0, ENOSYS // TODO
-}
-
-func Open(path string, mode int, perm uint32) (fd int, err error) {
-	return 0, ENOSYS // TODO
-}
-
-func Mkdir(path string, mode uint32) (err error) {
-	return ENOSYS // TODO
-}
-
-func Unlink(path string) (err error) {
-	return ENOSYS // TODO
-}
-
 func Kill(pid int, sig Signal) (err error) {
 	return ENOSYS // TODO
 }
@@ -53,24 +30,6 @@ func Getpid() (pid int) {
 	panic("unimplemented: getpid") // TODO
 }
 
-func Getenv(key string) (value string, found bool) {
-	data := append([]byte(key), 0)
-	raw := libc_getenv(&data[0])
-	if raw == nil {
-		return "", false
-	}
-
-	ptr := uintptr(unsafe.Pointer(raw))
-	for size := uintptr(0); ; size++ {
-		v := *(*byte)(unsafe.Pointer(ptr))
-		if v == 0 {
-			src := *(*[]byte)(unsafe.Pointer(&sliceHeader{buf: raw, len: size, cap: size}))
-			return string(src), true
-		}
-		ptr += unsafe.Sizeof(byte(0))
-	}
-}
-
 func splitSlice(p []byte) (buf *byte, len uintptr) {
 	slice := (*sliceHeader)(unsafe.Pointer(&p))
 	return slice.buf, slice.len
@@ -79,7 +38,3 @@ func splitSlice(p []byte) (buf *byte, len uintptr) {
 // ssize_t write(int fd, const void *buf, size_t count)
 //export write
 func libc_write(fd int32, buf *byte, count uint) int
-
-// char *getenv(const char *name);
-//export getenv
-func libc_getenv(name *byte) *byte
@@ -1,5 +1,9 @@
 package syscall
 
+import (
+	"unsafe"
+)
+
 // This file defines errno and constants to match the darwin libsystem ABI.
 // Values have been copied from src/syscall/zerrors_darwin_amd64.go.
 
@@ -54,3 +58,85 @@ const (
 	O_TRUNC  = 0x400
 	O_EXCL   = 0x800
 )
+
+const (
+	S_IFMT   = 0xf000
+	S_IFBLK  = 0x6000
+	S_IFCHR  = 0x2000
+	S_IFDIR  = 0x4000
+	S_IFIFO  = 0x1000
+	S_IFLNK  = 0xa000
+	S_IFREG  = 0x8000
+	S_IFSOCK = 0xc000
+)
+
+// pathMax is PATH_MAX on macOS.
+const pathMax = 1024
+
+// direntNameOffset is the offset of d_name in the dirent structure of macOS
+// (with 64-bit inodes):
+//
+//     struct dirent {
+//         __uint64_t d_ino;
+//         __uint64_t d_seekoff;
+//         __uint16_t d_reclen;
+//         __uint16_t d_namlen;
+//         __uint8_t  d_type;
+//         char       d_name[1024];
+//     };
+const direntNameOffset = 21
+
+// Timespec and Stat_t have been copied from
+// src/syscall/ztypes_darwin_amd64.go.
+
+type Timespec struct {
+	Sec  int64
+	Nsec int64
+}
+
+type Stat_t struct {
+	Dev           int32
+	Mode          uint16
+	Nlink         uint16
+	Ino           uint64
+	Uid           uint32
+	Gid           uint32
+	Rdev          int32
+	Pad_cgo_0     [4]byte
+	Atimespec     Timespec
+	Mtimespec     Timespec
+	Ctimespec     Timespec
+	Birthtimespec Timespec
+	Size          int64
+	Blocks        int64
+	Blksize       int32
+	Flags         uint32
+	Gen           uint32
+	Lspare        int32
+	Qspare        [2]int64
+}
+
+// waitFD waits until the file descriptor is ready for reading or writing. Reads
+// and writes simply block on this system.
+func waitFD(fd int, write bool) {
+}
+
+// int stat(const char *path, struct stat *buf);
+//export stat$INODE64
+func libc_stat(path *byte, st *Stat_t) int32
+
+// int lstat(const char *path, struct stat *buf);
+//export lstat$INODE64
+func libc_lstat(path *byte, st *Stat_t) int32
+
+// int fstat(int fd, struct stat *buf);
+//export fstat$INODE64
+func libc_fstat(fd int32, st *Stat_t) int32
+
+// DIR *opendir(const char *name);
+//export opendir$INODE64
+func libc_opendir(name *byte) unsafe.Pointer
+
+// struct dirent *readdir(DIR *dirp);
+//export readdir$INODE64
+func libc_readdir(dir unsafe.Pointer) unsafe.Pointer
@@ -0,0 +1,204 @@
+// +build darwin wasi
+
+package syscall
+
+// This file implements file and directory operations on top of the libc of the
+// target (wasi-libc on WebAssembly, libSystem on macOS). The structures that
+// differ between systems, such as Stat_t, are defined in the OS specific files.
+
+import (
+	"unsafe"
+)
+
+func Read(fd int, p []byte) (n int, err error) {
+	waitFD(fd, false)
+	buf, count := splitSlice(p)
+	n = libc_read(int32(fd), buf, uint(count))
+	if n < 0 {
+		err = getErrno()
+	}
+	return
+}
+
+func Close(fd int) (err error) {
+	return errorForResult(libc_close(int32(fd)))
+}
+
+func Seek(fd int, offset int64, whence int) (off int64, err error) {
+	off = libc_lseek(int32(fd), offset, int32(whence))
+	if off < 0 {
+		err = getErrno()
+	}
+	return
+}
+
+func Open(path string, mode int, perm uint32) (fd int, err error) {
+	fd = int(libc_open(cstring(path), int32(mode), perm))
+	if fd < 0 {
+		err = getErrno()
+	}
+	return
+}
+
+func Mkdir(path string, mode uint32) (err error) {
+	return errorForResult(libc_mkdir(cstring(path), mode))
+}
+
+func Unlink(path string) (err error) {
+	return errorForResult(libc_unlink(cstring(path)))
+}
+
+func Rmdir(path string) (err error) {
+	return errorForResult(libc_rmdir(cstring(path)))
+}
+
+func Rename(from, to string) (err error) {
+	return errorForResult(libc_rename(cstring(from), cstring(to)))
+}
+
+func Truncate(path string, length int64) (err error) {
+	return errorForResult(libc_truncate(cstring(path), length))
+}
+
+func Chdir(path string) (err error) {
+	return errorForResult(libc_chdir(cstring(path)))
+}
+
+func Getwd() (wd string, err error) {
+	var buf [pathMax]byte
+	if libc_getcwd(&buf[0], uint(len(buf))) == nil {
+		return "", getErrno()
+	}
+	return gostring(&buf[0]), nil
+}
+
+func Symlink(oldpath, newpath string) (err error) {
+	return errorForResult(libc_symlink(cstring(oldpath), cstring(newpath)))
+}
+
+func Readlink(path string, buf []byte) (n int, err error) {
+	if len(buf) == 0 {
+		return 0, nil
+	}
+	n = libc_readlink(cstring(path), &buf[0], uint(len(buf)))
+	if n < 0 {
+		err = getErrno()
+	}
+	return
+}
+
+func Stat(path string, st *Stat_t) (err error) {
+	return errorForResult(libc_stat(cstring(path), st))
+}
+
+func Lstat(path string, st *Stat_t) (err error) {
+	return errorForResult(libc_lstat(cstring(path), st))
+}
+
+func Fstat(fd int, st *Stat_t) (err error) {
+	return errorForResult(libc_fstat(int32(fd), st))
+}
+
+// readDirNames returns the names of all entries in the given directory except
+// for "." and "..", in directory order. It is used by the os package, which
+// can't use the dirent structure directly as it differs between systems.
+func readDirNames(path string) (names []string, err error) {
+	dir := libc_opendir(cstring(path))
+	if dir == nil {
+		return nil, getErrno()
+	}
+	for {
+		entry := libc_readdir(dir)
+		if entry == nil {
+			break
+		}
+		name := gostring((*byte)(unsafe.Pointer(uintptr(entry) + direntNameOffset)))
+		if name == "." || name == ".." {
+			continue
+		}
+		names = append(names, name)
+	}
+	libc_closedir(dir)
+	return names, nil
+}
+
+// cstring returns a pointer to a NUL-terminated copy of the given string.
+func cstring(s string) *byte {
+	data := append([]byte(s), 0)
+	return &data[0]
+}
+
+// gostring returns a copy of the given NUL-terminated C string.
+func gostring(s *byte) string {
+	size := uintptr(0)
+	for *(*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(s)) + size)) != 0 {
+		size++
+	}
+	buf := *(*[]byte)(unsafe.Pointer(&sliceHeader{buf: s, len: size, cap: size}))
+	return string(buf)
+}
+
+// errorForResult returns the C errno if the result of a libc call indicates an
+// error (by being negative), and nil otherwise.
+func errorForResult(result int32) error {
+	if result < 0 {
+		return getErrno()
+	}
+	return nil
+}
+
+// ssize_t read(int fd, void *buf, size_t count);
+//export read
+func libc_read(fd int32, buf *byte, count uint) int
+
+// int close(int fd);
+//export close
+func libc_close(fd int32) int32
+
+// off_t lseek(int fd, off_t offset, int whence);
+//export lseek
+func libc_lseek(fd int32, offset int64, whence int32) int64
+
+// int open(const char *pathname, int flags, mode_t mode);
+//export open
+func libc_open(pathname *byte, flags int32, mode uint32) int32
+
+// int mkdir(const char *pathname, mode_t mode);
+//export mkdir
+func libc_mkdir(pathname *byte, mode uint32) int32
+
+// int unlink(const char *pathname);
+//export unlink
+func libc_unlink(pathname *byte) int32
+
+// int rmdir(const char *pathname);
+//export rmdir
+func libc_rmdir(pathname *byte) int32
+
+// int rename(const char *from, const char *to);
+//export rename
+func libc_rename(from, to *byte) int32
+
+// int truncate(const char *path, off_t length);
+//export truncate
+func libc_truncate(path *byte, length int64) int32
+
+// int chdir(const char *path);
+//export chdir
+func libc_chdir(path *byte) int32
+
+// char *getcwd(char *buf, size_t size);
+//export getcwd
+func libc_getcwd(buf *byte, size uint) *byte
+
+// int symlink(const char *target, const char *linkpath);
+//export symlink
+func libc_symlink(target, linkpath *byte) int32
+
+// ssize_t readlink(const char *pathname, char *buf, size_t bufsiz);
+//export readlink
+func libc_readlink(pathname *byte, buf *byte, count uint) int
+
+// int closedir(DIR *dirp);
+//export closedir
+func libc_closedir(dir unsafe.Pointer) int32
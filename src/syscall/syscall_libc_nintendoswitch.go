@@ -46,3 +46,114 @@ var dummyError = errors.New("unknown syscall error")
 func getErrno() error {
 	return dummyError
 }
+
+const (
+	S_IFMT   = 0xf000
+	S_IFBLK  = 0x6000
+	S_IFCHR  = 0x2000
+	S_IFDIR  = 0x4000
+	S_IFIFO  = 0x1000
+	S_IFLNK  = 0xa000
+	S_IFREG  = 0x8000
+	S_IFSOCK = 0xc000
+)
+
+type Timespec struct {
+	Sec  int64
+	Nsec int64
+}
+
+// Stat_t has been copied from src/syscall/ztypes_linux_arm64.go. There is no
+// filesystem support, but it is used by the os package.
+type Stat_t struct {
+	Dev               uint64
+	Ino               uint64
+	Mode              uint32
+	Nlink             uint32
+	Uid               uint32
+	Gid               uint32
+	Rdev              uint64
+	X__pad1           uint64
+	Size              int64
+	Blksize           int32
+	X__pad2           int32
+	Blocks            int64
+	Atim              Timespec
+	Mtim              Timespec
+	Ctim              Timespec
+	X__glibc_reserved [2]int32
+}
+
+func Read(fd int, p []byte) (n int, err error) {
+	return 0, ENOSYS // TODO
+}
+
+func Close(fd int) (err error) {
+	return ENOSYS // TODO
+}
+
+func Seek(fd int, offset int64, whence int) (off int64, err error) {
+	return 0, ENOSYS // TODO
+}
+
+func Open(path string, mode int, perm uint32) (fd int, err error) {
+	return 0, ENOSYS // TODO
+}
+
+func Mkdir(path string, mode uint32) (err error) {
+	return ENOSYS // TODO
+}
+
+func Unlink(path string) (err error) {
+	return ENOSYS // TODO
+}
+
+func Rmdir(path string) (err error) {
+	return ENOSYS // TODO
+}
+
+func Rename(from, to string) (err error) {
+	return ENOSYS // TODO
+}
+
+func Truncate(path string, length int64) (err error) {
+	return ENOSYS // TODO
+}
+
+func Chdir(path string) (err error) {
+	return ENOSYS // TODO
+}
+
+func Getwd() (wd string, err error) {
+	return "", ENOSYS // TODO
+}
+
+func Symlink(oldpath, newpath string) (err error) {
+	return ENOSYS // TODO
+}
+
+func Readlink(path string, buf []byte) (n int, err error) {
+	return 0, ENOSYS // TODO
+}
+
+func Stat(path string, st *Stat_t) (err error) {
+	return ENOSYS // TODO
+}
+
+func Lstat(path string, st *Stat_t) (err error) {
+	return ENOSYS // TODO
+}
+
+func Fstat(fd int, st *Stat_t) (err error) {
+	return ENOSYS // TODO
+}
+
+// readDirNames is used by the os package to read directories.
+func readDirNames(path string) (names []string, err error) {
+	return nil, ENOSYS // TODO
+}
+
+// waitFD waits until the file descriptor is ready for reading or writing. Reads
+// and writes simply block on this system.
+func waitFD(fd int, write bool) {
+}
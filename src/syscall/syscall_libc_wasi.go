@@ -2,6 +2,10 @@
 
 package syscall
 
+import (
+	"unsafe"
+)
+
 // https://github.com/WebAssembly/wasi-libc/blob/main/expected/wasm32-wasi/predefined-macros.txt
 
 type Signal int
@@ -42,9 +46,174 @@ const (
 	O_CLOEXEC = 0
 )
 
+const (
+	S_IFMT   = 0xf000
+	S_IFBLK  = 0x6000
+	S_IFCHR  = 0x2000
+	S_IFDIR  = 0x4000
+	S_IFIFO  = 0x1000 // not used: WASI has no named pipes
+	S_IFLNK  = 0xa000
+	S_IFREG  = 0x8000
+	S_IFSOCK = 0xc000
+)
+
+// pathMax is PATH_MAX in wasi-libc.
+const pathMax = 4096
+
+// direntNameOffset is the offset of d_name in the dirent structure of
+// wasi-libc:
+//
+//     struct dirent {
+//         ino_t d_ino;
+//         unsigned char d_type;
+//         char d_name[];
+//     };
+const direntNameOffset = 9
+
+// Timespec matches struct timespec in wasi-libc, where long (the type of
+// tv_nsec) is 32 bits wide.
+type Timespec struct {
+	Sec       int64
+	Nsec      int32
+	Pad_cgo_0 [4]byte
+}
+
+// Stat_t matches struct stat in wasi-libc.
+type Stat_t struct {
+	Dev       uint64
+	Ino       uint64
+	Nlink     uint64
+	Mode      uint32
+	Uid       uint32
+	Gid       uint32
+	Pad_cgo_0 [4]byte
+	Rdev      uint64
+	Size      int64
+	Blksize   int32
+	Pad_cgo_1 [4]byte
+	Blocks    int64
+	Atim      Timespec
+	Mtim      Timespec
+	Ctim      Timespec
+	Qspare    [3]int64
+}
+
 //go:extern errno
 var libcErrno uintptr
 
+// getErrno returns the current C errno, translated to the errno values of the
+// syscall package (which are the Linux values). wasi-libc uses the WASI errno
+// values, which are different.
 func getErrno() error {
-	return Errno(libcErrno)
+	if libcErrno < uintptr(len(wasiErrnos)) && wasiErrnos[libcErrno] != 0 {
+		return wasiErrnos[libcErrno]
+	}
+	return EIO
 }
+
+// wasiErrnos maps WASI errno values to the errno values of this package.
+var wasiErrnos = [...]Errno{
+	1:  E2BIG,
+	2:  EACCES,
+	3:  EADDRINUSE,
+	4:  EADDRNOTAVAIL,
+	5:  EAFNOSUPPORT,
+	6:  EAGAIN,
+	7:  EALREADY,
+	8:  EBADF,
+	9:  EBADMSG,
+	10: EBUSY,
+	11: ECANCELED,
+	12: ECHILD,
+	13: ECONNABORTED,
+	14: ECONNREFUSED,
+	15: ECONNRESET,
+	16: EDEADLK,
+	17: EDESTADDRREQ,
+	18: EDOM,
+	19: EDQUOT,
+	20: EEXIST,
+	21: EFAULT,
+	22: EFBIG,
+	23: EHOSTUNREACH,
+	24: EIDRM,
+	25: EILSEQ,
+	26: EINPROGRESS,
+	27: EINTR,
+	28: EINVAL,
+	29: EIO,
+	30: EISCONN,
+	31: EISDIR,
+	32: ELOOP,
+	33: EMFILE,
+	34: EMLINK,
+	35: EMSGSIZE,
+	36: EMULTIHOP,
+	37: ENAMETOOLONG,
+	38: ENETDOWN,
+	39: ENETRESET,
+	40: ENETUNREACH,
+	41: ENFILE,
+	42: ENOBUFS,
+	43: ENODEV,
+	44: ENOENT,
+	45: ENOEXEC,
+	46: ENOLCK,
+	47: ENOLINK,
+	48: ENOMEM,
+	49: ENOMSG,
+	50: ENOPROTOOPT,
+	51: ENOSPC,
+	52: ENOSYS,
+	53: ENOTCONN,
+	54: ENOTDIR,
+	55: ENOTEMPTY,
+	57: ENOTSOCK,
+	58: ENOTSUP,
+	59: ENOTTY,
+	60: ENXIO,
+	61: EOVERFLOW,
+	63: EPERM,
+	64: EPIPE,
+	65: EPROTO,
+	66: EPROTONOSUPPORT,
+	67: EPROTOTYPE,
+	68: ERANGE,
+	69: EROFS,
+	70: ESPIPE,
+	71: ESRCH,
+	72: ESTALE,
+	73: ETIMEDOUT,
+	74: EBUSY, // ETXTBSY
+	75: EXDEV,
+	76: EACCES, // ENOTCAPABLE: the preopened directory doesn't allow this
+}
+
+// waitFD waits until the file descriptor is ready for reading or writing. Other
+// goroutines keep running in the meantime.
+func waitFD(fd int, write bool) {
+	runtime_waitFD(int32(fd), write)
+}
+
+// runtime_waitFD is implemented in the runtime using poll_oneoff.
+func runtime_waitFD(fd int32, write bool)
+
+// int stat(const char *path, struct stat *buf);
+//export stat
+func libc_stat(path *byte, st *Stat_t) int32
+
+// int lstat(const char *path, struct stat *buf);
+//export lstat
+func libc_lstat(path *byte, st *Stat_t) int32
+
+// int fstat(int fd, struct stat *buf);
+//export fstat
+func libc_fstat(fd int32, st *Stat_t) int32
+
+// DIR *opendir(const char *name);
+//export opendir
+func libc_opendir(name *byte) unsafe.Pointer
+
+// struct dirent *readdir(DIR *dirp);
+//export readdir
+func libc_readdir(dir unsafe.Pointer) unsafe.Pointer
@@ -0,0 +1,10 @@
+// Default implementations of optional hooks of the generic machine package,
+// used on the host and on WebAssembly. They are weak, so a simulator (such as
+// the machine/simulator package) or the host can replace them.
+
+// Called before each I2C transaction with the address of the device, followed
+// by a call to __tinygo_i2c_transfer. The address is ignored by default, for
+// hosts that only implement __tinygo_i2c_transfer.
+__attribute__((weak, visibility("hidden")))
+void __tinygo_i2c_set_address(unsigned char bus, unsigned short addr) {
+}
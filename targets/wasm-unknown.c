@@ -0,0 +1,53 @@
+// Default implementations of the hooks used by the runtime on the wasm-unknown
+// target. They are weak, so a program can replace them by exporting a function
+// with the same name, for example:
+//
+//     //export tinygo_putchar
+//     func putchar(c byte) { ... }
+//
+// This way the module doesn't import anything from the host unless the program
+// asks for it.
+
+typedef long long int64_t;
+
+// Write a single byte of output (from println and panics). It is discarded by
+// default.
+__attribute__((weak, visibility("hidden")))
+void tinygo_putchar(unsigned char c) {
+}
+
+// The current time in nanoseconds, and sleeping for the given number of
+// nanoseconds. By default there is no clock: time only advances while
+// sleeping, so that time.Sleep returns immediately but the program still sees
+// the time pass.
+static int64_t tinygo_time;
+
+__attribute__((weak, visibility("hidden")))
+int64_t tinygo_ticks(void) {
+	return tinygo_time;
+}
+
+__attribute__((weak, visibility("hidden")))
+void tinygo_sleep(int64_t ns) {
+	tinygo_time += ns;
+}
+
+void tinygo_abort(void);
+
+// Called by os.Exit with the exit code. A host that needs the exit code should
+// replace it with a function that doesn't return, for example one that stops
+// the module. By default, a nonzero code is a fatal error and 0 returns, after
+// which the runtime traps.
+__attribute__((weak, visibility("hidden")))
+void tinygo_exit(int code) {
+	if (code != 0) {
+		tinygo_abort();
+	}
+}
+
+// Called on a fatal error, such as a panic. It must not return. By default it
+// executes the unreachable instruction, which traps.
+__attribute__((weak, visibility("hidden")))
+void tinygo_abort(void) {
+	__builtin_trap();
+}
@@ -508,6 +508,7 @@
 
 	if (
 		global.require &&
+		typeof module !== "undefined" && // not defined in ES modules
 		global.require.main === module &&
 		global.process &&
 		global.process.versions &&
@@ -0,0 +1,17 @@
+#include "_cgo_export.h"
+
+// Call a Go function (exported with //export) from C.
+int squareViaGo(int x) {
+	return mul(x, x);
+}
+
+// Pass a string to Go, as a pointer and length.
+int stringViaGo(void) {
+	return goStringLength("hello from C", 12);
+}
+
+// Pass a slice to Go, as a pointer, length and capacity.
+int sumViaGo(void) {
+	int values[4] = {3, 5, 7, 100};
+	return goSum(values, 3, 4);
+}
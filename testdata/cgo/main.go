@@ -5,6 +5,7 @@ int fortytwo(void);
 #include "main.h"
 int mul(int, int);
 #include <string.h>
+#include <stdlib.h>
 */
 import "C"
 
@@ -25,6 +26,7 @@ func main() {
 	println("defined floats:", C.CONST_FLOAT, C.CONST_FLOAT2)
 	println("defined string:", C.CONST_STRING)
 	println("defined char:", C.CONST_CHAR)
+	println("defined expressions:", C.CONST_CAST, C.CONST_DERIVED, C.CONST_BIT(4))
 	var ptr C.intPointer
 	var n C.int = 15
 	ptr = C.intPointer(&n)
@@ -35,6 +37,9 @@ func main() {
 	println("callback 1:", C.doCallback(20, 30, cb))
 	cb = C.binop_t(C.mul)
 	println("callback 2:", C.doCallback(20, 30, cb))
+	println("export:", C.squareViaGo(7))
+	println("export string:", C.stringViaGo())
+	println("export slice:", C.sumViaGo())
 
 	// variadic functions
 	println("variadic0:", C.variadic0())
@@ -123,6 +128,17 @@ func main() {
 	buf2 := make([]byte, len(buf1))
 	C.strcpy((*C.char)(unsafe.Pointer(&buf2[0])), (*C.char)(unsafe.Pointer(&buf1[0])))
 	println("copied string:", string(buf2[:C.strlen((*C.char)(unsafe.Pointer(&buf2[0])))]))
+
+	// CGo helper functions.
+	cstr := C.CString("hello")
+	println("CString:", C.GoString(cstr), C.strlen(cstr))
+	println("GoStringN:", C.GoStringN(cstr, 3))
+	println("GoString(nil):", C.GoString(nil) == "")
+	cbuf := C.CBytes([]byte{1, 2, 3})
+	goBytes := C.GoBytes(cbuf, 3)
+	println("GoBytes:", len(goBytes), goBytes[0], goBytes[2])
+	C.free(unsafe.Pointer(cstr))
+	C.free(cbuf)
 }
 
 func printUnion(union C.joined_t) C.joined_t {
@@ -140,6 +156,22 @@ func mul(a, b C.int) C.int {
 	return a * b
 }
 
+//export goStringLength
+func goStringLength(s string) C.int {
+	println("string from C:", s)
+	return C.int(len(s))
+}
+
+//export goSum
+func goSum(values []C.int) C.int {
+	println("slice from C:", len(values), cap(values))
+	var sum C.int
+	for _, v := range values {
+		sum += v
+	}
+	return sum
+}
+
 func printBitfield(bitfield *C.bitfield_t) {
 	println("bitfield a:", bitfield.bitfield_a())
 	println("bitfield b:", bitfield.bitfield_b())
@@ -7,6 +7,9 @@ int add(int a, int b);
 int unusedFunction(void);
 typedef int (*binop_t) (int, int);
 int doCallback(int a, int b, binop_t cb);
+int squareViaGo(int x);
+int stringViaGo(void);
+int sumViaGo(void);
 typedef int * intPointer;
 void store(int value, int *ptr);
 
@@ -19,6 +22,9 @@ int variadic2(int x, int y, ...);
 # define CONST_FLOAT2 5.8f
 # define CONST_CHAR 'c'
 # define CONST_STRING "defined string"
+# define CONST_CAST ((uint32_t)0x40000000)
+# define CONST_DERIVED (CONST_INT * 2 + 1)
+# define CONST_BIT(n) (1u << (n))
 
 // this signature should not be included by CGo
 void unusedFunction2(int x, __builtin_va_list args);
@@ -0,0 +1,142 @@
+package main
+
+// Test filesystem operations of the os package: Stat, ReadDir, Readdir, Rename,
+// Truncate, Chdir and symbolic links. The test is run in a temporary
+// directory, which is also the preopened directory on WASI.
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const dir = "fstest"
+
+func main() {
+	check(os.Mkdir(dir, 0777))
+	writeFile(dir+"/a.txt", "hello")
+	writeFile(dir+"/b.txt", "hello world")
+	check(os.Mkdir(dir+"/sub", 0777))
+
+	// Stat and Lstat.
+	info, err := os.Stat(dir + "/b.txt")
+	check(err)
+	fmt.Println("stat:", info.Name(), info.Size(), info.IsDir(), info.Mode().IsRegular())
+	info, err = os.Stat(dir + "/sub")
+	check(err)
+	fmt.Println("stat:", info.Name(), info.IsDir())
+	_, err = os.Stat(dir + "/missing")
+	fmt.Println("stat missing:", os.IsNotExist(err))
+
+	// Rename a file while it is open. File.Stat describes the open file, so it
+	// still works after the rename.
+	f, err := os.Open(dir + "/a.txt")
+	check(err)
+	check(os.Rename(dir+"/a.txt", dir+"/c.txt"))
+	info, err = f.Stat()
+	check(err)
+	fmt.Println("file stat:", info.Name(), info.Size())
+	check(f.Close())
+	_, err = os.Stat(dir + "/a.txt")
+	fmt.Println("old name exists:", !os.IsNotExist(err))
+	info, err = os.Stat(dir + "/c.txt")
+	check(err)
+	fmt.Println("new name:", info.Name(), info.Size())
+
+	// Symbolic links.
+	check(os.Symlink("b.txt", dir+"/link"))
+	target, err := os.Readlink(dir + "/link")
+	check(err)
+	fmt.Println("readlink:", target)
+	info, err = os.Lstat(dir + "/link")
+	check(err)
+	fmt.Println("lstat:", info.Name(), info.Mode()&os.ModeSymlink != 0)
+	info, err = os.Stat(dir + "/link")
+	check(err)
+	fmt.Println("stat link:", info.Name(), info.Size(), info.Mode()&os.ModeSymlink != 0)
+
+	// Directory listing, sorted by name.
+	entries, err := os.ReadDir(dir)
+	check(err)
+	for _, entry := range entries {
+		fmt.Println("entry:", entry.Name(), entry.IsDir(), entry.Type()&os.ModeSymlink != 0)
+	}
+
+	// Reading a directory in parts, in directory order (which is not
+	// necessarily sorted).
+	d, err := os.Open(dir)
+	check(err)
+	names, err := d.Readdirnames(2)
+	check(err)
+	first := len(names)
+	infos, err := d.Readdir(-1)
+	check(err)
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	sort.Strings(names)
+	fmt.Println("readdirnames:", first, names)
+	_, err = d.Readdir(1)
+	fmt.Println("readdir at end:", err == io.EOF)
+	check(d.Close())
+
+	// Truncate a file, making it both smaller and larger.
+	check(os.Truncate(dir+"/c.txt", 2))
+	fmt.Printf("truncate: %q\n", readFile(dir+"/c.txt"))
+	check(os.Truncate(dir+"/c.txt", 4))
+	fmt.Printf("extend: %q\n", readFile(dir+"/c.txt"))
+
+	// Change the working directory. Relative paths are resolved against the
+	// new working directory.
+	wd, err := os.Getwd()
+	check(err)
+	check(os.Chdir(dir))
+	subwd, err := os.Getwd()
+	check(err)
+	fmt.Println("getwd:", subwd == strings.TrimSuffix(wd, "/")+"/"+dir)
+	info, err = os.Stat("b.txt")
+	check(err)
+	fmt.Println("stat after chdir:", info.Name(), info.Size())
+	check(os.Chdir(".."))
+	newwd, err := os.Getwd()
+	check(err)
+	fmt.Println("chdir back:", newwd == wd)
+	err = os.Chdir("missing")
+	fmt.Println("chdir missing:", os.IsNotExist(err))
+
+	// Clean up.
+	for _, name := range []string{"b.txt", "c.txt", "link", "sub"} {
+		check(os.Remove(dir + "/" + name))
+	}
+	check(os.Remove(dir))
+	fmt.Println("done")
+}
+
+func writeFile(name, data string) {
+	f, err := os.Create(name)
+	check(err)
+	_, err = f.Write([]byte(data))
+	check(err)
+	check(f.Close())
+}
+
+func readFile(name string) string {
+	f, err := os.Open(name)
+	check(err)
+	buf := make([]byte, 16)
+	n, err := f.Read(buf)
+	if err != io.EOF {
+		check(err)
+	}
+	check(f.Close())
+	return string(buf[:n])
+}
+
+func check(err error) {
+	if err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"runtime/debug"
+
+	"github.com/google/shlex"
+)
+
+// This global is set using -ldflags="-X main.someGlobal=value".
+var someGlobal string
+
+// This global has an initializer, which is ignored when it is set with -X.
+var otherGlobal = "original"
+
+// This global is not set with -X.
+var unchangedGlobal = "unchanged"
+
+// This global is set with a quoted -X flag, so its value contains a space.
+var quotedGlobal string
+
+func main() {
+	println("someGlobal:", someGlobal)
+	println("otherGlobal:", otherGlobal)
+	println("unchangedGlobal:", unchangedGlobal)
+	println("quotedGlobal:", quotedGlobal)
+
+	// Read back the build information, which is set by the compiler in the
+	// same way as -X flags. The shlex package is only imported to have a
+	// dependency with a version.
+	words, _ := shlex.Split("a 'b c'")
+	println("words:", len(words))
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		println("no build info")
+		return
+	}
+	println("path:", info.Path)
+	println("main:", info.Main.Path, info.Main.Version)
+	for _, dep := range info.Deps {
+		println("dep:", dep.Path, dep.Version)
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "-compiler" {
+			println("compiler:", setting.Value)
+		}
+	}
+}
@@ -11,4 +11,18 @@ func main() {
 		panic("ENV2 not found")
 	}
 	println(v)
+
+	found := false
+	for _, kv := range os.Environ() {
+		if kv == "ENV1=VALUE1" {
+			found = true
+		}
+	}
+	println("ENV1 in os.Environ():", found)
+
+	// The first argument is the program name, which differs between systems.
+	println("number of args:", len(os.Args))
+	for _, arg := range os.Args[1:] {
+		println(arg)
+	}
 }
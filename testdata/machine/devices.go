@@ -0,0 +1,198 @@
+package main
+
+// Test the peripheral models of the hardware simulator, both on the buses of
+// the machine package and on buses that are passed to a driver directly.
+
+import (
+	"machine"
+	"machine/simulator"
+)
+
+// i2cBus is the interface used by most I2C drivers.
+type i2cBus interface {
+	Tx(addr uint16, w, r []byte) error
+	ReadRegister(addr uint8, r uint8, buf []byte) error
+	WriteRegister(addr uint8, r uint8, buf []byte) error
+}
+
+// spiBus is the interface used by most SPI drivers.
+type spiBus interface {
+	Tx(w, r []byte) error
+	Transfer(b byte) (byte, error)
+}
+
+func main() {
+	testRegisterFile(machine.I2C0, simulator.AttachI2C)
+	bus := simulator.NewI2CBus()
+	testRegisterFile(bus, func(_ uint8, addr uint16, dev simulator.I2CDevice) {
+		bus.Attach(addr, dev)
+	})
+	testRegisterFileSPI()
+	testFlash()
+	testSSD1306I2C()
+	testSSD1306SPI()
+	testChipSelect()
+}
+
+func testRegisterFile(bus i2cBus, attach func(uint8, uint16, simulator.I2CDevice)) {
+	sensor := simulator.NewRegisterFile()
+	sensor.Set(0x0f, 0x33) // ID register
+	sensor.SetReadOnly(0x0f)
+	sensor.OnWrite = func(reg, value byte) {
+		if reg == 0x20 && value&1 != 0 {
+			// Start a measurement.
+			sensor.Set(0x28, 0x34, 0x12)
+		}
+	}
+	attach(0, 0x19, sensor)
+
+	id := make([]byte, 1)
+	bus.ReadRegister(0x19, 0x0f, id)
+	bus.WriteRegister(0x19, 0x0f, []byte{0})
+	println("id:", id[0], sensor.Get(0x0f))
+
+	bus.WriteRegister(0x19, 0x20, []byte{0x01, 0x02})
+	println("config:", sensor.Get(0x20), sensor.Get(0x21))
+	data := make([]byte, 2)
+	err := bus.ReadRegister(0x19, 0x28, data)
+	println("measurement:", uint16(data[0])|uint16(data[1])<<8, err == nil)
+
+	err = bus.ReadRegister(0x18, 0x0f, id)
+	println("wrong address:", err != nil)
+}
+
+func testRegisterFileSPI() {
+	const cs = machine.Pin(20)
+	sensor := simulator.NewRegisterFile()
+	sensor.Set(0x75, 0x71)
+	spi := simulator.NewSPIBus()
+	spi.Attach(cs, sensor)
+	var bus spiBus = spi
+	cs.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	cs.High()
+
+	r := make([]byte, 2)
+	cs.Low()
+	bus.Tx([]byte{0x75 | 0x80, 0}, r)
+	cs.High()
+	println("spi id:", r[1])
+
+	cs.Low()
+	bus.Tx([]byte{0x6b, 0x01, 0x02}, nil)
+	cs.High()
+	println("spi write:", sensor.Get(0x6b), sensor.Get(0x6c))
+
+	b, _ := bus.Transfer(0x80)
+	println("unselected:", b)
+}
+
+func testFlash() {
+	const cs = machine.Pin(21)
+	bus := simulator.NewSPIBus()
+	flash := simulator.NewFlash(64 * 1024)
+	flash.JEDECID = [3]byte{0xc8, 0x40, 0x10}
+	bus.Attach(cs, flash)
+	cs.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	cs.High()
+
+	command := func(w []byte, r []byte) {
+		cs.Low()
+		bus.Tx(w, r)
+		cs.High()
+	}
+
+	id := make([]byte, 4)
+	command([]byte{0x9f, 0, 0, 0}, id)
+	println("jedec id:", id[1], id[2], id[3])
+
+	// A page program wraps around at the end of the page, and can only clear
+	// bits.
+	command([]byte{0x06}, nil)
+	command([]byte{0x02, 0, 0x00, 0xfe, 0x0f, 0x1f, 0x2f}, nil)
+	command([]byte{0x06}, nil)
+	command([]byte{0x02, 0, 0x00, 0xfe, 0xf1}, nil)
+	data := flash.Data()
+	println("page program:", data[0xfe], data[0xff], data[0x00], data[0x100])
+
+	r := make([]byte, 8)
+	command([]byte{0x0b, 0, 0x00, 0xfe, 0, 0, 0, 0}, r)
+	println("fast read:", r[5], r[6], r[7])
+}
+
+func testSSD1306I2C() {
+	display := simulator.NewSSD1306(16, 16)
+	simulator.AttachI2C(0, 0x3c, display)
+	bus := machine.I2C0
+	command := func(cmd ...byte) {
+		for _, c := range cmd {
+			bus.WriteRegister(0x3c, 0x80, []byte{c})
+		}
+	}
+	command(0xae, 0x81, 0x40, 0xa7, 0xaf)
+	println("display on:", display.On(), "inverted:", display.Inverted(), "contrast:", display.Contrast())
+
+	// Draw a diagonal line and a horizontal line in horizontal addressing
+	// mode, over the whole display.
+	command(0x20, 0x00, 0x21, 0, 15, 0x22, 0, 1)
+	buf := make([]byte, 32)
+	for x := 0; x < 16; x++ {
+		buf[x/8*16+x] |= 1 << uint(x%8)
+		buf[16+x] |= 1 << 7
+	}
+	bus.WriteRegister(0x3c, 0x40, buf)
+	print(display.String())
+
+	// Write a single column in page addressing mode.
+	command(0x20, 0x02, 0xb1, 0x02, 0x10)
+	bus.WriteRegister(0x3c, 0x40, []byte{0xff})
+	println("pixel:", display.Pixel(2, 8), display.Pixel(2, 14), display.Pixel(3, 8))
+}
+
+func testSSD1306SPI() {
+	const (
+		cs = machine.Pin(22)
+		dc = machine.Pin(23)
+	)
+	display := simulator.NewSSD1306SPI(8, 16, dc)
+	simulator.AttachSPI(0, cs, display)
+	cs.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	dc.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	cs.High()
+	bus := machine.SPI0
+
+	cs.Low()
+	dc.Low()
+	bus.Tx([]byte{0x20, 0x01, 0x21, 2, 3, 0x22, 0, 1}, nil) // vertical addressing
+	dc.High()
+	bus.Tx([]byte{0x81, 0x42, 0x24, 0x18}, nil)
+	cs.High()
+	print(display.String())
+}
+
+func testChipSelect() {
+	const cs = machine.Pin(22)
+	bus := simulator.NewSPIBus()
+	flash := simulator.NewFlash(4096)
+	bus.Attach(cs, flash)
+
+	// A chip select pin that is not configured as an output does not select
+	// the device, even though it reads low.
+	b, _ := bus.Transfer(0x9f)
+	println("unconfigured cs:", b)
+
+	cs.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	cs.High()
+	id := make([]byte, 4)
+	cs.Low()
+	bus.Tx([]byte{0x9f, 0, 0, 0}, id)
+	cs.High()
+	println("configured cs:", id[1], id[2], id[3])
+
+	// Reset detaches the device, as its chip select pin is reset.
+	simulator.Reset()
+	cs.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	cs.Low()
+	b, _ = bus.Transfer(0x9f)
+	cs.High()
+	println("after reset:", b)
+}
@@ -0,0 +1,58 @@
+package main
+
+// Test a UART of the hardware simulator that is connected to a pseudo
+// terminal, like a serial port on the host. The test program opens the other
+// end of the terminal itself.
+
+import (
+	"machine"
+	"machine/simulator"
+	"os"
+	"strings"
+	"time"
+)
+
+func main() {
+	pty, err := simulator.AttachPTY(1)
+	if err != nil {
+		println("could not open pty:", err.Error())
+		return
+	}
+	println("name:", strings.HasPrefix(pty.Name(), "/dev/pts/"))
+	uart := machine.UART{Bus: 1}
+	uart.Configure(machine.UARTConfig{})
+
+	// Reading doesn't block when no data is available.
+	buf := make([]byte, 16)
+	n, _ := uart.Read(buf)
+	println("nothing received:", n)
+
+	terminal, err := os.OpenFile(pty.Name(), os.O_RDWR, 0)
+	if err != nil {
+		println("could not open terminal:", err.Error())
+		return
+	}
+
+	// Data written to the terminal is received by the UART. The terminal
+	// passes it on asynchronously, so it may take a moment to arrive.
+	terminal.Write([]byte("ping"))
+	received := ""
+	for i := 0; i < 100 && len(received) < 4; i++ {
+		n, _ := uart.Read(buf)
+		received += string(buf[:n])
+		time.Sleep(10 * time.Millisecond)
+	}
+	println("received:", received)
+
+	// Data sent by the UART can be read from the terminal. The terminal is in
+	// line mode, so the line must be terminated.
+	uart.Write([]byte("pong\n"))
+	n, err = terminal.Read(buf)
+	if err != nil {
+		println("could not read from terminal:", err.Error())
+	}
+	println("sent:", strings.TrimSpace(string(buf[:n])))
+
+	terminal.Close()
+	println("closed:", pty.Close() == nil)
+}
@@ -0,0 +1,177 @@
+package main
+
+// Test the hardware simulator behind the generic machine package.
+
+import (
+	"machine"
+	"machine/simulator"
+)
+
+func main() {
+	testGPIO()
+	testI2C()
+	testI2CTransferHook()
+	testSPIFlash()
+	testUART()
+	testAnalog()
+}
+
+func testGPIO() {
+	led := simulator.AttachLED(1)
+	pin := machine.Pin(1)
+	pin.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	for i := 0; i < 3; i++ {
+		pin.High()
+		pin.Low()
+	}
+	pin.High()
+	println("led on:", led.On(), "changes:", led.Changes(), "get:", pin.Get())
+
+	button := simulator.AttachButton(2)
+	pin = machine.Pin(2)
+	pin.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
+	println("button released:", pin.Get())
+	button.Press()
+	println("button pressed:", pin.Get())
+	button.Release()
+
+	pin = machine.Pin(3)
+	pin.Configure(machine.PinConfig{Mode: machine.PinInputPulldown})
+	println("unconnected pulldown:", pin.Get())
+}
+
+func testI2C() {
+	sensor, err := simulator.NewScriptedI2CFromJSON([]byte(`[
+		{"write": [117], "read": [104]},
+		{"write": [107, 0]},
+		{"write": [59], "read": [1, 2, 3, 4]},
+		{"nack": true}
+	]`))
+	if err != nil {
+		println("could not parse script:", err.Error())
+		return
+	}
+	simulator.AttachI2C(0, 0x68, sensor)
+
+	bus := machine.I2C0
+	bus.Configure(machine.I2CConfig{})
+	id := make([]byte, 1)
+	err = bus.ReadRegister(0x68, 117, id)
+	println("who am i:", id[0], err == nil)
+	err = bus.WriteRegister(0x68, 107, []byte{0})
+	println("wake up:", err == nil)
+	data := make([]byte, 6)
+	err = bus.ReadRegister(0x68, 59, data)
+	println("data:", data[0], data[1], data[2], data[3], data[4], data[5], err == nil)
+	err = bus.Tx(0x68, nil, nil)
+	println("nack:", err != nil)
+	println("script done:", sensor.Err() == nil)
+
+	err = bus.Tx(0x10, []byte{1}, nil)
+	println("no device:", err != nil)
+
+	_, err = simulator.NewScriptedI2CFromJSON([]byte(`[{"write": [256]}]`))
+	println("invalid script:", err != nil)
+}
+
+// The I2C hooks of the generic machine package, as called by a driver that
+// doesn't use machine.I2C.
+//export __tinygo_i2c_set_address
+func i2cSetAddress(bus uint8, addr uint16)
+
+//export __tinygo_i2c_transfer
+func i2cTransfer(bus uint8, w *byte, wlen int, r *byte, rlen int) int
+
+func testI2CTransferHook() {
+	sensor := simulator.NewRegisterFile()
+	sensor.Set(0x0f, 0x33)
+	simulator.AttachI2C(1, 0x19, sensor)
+	other := simulator.NewRegisterFile()
+	other.Set(0x0f, 0x44)
+	simulator.AttachI2C(1, 0x1a, other)
+
+	// The transfer goes to the device at the address that was set last.
+	w := []byte{0x0f}
+	r := make([]byte, 1)
+	i2cSetAddress(1, 0x1a)
+	result := i2cTransfer(1, &w[0], len(w), &r[0], len(r))
+	println("transfer hook:", result, r[0])
+	i2cSetAddress(1, 0x19)
+	result = i2cTransfer(1, &w[0], len(w), &r[0], len(r))
+	println("transfer hook:", result, r[0])
+
+	i2cSetAddress(1, 0x20)
+	result = i2cTransfer(1, &w[0], len(w), &r[0], len(r))
+	println("transfer hook without device:", result)
+}
+
+func testSPIFlash() {
+	const cs = machine.Pin(10)
+	flash := simulator.AttachFlash(0, cs, 1<<20)
+	cs.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	cs.High()
+	spi := machine.SPI0
+	spi.Configure(machine.SPIConfig{})
+
+	command := func(w []byte, r []byte) {
+		cs.Low()
+		spi.Tx(w, r)
+		cs.High()
+	}
+
+	id := make([]byte, 4)
+	command([]byte{0x9f, 0, 0, 0}, id)
+	println("jedec id:", id[1], id[2], id[3])
+
+	// Page program without write enable is ignored.
+	command([]byte{0x02, 0, 0x01, 0x00, 0x12, 0x34}, nil)
+	command([]byte{0x06}, nil)
+	status := make([]byte, 2)
+	command([]byte{0x05, 0}, status)
+	println("status:", status[1])
+	command([]byte{0x02, 0, 0x01, 0x00, 0x12, 0x34}, nil)
+	command([]byte{0x05, 0}, status)
+	println("status after program:", status[1])
+
+	data := make([]byte, 7)
+	command([]byte{0x03, 0, 0x00, 0xff, 0, 0, 0}, data)
+	println("read:", data[4], data[5], data[6])
+	println("data:", flash.Data()[0x100], flash.Data()[0x101])
+
+	command([]byte{0x06}, nil)
+	command([]byte{0x20, 0, 0x01, 0x00}, nil)
+	println("erased:", flash.Data()[0x100], flash.Data()[0x101])
+
+	// Nothing selected.
+	r, _ := spi.Transfer(0x9f)
+	println("unselected:", r)
+}
+
+func testUART() {
+	terminal := simulator.AttachTerminal(0)
+	uart := machine.UART0
+	uart.Configure(machine.UARTConfig{})
+	terminal.Type("hi")
+	buf := make([]byte, 8)
+	n, _ := uart.Read(buf)
+	println("received:", string(buf[:n]))
+	uart.Write([]byte("hello"))
+	uart.WriteByte('!')
+	println("sent:", terminal.Output())
+}
+
+func testAnalog() {
+	simulator.SetAnalog(4, 0x1234)
+	adc := machine.ADC{Pin: 4}
+	adc.Configure(machine.ADCConfig{})
+	println("adc:", adc.Get())
+
+	led := simulator.AttachLED(5)
+	pwm := machine.PWM{Pin: 5}
+	pwm.Configure()
+	pwm.Set(0x8000)
+	println("pwm:", simulator.PWMValue(5), led.Brightness())
+
+	simulator.Reset()
+	println("after reset:", machine.Pin(1).Get(), adc.Get())
+}
@@ -0,0 +1,34 @@
+package main
+
+// Test I/O on WASI: printing from an exported function (which must not be a
+// blocking operation with the coroutines scheduler) and reading from standard
+// input while another goroutine is waiting. Standard input is empty in the
+// test, so the read returns immediately instead of parking the goroutine.
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	hello()
+
+	done := make(chan struct{})
+	go func() {
+		buf := make([]byte, 16)
+		n, err := os.Stdin.Read(buf)
+		if err != nil && err != io.EOF {
+			fmt.Println("read error:", err)
+		}
+		fmt.Println("read:", n)
+		close(done)
+	}()
+	<-done
+	fmt.Println("done")
+}
+
+//export hello
+func hello() {
+	fmt.Println("hello from an exported function")
+}
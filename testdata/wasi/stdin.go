@@ -0,0 +1,40 @@
+package main
+
+// Test that a goroutine reading from standard input is parked with the tasks
+// scheduler, so that other goroutines keep running. The test feeds standard
+// input through a pipe, after a delay.
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+func main() {
+	done := make(chan string)
+	go func() {
+		buf := make([]byte, 16)
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			fmt.Println("read error:", err)
+		}
+		done <- string(buf[:n])
+	}()
+
+	// Keep printing (to standard error, to keep the output deterministic) until
+	// the read finishes. If the read blocks the whole program, this goroutine
+	// doesn't get to run while waiting for input.
+	ticks := 0
+	for {
+		select {
+		case s := <-done:
+			fmt.Printf("read: %q\n", s)
+			fmt.Println("printed while waiting:", ticks >= 2)
+			return
+		default:
+		}
+		fmt.Fprintln(os.Stderr, "tick")
+		ticks++
+		time.Sleep(50 * time.Millisecond)
+	}
+}
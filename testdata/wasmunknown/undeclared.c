@@ -0,0 +1,6 @@
+__attribute__((import_module("env"), import_name("undeclared")))
+int undeclared(void);
+
+int callUndeclared(void) {
+	return undeclared();
+}
@@ -0,0 +1,11 @@
+package main
+
+// The C code imports a function from the host that isn't declared with
+// //go:wasm-module, so building for wasm-unknown must fail.
+
+// int callUndeclared(void);
+import "C"
+
+func main() {
+	println(C.callUndeclared())
+}
@@ -0,0 +1,136 @@
+// +build go1.14
+
+package wasm
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestNodeSyscallJS tests syscall/js and the generated ES module loader in
+// Node.js, which the wasm target also uses as emulator.
+func TestNodeSyscallJS(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node not found:", err)
+	}
+
+	tmpDir, err := ioutil.TempDir("", "wasm_test")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// The loader is written next to the .wasm file, as syscalljs_wasm.mjs.
+	err = run("tinygo build -o " + tmpDir + "/syscalljs_wasm.wasm -target wasm -js-loader testdata/syscalljs.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"syscalljs_wasm.mjs", "syscalljs_wasm.d.ts"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+			t.Fatal("loader not generated:", err)
+		}
+	}
+	runner, err := ioutil.ReadFile("testdata/syscalljs.mjs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(tmpDir, "syscalljs.mjs"), runner, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := exec.Command("node", filepath.Join(tmpDir, "syscalljs.mjs")).CombinedOutput()
+	if err != nil {
+		t.Fatalf("node failed: %v\n%s", err, output)
+	}
+	expected := `Math.max: 7
+String: 42
+callback: 21
+answer: 42 true
+deleted: true
+copied to JS: 4 4 4
+copied to Go: 2 10 2
+main done
+double: 42
+join: hello, world
+multiply: 42
+isPositive: true false
+sum: 10
+aligned_alloc: 0 10
+posix_memalign: 0 0 3
+posix_memalign invalid: 28
+`
+	if actual := string(output); strings.TrimSpace(actual) != strings.TrimSpace(expected) {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", actual, expected)
+	}
+
+	declarations, err := ioutil.ReadFile(filepath.Join(tmpDir, "syscalljs_wasm.d.ts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, decl := range []string{
+		"export declare function multiply(a: number, b: number): number;",
+		"export declare function isPositive(x: number): boolean;",
+		"export declare function collect(): void;",
+		"export declare function sum(ptr: number, n: number): number;",
+	} {
+		if !strings.Contains(string(declarations), decl) {
+			t.Errorf("missing TypeScript declaration: %s", decl)
+		}
+	}
+}
+
+// TestNodeCanonicalABI passes strings, slices, structs and multiple results
+// in both directions between Go and the host using the canonical ABI, with
+// Node.js providing WASI and the imported functions.
+func TestNodeCanonicalABI(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node not found:", err)
+	}
+
+	tmpDir, err := ioutil.TempDir("", "wasm_test")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	err = run("tinygo build -o " + tmpDir + "/canonical.wasm -target wasi -wasm-abi=canonical testdata/canonical.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner, err := ioutil.ReadFile("testdata/canonical.mjs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(tmpDir, "canonical.mjs"), runner, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only read stdout: Node.js warns on stderr that WASI is experimental.
+	cmd := exec.Command("node", filepath.Join(tmpDir, "canonical.mjs"))
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("node failed: %v\n%s", err, output)
+	}
+	expected := `reverse: olleh
+minmax: -1 7
+move: GO 11 2 false
+greet: hello, world
+sum: 10
+shout: hi! 3 -4 true
+divmod: 3n 2n
+`
+	if actual := string(output); strings.TrimSpace(actual) != strings.TrimSpace(expected) {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", actual, expected)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+// Exports and imports using the canonical ABI. The host side is implemented in
+// canonical.mjs.
+
+type point struct {
+	X, Y int32
+}
+
+type label struct {
+	Text string
+	Pos  point
+	Bold bool
+}
+
+//go:wasm-module host
+//export reverse
+func hostReverse(s string) string
+
+//go:wasm-module host
+//export minmax
+func hostMinMax(values []int32) (min, max int32)
+
+//go:wasm-module host
+//export move
+func hostMove(l label, dx int32) label
+
+func main() {
+	println("reverse:", hostReverse("hello"))
+	min, max := hostMinMax([]int32{3, -1, 7})
+	println("minmax:", min, max)
+	l := hostMove(label{Text: "go", Pos: point{1, 2}, Bold: true}, 10)
+	println("move:", l.Text, l.Pos.X, l.Pos.Y, l.Bold)
+}
+
+//export greet
+func greet(name string) string {
+	return "hello, " + name
+}
+
+//export sum
+func sum(values []int32) int32 {
+	total := int32(0)
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+//export shout
+func shout(l label) label {
+	l.Text += "!"
+	l.Pos.Y = -l.Pos.Y
+	l.Bold = !l.Bold
+	return l
+}
+
+//export divmod
+func divmod(a, b int64) (int64, int64) {
+	return a / b, a % b
+}
@@ -0,0 +1,83 @@
+// Host side of canonical.go: implements the imports and calls the exports,
+// passing values through linear memory as described by the canonical ABI.
+import { readFile } from "fs/promises";
+import { WASI } from "wasi";
+
+const encoder = new TextEncoder();
+const decoder = new TextDecoder();
+let exports;
+
+function memory() {
+	return new DataView(exports.memory.buffer);
+}
+
+// Copy the string to memory allocated with cabi_realloc and return the pointer
+// and length.
+function lowerString(s) {
+	const bytes = encoder.encode(s);
+	const ptr = exports.cabi_realloc(0, 0, 1, bytes.length);
+	new Uint8Array(exports.memory.buffer, ptr, bytes.length).set(bytes);
+	return [ptr, bytes.length];
+}
+
+function liftString(ptr, len) {
+	return decoder.decode(new Uint8Array(exports.memory.buffer, ptr, len));
+}
+
+// Read a label (string, point, bool) from the given return area.
+function liftLabel(retarea) {
+	const mem = memory();
+	const values = [0, 4, 8, 12, 16].map((offset) => mem.getInt32(retarea + offset, true));
+	return {
+		text: liftString(values[0], values[1]),
+		x: values[2],
+		y: values[3],
+		bold: values[4] !== 0,
+	};
+}
+
+const host = {
+	reverse(ptr, len, retarea) {
+		const [rptr, rlen] = lowerString([...liftString(ptr, len)].reverse().join(""));
+		const mem = memory();
+		mem.setInt32(retarea, rptr, true);
+		mem.setInt32(retarea + 4, rlen, true);
+	},
+	minmax(ptr, len, retarea) {
+		const values = new Int32Array(exports.memory.buffer, ptr, len);
+		const mem = memory();
+		mem.setInt32(retarea, Math.min(...values), true);
+		mem.setInt32(retarea + 4, Math.max(...values), true);
+	},
+	move(textPtr, textLen, x, y, bold, dx, retarea) {
+		const [ptr, len] = lowerString(liftString(textPtr, textLen).toUpperCase());
+		const mem = memory();
+		for (const [offset, value] of [[0, ptr], [4, len], [8, x + dx], [12, y], [16, bold ? 0 : 1]]) {
+			mem.setInt32(retarea + offset, value, true);
+		}
+	},
+};
+
+const wasi = new WASI({ version: "preview1", returnOnExit: true });
+const source = await readFile(new URL("./canonical.wasm", import.meta.url));
+const { instance } = await WebAssembly.instantiate(source, {
+	wasi_snapshot_preview1: wasi.wasiImport,
+	host,
+});
+exports = instance.exports;
+wasi.start(instance);
+
+let retarea = exports.greet(...lowerString("world"));
+console.log("greet:", liftString(memory().getInt32(retarea, true), memory().getInt32(retarea + 4, true)));
+
+const values = [1, 2, 3, 4];
+const ptr = exports.cabi_realloc(0, 0, 4, values.length * 4);
+new Int32Array(exports.memory.buffer, ptr, values.length).set(values);
+console.log("sum:", exports.sum(ptr, values.length));
+
+const [textPtr, textLen] = lowerString("hi");
+const label = liftLabel(exports.shout(textPtr, textLen, 3, 4, 0));
+console.log("shout:", label.text, label.x, label.y, label.bold);
+
+retarea = exports.divmod(17n, 5n);
+console.log("divmod:", memory().getBigInt64(retarea, true), memory().getBigInt64(retarea + 8, true));
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"runtime"
+	"syscall/js"
+	"unsafe"
+)
+
+func main() {
+	global := js.Global()
+
+	// Value.Call and Value.Invoke.
+	println("Math.max:", global.Get("Math").Call("max", 3, 7).Int())
+	println("String:", global.Get("String").Invoke(42).String())
+	println("callback:", global.Call("jsCallback", 20).Int())
+
+	// Objects.
+	obj := global.Get("Object").New()
+	obj.Set("answer", 42)
+	println("answer:", obj.Get("answer").Int(), obj.Get("missing").IsUndefined())
+	obj.Delete("answer")
+	println("deleted:", obj.Get("answer").IsUndefined())
+
+	// Typed arrays.
+	arr := global.Get("Uint8Array").New(4)
+	n := js.CopyBytesToJS(arr, []byte{1, 2, 3, 4, 5})
+	println("copied to JS:", n, arr.Length(), arr.Index(3).Int())
+	arr.SetIndex(0, 10)
+	buf := make([]byte, 2)
+	n = js.CopyBytesToGo(buf, arr)
+	println("copied to Go:", n, buf[0], buf[1])
+
+	// Functions called from JavaScript.
+	global.Set("double", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		return args[0].Int() * 2
+	}))
+	global.Set("join", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		return args[0].String() + ", " + args[1].String()
+	}))
+	println("main done")
+
+	// Keep running, so that the functions above can be called.
+	select {}
+}
+
+//export multiply
+func multiply(a, b int32) int32 {
+	return a * b
+}
+
+//export isPositive
+func isPositive(x float64) bool {
+	return x > 0
+}
+
+// collect runs a garbage collection cycle and then allocates memory, which
+// reuses any memory that was freed. The host calls it after allocating a buffer
+// with malloc and before passing it to sum, so that no Go code refers to the
+// buffer during the collection: only the pin keeps it alive.
+//export collect
+func collect() {
+	runtime.GC()
+	for i := 0; i < 64; i++ {
+		garbage := make([]byte, 64)
+		for j := range garbage {
+			garbage[j] = 0xff
+		}
+		keep = append(keep, garbage)
+	}
+}
+
+// keep holds the allocations made by collect, so that they are not freed
+// again before the buffer is used.
+var keep [][]byte
+
+// sum adds the bytes in a buffer allocated by the host with malloc. If the
+// buffer had been collected by the call to collect, it would have been
+// overwritten with 0xff bytes.
+//export sum
+func sum(ptr *byte, n int32) int32 {
+	buf := (*[1 << 16]byte)(unsafe.Pointer(ptr))[:n:n]
+	keep = nil
+	total := int32(0)
+	for _, b := range buf {
+		total += int32(b)
+	}
+	return total
+}
@@ -0,0 +1,31 @@
+// Runs syscalljs.wasm through the ES module loader generated by TinyGo.
+import { load, multiply, isPositive, collect, sum } from "./syscalljs_wasm.mjs";
+
+globalThis.jsCallback = (x) => x + 1;
+
+const instance = await load();
+console.log("double:", globalThis.double(21));
+console.log("join:", globalThis.join("hello", "world"));
+console.log("multiply:", multiply(6, 7));
+console.log("isPositive:", isPositive(2.5), isPositive(-1));
+
+// Memory allocated by the host stays alive until it is freed, even when the
+// garbage collector runs while only the host refers to it.
+const ptr = instance.exports.malloc(4);
+new Uint8Array(instance.exports.memory.buffer, ptr, 4).set([1, 2, 3, 4]);
+collect();
+console.log("sum:", sum(ptr, 4));
+instance.exports.free(ptr);
+
+// The other entry points of the C allocator also allocate from the Go heap.
+const aligned = instance.exports.aligned_alloc(64, 10);
+console.log("aligned_alloc:", aligned % 64, instance.exports.malloc_usable_size(aligned));
+instance.exports.free(aligned);
+const memptr = instance.exports.malloc(4);
+const result = instance.exports.posix_memalign(memptr, 256, 3);
+const memaligned = new DataView(instance.exports.memory.buffer).getUint32(memptr, true);
+console.log("posix_memalign:", result, memaligned % 256, instance.exports.malloc_usable_size(memaligned));
+console.log("posix_memalign invalid:", instance.exports.posix_memalign(memptr, 3, 4));
+instance.exports.free(memaligned);
+instance.exports.free(memptr);
+process.exit(0);
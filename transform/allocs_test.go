@@ -2,9 +2,11 @@ package transform
 
 import (
 	"testing"
+
+	"github.com/tinygo-org/tinygo/compiler/irtest"
 )
 
 func TestAllocs(t *testing.T) {
 	t.Parallel()
-	testTransform(t, "testdata/allocs", OptimizeAllocs)
+	irtest.RunTransform(t, "testdata/allocs", OptimizeAllocs)
 }
@@ -2,9 +2,11 @@ package transform
 
 import (
 	"testing"
+
+	"github.com/tinygo-org/tinygo/compiler/irtest"
 )
 
 func TestFuncLowering(t *testing.T) {
 	t.Parallel()
-	testTransform(t, "testdata/func-lowering", LowerFuncValues)
+	irtest.RunTransform(t, "testdata/func-lowering", LowerFuncValues)
 }
@@ -3,19 +3,20 @@ package transform
 import (
 	"testing"
 
+	"github.com/tinygo-org/tinygo/compiler/irtest"
 	"tinygo.org/x/go-llvm"
 )
 
 func TestAddGlobalsBitmap(t *testing.T) {
 	t.Parallel()
-	testTransform(t, "testdata/gc-globals", func(mod llvm.Module) {
+	irtest.RunTransform(t, "testdata/gc-globals", func(mod llvm.Module) {
 		AddGlobalsBitmap(mod)
 	})
 }
 
 func TestMakeGCStackSlots(t *testing.T) {
 	t.Parallel()
-	testTransform(t, "testdata/gc-stackslots", func(mod llvm.Module) {
+	irtest.RunTransform(t, "testdata/gc-stackslots", func(mod llvm.Module) {
 		MakeGCStackSlots(mod)
 	})
 }
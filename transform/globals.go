@@ -1,9 +1,13 @@
 package transform
 
-import "tinygo.org/x/go-llvm"
+import (
+	"strings"
+
+	"tinygo.org/x/go-llvm"
+)
 
 // This file implements small transformations on globals (functions and global
-// variables) for specific ABIs/architectures.
+// variables), some of them for specific ABIs/architectures.
 
 // ApplyFunctionSections puts every function in a separate section. This makes
 // it possible for the linker to remove dead code. It is the equivalent of
@@ -32,3 +36,268 @@ func DisableTailCalls(mod llvm.Module) {
 		llvmFn = llvm.NextFunction(llvmFn)
 	}
 }
+
+// InternalizeSymbols gives internal linkage to the given global definitions,
+// usually the C functions and global variables that were linked in from
+// bitcode files with -lto. This allows the optimizer to inline them in their
+// callers and to remove them when they are unused. Symbols that must remain
+// visible to the linker keep their linkage: main (called from the libc startup
+// code), interrupt handlers (referenced by name from vector tables) and
+// exported symbols.
+func InternalizeSymbols(mod llvm.Module, names []string) {
+	keep := map[string]struct{}{
+		"main": {},
+	}
+	// Symbols in llvm.used may be referenced from assembly.
+	if used := mod.NamedGlobal("llvm.used"); !used.IsNil() && !used.Initializer().IsNil() {
+		initializer := used.Initializer()
+		for i := 0; i < initializer.OperandsCount(); i++ {
+			value := initializer.Operand(i)
+			if !value.IsAConstantExpr().IsNil() {
+				value = value.Operand(0)
+			}
+			keep[value.Name()] = struct{}{}
+		}
+	}
+
+	for _, name := range names {
+		if _, ok := keep[name]; ok || isInterruptHandlerName(name) {
+			continue
+		}
+		if fn := mod.NamedFunction(name); !fn.IsNil() {
+			if fn.IsDeclaration() {
+				continue
+			}
+			if !fn.GetStringAttributeAtIndex(-1, "wasm-export-name").IsNil() {
+				continue
+			}
+			if !fn.GetStringAttributeAtIndex(-1, "interrupt").IsNil() || !fn.GetStringAttributeAtIndex(-1, "signal").IsNil() {
+				// Interrupt handler on RISC-V or AVR.
+				continue
+			}
+			fn.SetLinkage(llvm.InternalLinkage)
+			continue
+		}
+		if global := mod.NamedGlobal(name); !global.IsNil() && !global.IsDeclaration() {
+			global.SetLinkage(llvm.InternalLinkage)
+		}
+	}
+}
+
+// isInterruptHandlerName returns whether the name is conventionally used for
+// an interrupt handler that is referenced from a vector table, such as
+// UART0_IRQHandler or SysTick_Handler (CMSIS) or __vector_5 (AVR).
+func isInterruptHandlerName(name string) bool {
+	return strings.HasSuffix(name, "_Handler") || strings.HasSuffix(name, "_IRQHandler") || strings.HasPrefix(name, "__vector_")
+}
+
+// MakeGlobalsConstant marks global variables as constant if it can prove they
+// are never written to after package initialization, which has been done at
+// compile time by interp at this point. Constant globals are placed in
+// read-only memory (usually flash) instead of RAM.
+//
+// A global is only made constant if its address does not escape: it may only
+// be loaded from or passed to functions known to only read from it. Pointers
+// to the global in the initializer of another global (such as the backing
+// array of a slice) are followed through loads from that other global.
+func MakeGlobalsConstant(mod llvm.Module) {
+	for global := mod.FirstGlobal(); !global.IsNil(); global = llvm.NextGlobal(global) {
+		if global.IsDeclaration() || global.IsGlobalConstant() || global.Section() != "" {
+			continue
+		}
+		switch global.Linkage() {
+		case llvm.InternalLinkage, llvm.PrivateLinkage:
+		default:
+			// The global may be written from outside the module.
+			continue
+		}
+		if mayBeWritten(global, trackedPointer, make(map[trackedValue]struct{})) {
+			continue
+		}
+		global.SetGlobalConstant(true)
+	}
+}
+
+// Kinds of values that are followed by mayBeWritten. All are derived from the
+// address of the global that is analyzed.
+const (
+	trackedPointer   = iota // pointer into the global
+	trackedIndirect         // pointer to memory that contains a trackedPointer
+	trackedAggregate        // struct or array that contains a trackedPointer
+	trackedInteger          // trackedPointer converted to an integer
+)
+
+type trackedValue struct {
+	value llvm.Value
+	kind  int
+}
+
+// readonlyParams lists runtime functions that only read from (and don't
+// capture) some of their pointer parameters, by parameter index.
+var readonlyParams = map[string][]int{
+	"runtime.hashmapBinaryGet": {0, 1},
+	"runtime.hashmapLen":       {0},
+	"runtime.hashmapStringGet": {0, 1},
+	"runtime.printstring":      {0},
+	"runtime.sliceCopy":        {1},
+	"runtime.stringConcat":     {0, 2},
+	"runtime.stringEqual":      {0, 2},
+	"runtime.stringFromBytes":  {0},
+	"runtime.stringLess":       {0, 2},
+	"runtime.stringToBytes":    {0},
+	"runtime.trackPointer":     {0},
+}
+
+// mayBeWritten returns whether the memory of the analyzed global may be written
+// through the given value, which is of the given kind (trackedPointer etc). It
+// returns true if this cannot be proven.
+func mayBeWritten(value llvm.Value, kind int, visited map[trackedValue]struct{}) bool {
+	key := trackedValue{value, kind}
+	if _, ok := visited[key]; ok {
+		// Already checked (or being checked).
+		return false
+	}
+	visited[key] = struct{}{}
+	for _, use := range getUses(value) {
+		if useMayWrite(value, use, kind, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// useMayWrite is a helper for mayBeWritten that checks a single use of the
+// value.
+func useMayWrite(value, use llvm.Value, kind int, visited map[trackedValue]struct{}) bool {
+	if !use.IsAGlobalVariable().IsNil() {
+		// The value is (part of) the initializer of another global.
+		if kind != trackedPointer && kind != trackedAggregate {
+			return true
+		}
+		return mayBeWritten(use, trackedIndirect, visited)
+	}
+	if !use.IsAConstantStruct().IsNil() || !use.IsAConstantArray().IsNil() {
+		if kind != trackedPointer && kind != trackedAggregate {
+			return true
+		}
+		return mayBeWritten(use, trackedAggregate, visited)
+	}
+
+	var opcode llvm.Opcode
+	switch {
+	case !use.IsAInstruction().IsNil():
+		opcode = use.InstructionOpcode()
+	case !use.IsAConstantExpr().IsNil():
+		opcode = use.Opcode()
+	default:
+		return true
+	}
+	switch opcode {
+	case llvm.Load:
+		if kind == trackedIndirect {
+			// The loaded value may be (or contain) the pointer.
+			switch use.Type().TypeKind() {
+			case llvm.PointerTypeKind:
+				return mayBeWritten(use, trackedPointer, visited)
+			case llvm.IntegerTypeKind:
+				return mayBeWritten(use, trackedInteger, visited)
+			case llvm.StructTypeKind, llvm.ArrayTypeKind:
+				if typeHasPointers(use.Type()) {
+					return mayBeWritten(use, trackedAggregate, visited)
+				}
+			}
+		}
+		return false
+	case llvm.Store:
+		if use.Operand(0) == value {
+			// The value itself is stored, so it escapes.
+			return true
+		}
+		// Storing to the global is a write, but storing to memory that
+		// contains a pointer to the global only replaces that pointer.
+		return kind == trackedPointer
+	case llvm.GetElementPtr:
+		if use.Operand(0) != value {
+			// Used as an index.
+			return true
+		}
+		return mayBeWritten(use, kind, visited)
+	case llvm.BitCast, llvm.PHI, llvm.Select:
+		return mayBeWritten(use, kind, visited)
+	case llvm.ICmp, llvm.Br, llvm.Switch:
+		return false
+	case llvm.PtrToInt:
+		if kind != trackedPointer {
+			return true
+		}
+		return mayBeWritten(use, trackedInteger, visited)
+	case llvm.IntToPtr:
+		if kind != trackedInteger {
+			return true
+		}
+		return mayBeWritten(use, trackedPointer, visited)
+	case llvm.Add, llvm.Sub, llvm.Mul, llvm.And, llvm.Or, llvm.Xor, llvm.Shl, llvm.LShr, llvm.AShr, llvm.UDiv, llvm.URem, llvm.Trunc, llvm.ZExt, llvm.SExt:
+		if kind != trackedInteger {
+			return true
+		}
+		return mayBeWritten(use, trackedInteger, visited)
+	case llvm.ExtractValue:
+		if kind != trackedAggregate {
+			return true
+		}
+		switch use.Type().TypeKind() {
+		case llvm.PointerTypeKind:
+			return mayBeWritten(use, trackedPointer, visited)
+		case llvm.IntegerTypeKind:
+			return mayBeWritten(use, trackedInteger, visited)
+		case llvm.StructTypeKind, llvm.ArrayTypeKind:
+			return mayBeWritten(use, trackedAggregate, visited)
+		}
+		return false
+	case llvm.InsertValue:
+		if kind != trackedPointer && kind != trackedAggregate {
+			return true
+		}
+		return mayBeWritten(use, trackedAggregate, visited)
+	case llvm.Call:
+		if kind != trackedPointer {
+			return true
+		}
+		return callMayWrite(use, value)
+	default:
+		return true
+	}
+}
+
+// callMayWrite returns whether the called function may write to the memory
+// pointed to by value, or may capture the pointer.
+func callMayWrite(call, value llvm.Value) bool {
+	fn := call.CalledValue()
+	if fn.IsAFunction().IsNil() {
+		// Function pointer call.
+		return true
+	}
+	if !fn.Type().ElementType().IsFunctionVarArg() && hasFlag(call, value, "readonly") && hasFlag(call, value, "nocapture") {
+		return false
+	}
+	name := fn.Name()
+	allowed := readonlyParams[name]
+	if strings.HasPrefix(name, "llvm.memcpy.") || strings.HasPrefix(name, "llvm.memmove.") {
+		allowed = []int{1} // source
+	}
+	for i := 0; i < call.OperandsCount()-1; i++ {
+		if call.Operand(i) != value {
+			continue
+		}
+		readonly := false
+		for _, index := range allowed {
+			if index == i {
+				readonly = true
+			}
+		}
+		if !readonly {
+			return true
+		}
+	}
+	return false
+}
@@ -3,12 +3,33 @@ package transform
 import (
 	"testing"
 
+	"github.com/tinygo-org/tinygo/compiler/irtest"
 	"tinygo.org/x/go-llvm"
 )
 
 func TestApplyFunctionSections(t *testing.T) {
 	t.Parallel()
-	testTransform(t, "testdata/globals-function-sections", func(mod llvm.Module) {
+	irtest.RunTransform(t, "testdata/globals-function-sections", func(mod llvm.Module) {
 		ApplyFunctionSections(mod)
 	})
 }
+
+func TestMakeGlobalsConstant(t *testing.T) {
+	t.Parallel()
+	irtest.RunTransform(t, "testdata/globals-constant", func(mod llvm.Module) {
+		MakeGlobalsConstant(mod)
+	})
+}
+
+func TestInternalizeSymbols(t *testing.T) {
+	t.Parallel()
+	irtest.RunTransform(t, "testdata/globals-internalize", func(mod llvm.Module) {
+		InternalizeSymbols(mod, []string{"add", "unused", "counter", "main", "UART0_IRQHandler", "exported", "used"})
+
+		// Unused C functions are removed once they are internal.
+		pm := llvm.NewPassManager()
+		defer pm.Dispose()
+		pm.AddGlobalDCEPass()
+		pm.Run(mod)
+	})
+}
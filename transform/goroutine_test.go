@@ -2,12 +2,14 @@ package transform
 
 import (
 	"testing"
+
+	"github.com/tinygo-org/tinygo/compiler/irtest"
 	"tinygo.org/x/go-llvm"
 )
 
 func TestGoroutineLowering(t *testing.T) {
 	t.Parallel()
-	testTransform(t, "testdata/coroutines", func(mod llvm.Module) {
+	irtest.RunTransform(t, "testdata/coroutines", func(mod llvm.Module) {
 		err := LowerCoroutines(mod, false)
 		if err != nil {
 			panic(err)
@@ -3,12 +3,13 @@ package transform
 import (
 	"testing"
 
+	"github.com/tinygo-org/tinygo/compiler/irtest"
 	"tinygo.org/x/go-llvm"
 )
 
 func TestInterfaceLowering(t *testing.T) {
 	t.Parallel()
-	testTransform(t, "testdata/interface", func(mod llvm.Module) {
+	irtest.RunTransform(t, "testdata/interface", func(mod llvm.Module) {
 		err := LowerInterfaces(mod)
 		if err != nil {
 			t.Error(err)
@@ -3,6 +3,7 @@ package transform
 import (
 	"testing"
 
+	"github.com/tinygo-org/tinygo/compiler/irtest"
 	"tinygo.org/x/go-llvm"
 )
 
@@ -10,7 +11,7 @@ func TestInterruptLowering(t *testing.T) {
 	t.Parallel()
 	for _, subtest := range []string{"avr", "cortexm"} {
 		t.Run(subtest, func(t *testing.T) {
-			testTransform(t, "testdata/interrupt-"+subtest, func(mod llvm.Module) {
+			irtest.RunTransform(t, "testdata/interrupt-"+subtest, func(mod llvm.Module) {
 				errs := LowerInterrupts(mod)
 				if len(errs) != 0 {
 					t.Fail()
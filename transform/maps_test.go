@@ -3,12 +3,13 @@ package transform
 import (
 	"testing"
 
+	"github.com/tinygo-org/tinygo/compiler/irtest"
 	"tinygo.org/x/go-llvm"
 )
 
 func TestOptimizeMaps(t *testing.T) {
 	t.Parallel()
-	testTransform(t, "testdata/maps", func(mod llvm.Module) {
+	irtest.RunTransform(t, "testdata/maps", func(mod llvm.Module) {
 		// Run optimization pass.
 		OptimizeMaps(mod)
 
@@ -2,9 +2,11 @@ package transform
 
 import (
 	"testing"
+
+	"github.com/tinygo-org/tinygo/compiler/irtest"
 )
 
 func TestReplacePanicsWithTrap(t *testing.T) {
 	t.Parallel()
-	testTransform(t, "testdata/panic", ReplacePanicsWithTrap)
+	irtest.RunTransform(t, "testdata/panic", ReplacePanicsWithTrap)
 }
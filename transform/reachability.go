@@ -0,0 +1,224 @@
+package transform
+
+// This file implements a report that explains why a function, global or type
+// is still present in the program after interfaces have been lowered and dead
+// code has been removed. It prints the shortest chain of references from a root
+// (such as an exported symbol) to the symbol in question, which makes it easier
+// to find the cause of code size regressions.
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"tinygo.org/x/go-llvm"
+)
+
+// reference is a single reference from one global value to another.
+type reference struct {
+	to   llvm.Value
+	call bool // whether the reference is a direct call
+}
+
+// reachabilityGraph contains all references between global values in a
+// module, and for each reachable global value the reference through which it
+// was first reached from a root.
+type reachabilityGraph struct {
+	references map[llvm.Value][]reference
+	roots      map[llvm.Value]string // root symbols with the reason they are a root
+	parents    map[llvm.Value]llvm.Value
+	calls      map[llvm.Value]bool // whether the symbol was reached with a call
+}
+
+// PrintReachability writes to w why the given symbol is included in the
+// program. The name may be the name of a function or global, or the name of a
+// named type (such as main.T), in which case the type code and methods of the
+// type are explained.
+func PrintReachability(w io.Writer, mod llvm.Module, name string) {
+	g := newReachabilityGraph(mod)
+
+	var symbols []llvm.Value
+	for global := mod.FirstGlobal(); !global.IsNil(); global = llvm.NextGlobal(global) {
+		if matchesReachabilityQuery(global.Name(), name) {
+			symbols = append(symbols, global)
+		}
+	}
+	for fn := mod.FirstFunction(); !fn.IsNil(); fn = llvm.NextFunction(fn) {
+		if matchesReachabilityQuery(fn.Name(), name) {
+			symbols = append(symbols, fn)
+		}
+	}
+	if len(symbols) == 0 {
+		fmt.Fprintf(w, "%s: not present in the program\n", name)
+		return
+	}
+
+	for _, symbol := range symbols {
+		if _, ok := g.parents[symbol]; !ok {
+			if _, isRoot := g.roots[symbol]; !isRoot {
+				fmt.Fprintf(w, "%s: not reachable from any root\n", symbol.Name())
+				continue
+			}
+		}
+		fmt.Fprintf(w, "%s is reachable through:\n", symbol.Name())
+
+		// Walk back from the symbol to a root.
+		var chain []llvm.Value
+		for value := symbol; ; value = g.parents[value] {
+			chain = append(chain, value)
+			if _, isRoot := g.roots[value]; isRoot {
+				break
+			}
+		}
+		for i := len(chain) - 1; i >= 0; i-- {
+			value := chain[i]
+			line := value.Name()
+			if i == len(chain)-1 {
+				line += " (" + g.roots[value] + ")"
+			} else if g.calls[value] {
+				line = "calls " + line
+			} else {
+				line = "references " + line
+			}
+			if description := describeSymbol(value); description != "" {
+				line += " (" + description + ")"
+			}
+			fmt.Fprintln(w, "   ", line)
+		}
+	}
+}
+
+// newReachabilityGraph collects all references in the module and determines
+// for every global value through which reference it is reachable, using a
+// breadth-first search so that the shortest chain is found.
+func newReachabilityGraph(mod llvm.Module) *reachabilityGraph {
+	g := &reachabilityGraph{
+		references: make(map[llvm.Value][]reference),
+		roots:      make(map[llvm.Value]string),
+		parents:    make(map[llvm.Value]llvm.Value),
+		calls:      make(map[llvm.Value]bool),
+	}
+
+	// Collect all references, and the roots of the graph.
+	var worklist []llvm.Value
+	addRoot := func(value llvm.Value, reason string) {
+		if _, ok := g.roots[value]; !ok {
+			g.roots[value] = reason
+			worklist = append(worklist, value)
+		}
+	}
+	for global := mod.FirstGlobal(); !global.IsNil(); global = llvm.NextGlobal(global) {
+		if global.IsDeclaration() {
+			continue
+		}
+		g.addReferences(global, global.Initializer(), false)
+		switch global.Linkage() {
+		case llvm.InternalLinkage, llvm.PrivateLinkage:
+		case llvm.AppendingLinkage:
+			// Special globals like llvm.used.
+			addRoot(global, "used by the compiler")
+		default:
+			addRoot(global, "exported symbol")
+		}
+	}
+	for fn := mod.FirstFunction(); !fn.IsNil(); fn = llvm.NextFunction(fn) {
+		if fn.IsDeclaration() {
+			continue
+		}
+		for bb := fn.FirstBasicBlock(); !bb.IsNil(); bb = llvm.NextBasicBlock(bb) {
+			for inst := bb.FirstInstruction(); !inst.IsNil(); inst = llvm.NextInstruction(inst) {
+				numOperands := inst.OperandsCount()
+				for i := 0; i < numOperands; i++ {
+					operand := inst.Operand(i)
+					isCall := !inst.IsACallInst().IsNil() && i == numOperands-1
+					g.addReferences(fn, operand, isCall)
+				}
+			}
+		}
+		switch fn.Linkage() {
+		case llvm.InternalLinkage, llvm.PrivateLinkage:
+		default:
+			addRoot(fn, "exported symbol")
+		}
+	}
+
+	// Find the shortest path from any root to every reachable global value.
+	for len(worklist) != 0 {
+		value := worklist[0]
+		worklist = worklist[1:]
+		for _, ref := range g.references[value] {
+			if _, ok := g.parents[ref.to]; ok {
+				continue
+			}
+			if _, ok := g.roots[ref.to]; ok {
+				continue
+			}
+			g.parents[ref.to] = value
+			g.calls[ref.to] = ref.call
+			worklist = append(worklist, ref.to)
+		}
+	}
+	return g
+}
+
+// addReferences adds all global values referenced by the given value (which
+// may be a constant expression or aggregate) as references from the given
+// global value.
+func (g *reachabilityGraph) addReferences(from, value llvm.Value, call bool) {
+	if value.IsNil() {
+		return
+	}
+	if !value.IsAGlobalValue().IsNil() {
+		for i, ref := range g.references[from] {
+			if ref.to == value {
+				if call {
+					// Prefer describing the reference as a call.
+					g.references[from][i].call = true
+				}
+				return
+			}
+		}
+		g.references[from] = append(g.references[from], reference{value, call})
+		return
+	}
+	if value.IsAConstant().IsNil() {
+		// Instructions, arguments, basic blocks etc. are not global values.
+		return
+	}
+	for i := 0; i < value.OperandsCount(); i++ {
+		g.addReferences(from, value.Operand(i), false)
+	}
+}
+
+// matchesReachabilityQuery returns whether the symbol name matches the name
+// given on the command line: either the symbol itself, or the type code or a
+// method of a named type.
+func matchesReachabilityQuery(symbol, name string) bool {
+	return symbol == name ||
+		symbol == "reflect/types.type:named:"+name ||
+		strings.HasPrefix(symbol, "("+name+").") ||
+		strings.HasPrefix(symbol, "(*"+name+").")
+}
+
+// describeSymbol returns a short description of special symbols created by the
+// compiler or by the interface lowering pass, or the empty string for regular
+// functions and globals.
+func describeSymbol(value llvm.Value) string {
+	name := value.Name()
+	switch {
+	case strings.HasPrefix(name, "reflect/types.type:"):
+		return "type code, used by interfaces and reflect"
+	case strings.HasSuffix(name, "$methodset"):
+		return "method set of a type stored in an interface"
+	case strings.HasSuffix(name, "$invoke"):
+		return "method wrapper for interface calls"
+	case strings.HasSuffix(name, "$typeassert"):
+		return "interface type assertion"
+	case strings.HasSuffix(name, "$gowrapper"):
+		return "goroutine start wrapper"
+	case !value.IsAFunction().IsNil() && value.ParamsCount() >= 2 && llvm.PrevParam(value.LastParam()).Name() == "actualType":
+		// Created by getInterfaceMethodFunc.
+		return "interface method call"
+	}
+	return ""
+}
@@ -0,0 +1,37 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tinygo-org/tinygo/compiler/irtest"
+)
+
+func TestPrintReachability(t *testing.T) {
+	t.Parallel()
+	mod := irtest.LoadModule(t, "testdata/reachability.ll")
+	for _, tc := range []struct {
+		name     string
+		expected string
+	}{
+		{"main.T", `reflect/types.type:named:main.T is reachable through:
+    main (exported symbol)
+    calls main.main
+    references reflect/types.type:named:main.T (type code, used by interfaces and reflect)
+(*main.T).String is reachable through:
+    main (exported symbol)
+    calls main.main
+    calls (main.Stringer).String (interface method call)
+    calls (*main.T).String
+`},
+		{"main.dead", "main.dead: not reachable from any root\n"},
+		{"main.unused", "main.unused: not reachable from any root\n"},
+		{"main.missing", "main.missing: not present in the program\n"},
+	} {
+		buf := &strings.Builder{}
+		PrintReachability(buf, mod, tc.name)
+		if buf.String() != tc.expected {
+			t.Errorf("unexpected output for %s:\n%s", tc.name, buf.String())
+		}
+	}
+}
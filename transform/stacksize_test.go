@@ -4,12 +4,13 @@ import (
 	"testing"
 
 	"github.com/tinygo-org/tinygo/compileopts"
+	"github.com/tinygo-org/tinygo/compiler/irtest"
 	"tinygo.org/x/go-llvm"
 )
 
 func TestCreateStackSizeLoads(t *testing.T) {
 	t.Parallel()
-	testTransform(t, "testdata/stacksize", func(mod llvm.Module) {
+	irtest.RunTransform(t, "testdata/stacksize", func(mod llvm.Module) {
 		// Run optimization pass.
 		CreateStackSizeLoads(mod, &compileopts.Config{
 			Target: &compileopts.TargetSpec{
@@ -3,12 +3,13 @@ package transform
 import (
 	"testing"
 
+	"github.com/tinygo-org/tinygo/compiler/irtest"
 	"tinygo.org/x/go-llvm"
 )
 
 func TestOptimizeStringToBytes(t *testing.T) {
 	t.Parallel()
-	testTransform(t, "testdata/stringtobytes", func(mod llvm.Module) {
+	irtest.RunTransform(t, "testdata/stringtobytes", func(mod llvm.Module) {
 		// Run optimization pass.
 		OptimizeStringToBytes(mod)
 	})
@@ -0,0 +1,61 @@
+target datalayout = "e-m:e-p:32:32-Fi8-i64:64-v128:64:128-a:0:32-n32-S64"
+target triple = "armv7em-none-eabi"
+
+@main.table = internal global [4 x i8] c"\01\02\03\04"
+@main.written = internal global i32 0
+@main.exported = global i32 1
+@main.escaped = internal global i32 2
+@main.sliceBuf = internal global [3 x i32] [i32 1, i32 2, i32 3]
+@main.slice = internal global { i32*, i32, i32 } { i32* getelementptr inbounds ([3 x i32], [3 x i32]* @main.sliceBuf, i32 0, i32 0), i32 3, i32 3 }
+@main.writtenSliceBuf = internal global [3 x i32] [i32 1, i32 2, i32 3]
+@main.writtenSlice = internal global { i32*, i32, i32 } { i32* getelementptr inbounds ([3 x i32], [3 x i32]* @main.writtenSliceBuf, i32 0, i32 0), i32 3, i32 3 }
+
+declare void @main.use(i32*)
+
+declare i1 @runtime.stringEqual(i8*, i32, i8*, i32, i8*, i8*)
+
+; Only loads from the global: it can be made constant.
+define i8 @main.readTable(i32 %index) {
+  %ptr = getelementptr inbounds [4 x i8], [4 x i8]* @main.table, i32 0, i32 %index
+  %value = load i8, i8* %ptr
+  ret i8 %value
+}
+
+; The global is passed to a runtime function that only reads from it.
+define i1 @main.compareTable() {
+  %equal = call i1 @runtime.stringEqual(i8* getelementptr inbounds ([4 x i8], [4 x i8]* @main.table, i32 0, i32 0), i32 4, i8* getelementptr inbounds ([4 x i8], [4 x i8]* @main.table, i32 0, i32 0), i32 4, i8* undef, i8* null)
+  ret i1 %equal
+}
+
+define void @main.write() {
+  store i32 5, i32* @main.written
+  ret void
+}
+
+define i32 @main.readExported() {
+  %value = load i32, i32* @main.exported
+  ret i32 %value
+}
+
+; The address escapes to a function that may write to it.
+define void @main.escape() {
+  call void @main.use(i32* @main.escaped)
+  ret void
+}
+
+; The backing array of a slice is only read from.
+define i32 @main.readSlice(i32 %index) {
+  %buf = load i32*, i32** getelementptr inbounds ({ i32*, i32, i32 }, { i32*, i32, i32 }* @main.slice, i32 0, i32 0)
+  %ptr = getelementptr inbounds i32, i32* %buf, i32 %index
+  %value = load i32, i32* %ptr
+  ret i32 %value
+}
+
+; The backing array of a slice is written to through the slice.
+define void @main.writeSlice(i32 %index) {
+  %slice = load { i32*, i32, i32 }, { i32*, i32, i32 }* @main.writtenSlice
+  %buf = extractvalue { i32*, i32, i32 } %slice, 0
+  %ptr = getelementptr inbounds i32, i32* %buf, i32 %index
+  store i32 5, i32* %ptr
+  ret void
+}
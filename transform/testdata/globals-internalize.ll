@@ -0,0 +1,43 @@
+target datalayout = "e-m:e-p:32:32-Fi8-i64:64-v128:64:128-a:0:32-n32-S64"
+target triple = "armv7em-none-eabi"
+
+@counter = global i32 0
+@used = global i32 1
+@llvm.used = appending global [1 x i8*] [i8* bitcast (i32* @used to i8*)], section "llvm.metadata"
+
+; A Go function that calls a C function.
+define i32 @main.add(i32 %a, i32 %b) {
+  %result = call i32 @add(i32 %a, i32 %b)
+  ret i32 %result
+}
+
+; C function that is called from Go: it is made internal so it can be inlined.
+define i32 @add(i32 %a, i32 %b) {
+  %count = load i32, i32* @counter
+  %count.next = add i32 %count, 1
+  store i32 %count.next, i32* @counter
+  %result = add i32 %a, %b
+  ret i32 %result
+}
+
+; C function that is never called: it is removed.
+define void @unused() {
+  ret void
+}
+
+; Called from the libc startup code.
+define i32 @main() {
+  ret i32 0
+}
+
+; Referenced from the vector table.
+define void @UART0_IRQHandler() {
+  ret void
+}
+
+; Exported from a WebAssembly module.
+define void @exported() #0 {
+  ret void
+}
+
+attributes #0 = { "wasm-export-name"="exported" }
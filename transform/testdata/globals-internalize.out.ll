@@ -0,0 +1,33 @@
+target datalayout = "e-m:e-p:32:32-Fi8-i64:64-v128:64:128-a:0:32-n32-S64"
+target triple = "armv7em-none-eabi"
+
+@counter = internal global i32 0
+@used = global i32 1
+@llvm.used = appending global [1 x i8*] [i8* bitcast (i32* @used to i8*)], section "llvm.metadata"
+
+define i32 @main.add(i32 %a, i32 %b) {
+  %result = call i32 @add(i32 %a, i32 %b)
+  ret i32 %result
+}
+
+define internal i32 @add(i32 %a, i32 %b) {
+  %count = load i32, i32* @counter
+  %count.next = add i32 %count, 1
+  store i32 %count.next, i32* @counter
+  %result = add i32 %a, %b
+  ret i32 %result
+}
+
+define i32 @main() {
+  ret i32 0
+}
+
+define void @UART0_IRQHandler() {
+  ret void
+}
+
+define void @exported() #0 {
+  ret void
+}
+
+attributes #0 = { "wasm-export-name"="exported" }
@@ -0,0 +1,30 @@
+target datalayout = "e-m:e-p:32:32-Fi8-i64:64-v128:64:128-a:0:32-n32-S64"
+target triple = "armv7em-none-eabi"
+
+@"reflect/types.type:named:main.T" = internal constant i8 0
+@main.unused = internal global i32 0
+
+define void @main() {
+  call void @main.main()
+  ret void
+}
+
+define internal void @main.main() {
+  call void @"(main.Stringer).String"(i8* @"reflect/types.type:named:main.T", i8* null)
+  ret void
+}
+
+; Interface method thunk, as created by the interface lowering pass.
+define internal void @"(main.Stringer).String"(i8* %actualType, i8* %parentHandle) {
+  call void @"(*main.T).String"()
+  ret void
+}
+
+define internal void @"(*main.T).String"() {
+  ret void
+}
+
+define internal i32 @main.dead() {
+  %value = load i32, i32* @main.unused
+  ret i32 %value
+}
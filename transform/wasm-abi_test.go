@@ -3,12 +3,13 @@ package transform
 import (
 	"testing"
 
+	"github.com/tinygo-org/tinygo/compiler/irtest"
 	"tinygo.org/x/go-llvm"
 )
 
 func TestWasmABI(t *testing.T) {
 	t.Parallel()
-	testTransform(t, "testdata/wasm-abi", func(mod llvm.Module) {
+	irtest.RunTransform(t, "testdata/wasm-abi", func(mod llvm.Module) {
 		// Run ABI change pass.
 		err := ExternalInt64AsPtr(mod)
 		if err != nil {